@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+)
+
+const (
+	outputParameterName        = "output"
+	thumbnailSizeParameterName = "thumbnail-size"
+)
+
+// contactsheetCmd represents the contactsheet command
+var contactsheetCmd = &cobra.Command{
+	Use:   "contactsheet",
+	Short: "Generate a HTML contact sheet for every month of the archive",
+	Long: `Generate a HTML contact sheet for every month of the archive. Every sheet shows a grid of thumbnails linking to
+the original files. The thumbnails embedded in the EXIF data are used if present.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		archiveRoot := cmd.Flag(directoryParameterName).Value.String()
+		output := cmd.Flag(outputParameterName).Value.String()
+		if output == "" {
+			output = filepath.Join(archiveRoot, "contactsheets")
+		}
+		size, err := cmd.Flags().GetInt(thumbnailSizeParameterName)
+		if err != nil || size < 1 {
+			log.Printf("invalid thumbnail size: %v", err)
+			os.Exit(1)
+		}
+		pages, err := archive.NewContactSheetWriter(archiveRoot, output, size).WriteAll()
+		for _, p := range pages {
+			fmt.Println(p)
+		}
+		if err != nil {
+			log.Printf("failed to write contact sheets: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(contactsheetCmd)
+
+	contactsheetCmd.PersistentFlags().StringP(directoryParameterName, "", "", "archive directory")
+	contactsheetCmd.PersistentFlags().StringP(outputParameterName, "o", "", "directory to write the contact sheets to (default is DIRECTORY/contactsheets)")
+	contactsheetCmd.PersistentFlags().IntP(thumbnailSizeParameterName, "", 160, "maximum width and height of the thumbnails in pixels")
+}
@@ -1,22 +1,32 @@
 package cmd
 
 import (
-	"bufio"
-	"io"
 	"log"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/hikhvar/exifsorter/pkg/archive"
+	"github.com/hikhvar/exifsorter/pkg/extraction"
 )
 
 const (
-	directoryParameterName = "directory"
-	inputParameterName     = "input"
-	delimiterParameterName = "delimiter"
-	dryrunParameterName    = "dry-run"
+	directoryParameterName      = "directory"
+	inputParameterName          = "input"
+	delimiterParameterName      = "delimiter"
+	dryrunParameterName         = "dry-run"
+	keepParameterName           = "keep"
+	formatParameterName         = "format"
+	originFallbackParameterName = "origin-fallback"
+	metadataDatesParameterName  = "prefer-metadata-dates"
+	timeFormatParameterName     = "time-format"
+	subSecNamesParameterName    = "subsec-names"
+)
+
+const (
+	delimitedFormat = archive.DelimitedFormat
+	jsonFormat      = archive.JSONFormat
+	nulFormat       = archive.NULFormat
 )
 
 // dedupCmd represents the dedup command
@@ -29,37 +39,75 @@ var dedupCmd = &cobra.Command{
 		archiveRoot := cmd.Flag(directoryParameterName).Value.String()
 		inputFilePath := cmd.Flag(inputParameterName).Value.String()
 		delimiter := cmd.Flag("delimiter").Value.String()
+		format := cmd.Flag(formatParameterName).Value.String()
 
-		f, err := os.Open(inputFilePath)
-		if err != nil {
-			log.Printf("can't open input file: %s", err)
-			os.Exit(1)
+		f := os.Stdin
+		if inputFilePath != "-" {
+			var err error
+			f, err = os.Open(inputFilePath)
+			if err != nil {
+				log.Printf("can't open input file: %s", err)
+				os.Exit(1)
+			}
 		}
-		if len(delimiter) > 1 {
+		if format == delimitedFormat && len(delimiter) > 1 {
 			log.Printf("can only use a single character as delimiter. '%s' has the length %d", delimiter, len(delimiter))
 			os.Exit(1)
 		}
-		if len(delimiter) < 1 {
+		if format == delimitedFormat && len(delimiter) < 1 {
 			log.Printf("Empty string not allowed as delimiter")
 			os.Exit(1)
 		}
 
-		duplicates, err := readInput(f, delimiter)
-		if err != nil {
-			log.Printf("failed to read input file: %s", err)
-			os.Exit(1)
-		}
+		duplicates := archive.NewDuplicateReader(f, format, delimiter)
 
 		dryRun, err := cmd.PersistentFlags().GetBool(dryrunParameterName)
 		if err != nil {
 			log.Printf("expected dry-run flag, didn't found it: %s", err)
 		}
 
-		var fs archive.FileSystem = archive.NewOSFileSystem()
+		policy, err := archive.ParseKeepPolicy(cmd.Flag(keepParameterName).Value.String())
+		if err != nil {
+			log.Printf("invalid keep policy: %s", err)
+			os.Exit(1)
+		}
+
+		var opts []archive.DedupOption
+		if fallback, err := cmd.PersistentFlags().GetBool(originFallbackParameterName); err == nil && fallback {
+			opts = append(opts, archive.WithOriginFallback())
+		}
+		timeFormat, _ := cmd.PersistentFlags().GetString(timeFormatParameterName)
+		if err := archive.ValidTimeFormat(timeFormat); err != nil {
+			log.Printf("%s", err)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithDedupTimeFormat(timeFormat))
+		if subSec, err := cmd.PersistentFlags().GetBool(subSecNamesParameterName); err == nil && subSec {
+			opts = append(opts, archive.WithDedupSubSecondNames())
+		}
+		if prefer, err := cmd.PersistentFlags().GetBool(metadataDatesParameterName); err == nil && prefer {
+			opts = append(opts, archive.WithMetadataDates(extraction.CaptureDateWithSource))
+		}
+
 		if dryRun {
-			fs = archive.NewLoggingFileSystem()
+			summary, err := archive.PlanDeduplication(archiveRoot, duplicates, policy, os.Stat, opts...)
+			if err != nil {
+				log.Printf("failed to plan deduplication: %s", err)
+				os.Exit(1)
+			}
+			err = summary.Write(os.Stdout)
+			if err != nil {
+				log.Printf("failed to print deduplication plan: %s", err)
+				os.Exit(1)
+			}
+			return
 		}
-		err = archive.DeduplicateAll(archiveRoot, duplicates, fs)
+		dirMode, err := dirModeFromFlags(cmd.Flags())
+		if err != nil {
+			log.Printf("%s", err)
+			os.Exit(1)
+		}
+		err = archive.DeduplicateAll(archiveRoot, duplicates, policy, archive.NewOSFileSystem().WithDirPerm(dirMode), opts...)
 		if err != nil {
 			log.Printf("failed to deduplicate files: %s", err)
 			os.Exit(1)
@@ -67,16 +115,6 @@ var dedupCmd = &cobra.Command{
 	},
 }
 
-func readInput(reader io.Reader, delimiter string) ([][]string, error) {
-	var ret [][]string
-	s := bufio.NewScanner(reader)
-	for s.Scan() {
-		line := strings.Split(s.Text(), delimiter)
-		ret = append(ret, line)
-	}
-	return ret, s.Err()
-}
-
 func init() {
 	rootCmd.AddCommand(dedupCmd)
 
@@ -85,9 +123,16 @@ func init() {
 	// Cobra supports Persistent Flags which will work for this command
 	// and all subcommands, e.g.:
 	dedupCmd.PersistentFlags().StringP(directoryParameterName, "", "", "directory to deduplicate in")
-	dedupCmd.PersistentFlags().StringP(inputParameterName, "i", "", "path to a file with duplicated files")
+	dedupCmd.PersistentFlags().StringP(inputParameterName, "i", "", "path to a file with duplicated files or - for stdin")
 	dedupCmd.PersistentFlags().StringP(delimiterParameterName, "", " ", "delimiter used in the file given by INPUT")
+	dedupCmd.PersistentFlags().StringP(formatParameterName, "", delimitedFormat, "format of the file given by INPUT: delimited (one group per line), json (array of arrays) or nul (NUL separated files, groups terminated by an empty entry)")
 	dedupCmd.PersistentFlags().BoolP(dryrunParameterName, "", true, "don't deduplicate, only dry-run")
+	addDirModeFlag(dedupCmd.PersistentFlags())
+	dedupCmd.PersistentFlags().BoolP(originFallbackParameterName, "", false, "keep a file below origin if no duplicate is in a calendar directory instead of failing")
+	dedupCmd.PersistentFlags().BoolP(metadataDatesParameterName, "", false, "keep the file named with the capture date read from its meta data over duplicates named with another date, e.g. their modification time. The keep policy decides among them")
+	dedupCmd.PersistentFlags().String(timeFormatParameterName, "20060102_150405", "layout of the capture date in the archive file names, as given to sort --time-format. The earliest and latest policies and --origin-fallback read the dates with it")
+	dedupCmd.PersistentFlags().Bool(subSecNamesParameterName, false, "the archive was sorted with sort --subsec-names")
+	dedupCmd.PersistentFlags().StringP(keepParameterName, "", "first", "calendar file to keep: first (lexical), earliest or latest")
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+const dirModeParameterName = "dir-mode"
+
+// addDirModeFlag adds the flag for the permissions of created directories to the given flag set.
+func addDirModeFlag(flags *pflag.FlagSet) {
+	flags.String(dirModeParameterName, "0777", "octal permissions of the directories created in the archive, subject to the umask")
+}
+
+// dirModeFromFlags returns the permissions of created directories given by the flags.
+func dirModeFromFlags(flags *pflag.FlagSet) (os.FileMode, error) {
+	val, _ := flags.GetString(dirModeParameterName)
+	mode, err := strconv.ParseUint(val, 8, 32)
+	if err != nil || mode > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("invalid --%s '%s': expected octal permissions like 0755", dirModeParameterName, val)
+	}
+	return os.FileMode(mode), nil
+}
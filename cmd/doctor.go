@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/exploration"
+	"github.com/hikhvar/exifsorter/pkg/extraction"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor DIRECTORY",
+	Short: "Report where the capture dates of the media files of a directory are read from",
+	Long: `Report where the capture dates of the media files of a directory are read from, e.g. EXIF, video meta data
+or the modification time, followed by a sample of the files without a capture date in their meta data. Nothing is
+changed. Use it to judge whether a directory is well dated before sorting it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		samples, _ := cmd.Flags().GetInt("samples")
+		captureDate := extraction.CaptureDateWithSource
+		if set, err := cmd.Flags().GetBool("sidecar-dates"); err == nil && set {
+			captureDate = extraction.CaptureDateWithSidecarAndSource
+		}
+		_, files, err := exploration.InitialFiles(args[0], nil, exploration.OnWalkError(func(path string, err error) {
+			fmt.Printf("could not read %s: %s\n", path, err.Error())
+		}))
+		if err != nil {
+			fmt.Printf("could not list all files: %s\n", err.Error())
+			os.Exit(1)
+		}
+		report := extraction.NewSourceReport(samples)
+		for _, f := range files {
+			if voi, err := extraction.IsVideoOrImage(f); err != nil || !voi {
+				report.NotMedia++
+				continue
+			}
+			_, source, err := captureDate(f)
+			report.Add(f, source, err)
+		}
+		if err := report.Write(os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.PersistentFlags().Int("samples", 10, "number of files dated from their modification time to print")
+	doctorCmd.PersistentFlags().Bool("sidecar-dates", false, "use the date of XMP sidecar files for media files without an embedded capture date")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+// duplicatesCmd represents the duplicates command
+var duplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "Find calendar files with identical content in the archive",
+	Long: `Find calendar files with identical content in the archive. The groups of duplicates are printed in the input
+format of the dedup command, e.g.: exifsorter duplicates --directory DIR | exifsorter dedup --directory DIR --input -`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		archiveRoot := cmd.Flag(directoryParameterName).Value.String()
+		delimiter := cmd.Flag(delimiterParameterName).Value.String()
+		format := cmd.Flag(formatParameterName).Value.String()
+
+		duplicates, err := archive.FindDuplicates(archiveRoot, files.Hash)
+		if err != nil {
+			log.Printf("failed to find duplicates: %s", err)
+			os.Exit(1)
+		}
+		err = writeOutput(os.Stdout, duplicates, format, delimiter)
+		if err != nil {
+			log.Printf("failed to print duplicates: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// writeOutput writes the groups of duplicates in the given format archive.NewDuplicateReader understands.
+func writeOutput(w io.Writer, duplicates [][]string, format string, delimiter string) error {
+	switch format {
+	case delimitedFormat:
+		for _, group := range duplicates {
+			if _, err := fmt.Fprintln(w, strings.Join(group, delimiter)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case jsonFormat:
+		if duplicates == nil {
+			duplicates = [][]string{}
+		}
+		return json.NewEncoder(w).Encode(duplicates)
+	case nulFormat:
+		for _, group := range duplicates {
+			if _, err := io.WriteString(w, strings.Join(group, "\x00")+"\x00\x00"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s'", format)
+}
+
+func init() {
+	rootCmd.AddCommand(duplicatesCmd)
+
+	duplicatesCmd.PersistentFlags().StringP(directoryParameterName, "", "", "archive directory to search for duplicates")
+	duplicatesCmd.PersistentFlags().StringP(delimiterParameterName, "", " ", "delimiter between the files of a group in the delimited format")
+	duplicatesCmd.PersistentFlags().StringP(formatParameterName, "", delimitedFormat, "output format: delimited (one group per line), json (array of arrays) or nul (NUL separated files, groups terminated by an empty entry)")
+}
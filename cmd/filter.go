@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+)
+
+const (
+	sinceParameterName          = "since"
+	untilParameterName          = "until"
+	includeUndatedParameterName = "include-undated"
+	filterDateLayout            = "2006-01-02"
+)
+
+// addDateFilterFlags adds the flags of the capture date filter to the given flag set.
+func addDateFilterFlags(flags *pflag.FlagSet) {
+	flags.String(sinceParameterName, "", "only files captured at or after this date (YYYY-MM-DD or RFC3339)")
+	flags.String(untilParameterName, "", "only files captured before the end of this day (YYYY-MM-DD) or before this time (RFC3339)")
+	flags.Bool(includeUndatedParameterName, false, "also process files without a capture date in their meta data if --since or --until is given")
+}
+
+// dateFilterFromFlags returns the capture date filter given by the flags.
+func dateFilterFromFlags(flags *pflag.FlagSet) (archive.DateFilter, error) {
+	var f archive.DateFilter
+	var err error
+	since, _ := flags.GetString(sinceParameterName)
+	if f.Since, err = parseFilterDate(since, false); err != nil {
+		return f, fmt.Errorf("invalid --%s: %w", sinceParameterName, err)
+	}
+	until, _ := flags.GetString(untilParameterName)
+	if f.Until, err = parseFilterDate(until, true); err != nil {
+		return f, fmt.Errorf("invalid --%s: %w", untilParameterName, err)
+	}
+	f.IncludeUndated, _ = flags.GetBool(includeUndatedParameterName)
+	return f, nil
+}
+
+// parseFilterDate parses a date in local time or a RFC3339 timestamp. If endOfDay is set, dates are moved to the start
+// of the next day.
+func parseFilterDate(val string, endOfDay bool) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(filterDateLayout, val, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
+)
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect FILE...",
+	Short: "Print all EXIF fields of JPEG and TIFF files",
+	Long: `Print all EXIF fields of JPEG and TIFF files followed by the values derived from them: the capture date and
+where it was read from, the GPS position and the flash mode. Use it to debug why a file is sorted unexpectedly.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		failed := false
+		for i, f := range args {
+			if len(args) > 1 {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("%s:\n", f)
+			}
+			inspection, err := extraction.Inspect(f)
+			if err != nil {
+				fmt.Printf("could not inspect %s: %s\n", f, err.Error())
+				failed = true
+				continue
+			}
+			for _, field := range inspection.Fields {
+				fmt.Printf("%s: %s\n", field.Name, field.Value)
+			}
+			for _, field := range inspection.Derived {
+				fmt.Printf("%s (derived): %s\n", field.Name, field.Value)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+}
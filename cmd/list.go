@@ -16,7 +16,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/hikhvar/exifsorter/pkg/archive"
 	"github.com/hikhvar/exifsorter/pkg/exploration"
 	"github.com/hikhvar/exifsorter/pkg/extraction"
 	"github.com/spf13/cobra"
@@ -29,19 +32,30 @@ var listCmd = &cobra.Command{
 	Long:  `List the found exif meta data for a subdirectory`,
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		_, files, err := exploration.InitialFiles(args[0], nil)
+		filter, err := dateFilterFromFlags(cmd.Flags())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		_, files, err := exploration.InitialFiles(args[0], nil, exploration.OnWalkError(func(path string, err error) {
+			fmt.Printf("could not read %s: %s\n", path, err.Error())
+		}))
 		if err != nil {
 			fmt.Printf("could not list all files %s", err.Error())
 		}
+		captureDate, hasCaptureDate := extraction.CaptureDate, extraction.HasCaptureDate
+		if set, err := cmd.Flags().GetBool("sidecar-dates"); err == nil && set {
+			captureDate, hasCaptureDate = extraction.CaptureDateWithSidecar, extraction.HasCaptureDateWithSidecar
+		}
 		for _, f := range files {
 			voi, err := extraction.IsVideoOrImage(f)
 			if err != nil {
 				fmt.Printf("not a video or image %s: %s\n", f, err.Error())
 			} else if voi {
-				date, err := extraction.CaptureDate(f)
+				date, err := captureDate(f)
 				if err != nil {
 					fmt.Printf("could not determine capture date %s: %s\n", f, err.Error())
-				} else {
+				} else if !excluded(filter, hasCaptureDate, f, date) {
 					fmt.Printf("exif date of file %s is: %v\n", f, date)
 				}
 
@@ -50,6 +64,19 @@ var listCmd = &cobra.Command{
 	},
 }
 
+// excluded returns true if the file is skipped by the date filter.
+func excluded(filter archive.DateFilter, hasCaptureDate archive.CaptureDateChecker, fname string, date time.Time) bool {
+	if !filter.Active() {
+		return false
+	}
+	if !filter.IncludeUndated {
+		if dated, err := hasCaptureDate(fname); err != nil || !dated {
+			return true
+		}
+	}
+	return !filter.Includes(date)
+}
+
 func init() {
 	rootCmd.AddCommand(listCmd)
 
@@ -58,6 +85,8 @@ func init() {
 	// Cobra supports Persistent Flags which will work for this command
 	// and all subcommands, e.g.:
 	listCmd.PersistentFlags().StringP("directory", "d", "", "directory to list")
+	listCmd.PersistentFlags().Bool("sidecar-dates", false, "use the date of XMP sidecar files for media files without an embedded capture date")
+	addDateFilterFlags(listCmd.PersistentFlags())
 
 	// Cobra supports local flags which will only run when this command
 	// is called directly, e.g.:
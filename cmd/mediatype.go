@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+)
+
+const (
+	targetByExtensionParameterName = "target-by-extension"
+	photosDirParameterName         = "photos-dir"
+	videosDirParameterName         = "videos-dir"
+	mergedOriginParameterName      = "merged-origin"
+	deviceDirsParameterName        = "device-dirs"
+)
+
+// addMediaTypeFlags adds the flags to split the archive by media type and device to the given flag set.
+func addMediaTypeFlags(flags *pflag.FlagSet) {
+	flags.Bool(targetByExtensionParameterName, false, "sort images and videos into separate trees of the archive")
+	flags.String(photosDirParameterName, "photos", "directory of the images in the archive if --"+targetByExtensionParameterName+" is given")
+	flags.String(videosDirParameterName, "videos", "directory of the videos in the archive if --"+targetByExtensionParameterName+" is given")
+	flags.Bool(mergedOriginParameterName, false, "keep a single origin directory for images and videos if --"+targetByExtensionParameterName+" is given")
+	flags.StringSlice(deviceDirsParameterName, nil, "sort into a directory per device named by the values of these EXIF fields, e.g. Make,Model for Canon-EOS-5D/2019/04")
+}
+
+// mediaTypeOptionsFromFlags returns the options to split the archive by media type and device given by the flags.
+func mediaTypeOptionsFromFlags(flags *pflag.FlagSet) ([]archive.Option, error) {
+	var opts []archive.Option
+	if fields, _ := flags.GetStringSlice(deviceDirsParameterName); len(fields) > 0 {
+		opts = append(opts, archive.WithDeviceDirs(fields...))
+	}
+	if split, _ := flags.GetBool(targetByExtensionParameterName); !split {
+		return opts, nil
+	}
+	photos, _ := flags.GetString(photosDirParameterName)
+	videos, _ := flags.GetString(videosDirParameterName)
+	for name, dir := range map[string]string{photosDirParameterName: photos, videosDirParameterName: videos} {
+		if dir == "" || strings.ContainsAny(dir, `/\`) || dir == "." || dir == ".." || dir == "origin" || dir == "quarantine" {
+			return nil, fmt.Errorf("invalid --%s '%s': expected a single directory name other than origin and quarantine", name, dir)
+		}
+	}
+	if photos == videos {
+		return nil, fmt.Errorf("--%s and --%s must differ", photosDirParameterName, videosDirParameterName)
+	}
+	opts = append(opts, archive.WithMediaTypeDirs(photos, videos))
+	if merged, _ := flags.GetBool(mergedOriginParameterName); merged {
+		opts = append(opts, archive.WithMergedOrigin())
+	}
+	return opts, nil
+}
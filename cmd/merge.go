@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+)
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge <other archive>",
+	Short: "Import another archive into the archive in the given directory",
+	Long: `Import another archive into the archive in the given directory. Calendar files of the other archive are copied
+under their name without reading their meta data again, files already in the archive are skipped. The links below
+origin of the other archive are re-created below origin of the archive.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancelFunc := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancelFunc()
+		archiveRoot := cmd.Flag(directoryParameterName).Value.String()
+		dirMode, err := dirModeFromFlags(cmd.Flags())
+		if err != nil {
+			log.Printf("%s", err)
+			os.Exit(1)
+		}
+		opts := []archive.Option{archive.WithDirPerm(dirMode), archive.WithReporter(textReporter{})}
+		mediaTypeOpts, err := mediaTypeOptionsFromFlags(cmd.Flags())
+		if err != nil {
+			log.Printf("%s", err)
+			os.Exit(1)
+		}
+		opts = append(opts, mediaTypeOpts...)
+		if journalFile, _ := cmd.Flags().GetString("journal"); journalFile != "" {
+			journal, err := archive.OpenJournal(journalFile)
+			if err != nil {
+				log.Printf("%s", err)
+				os.Exit(1)
+			}
+			defer journal.Close()
+			opts = append(opts, archive.WithJournal(journal))
+		}
+		if set, _ := cmd.Flags().GetBool("lock"); set {
+			wait, _ := cmd.Flags().GetDuration("lock-wait")
+			opts = append(opts, archive.WithLock(wait))
+		}
+		a := archive.NewAlgorithm("", archiveRoot, opts...)
+		err = a.Init()
+		if err != nil {
+			log.Printf("failed to create target directories: %s%s", err, lockWaitHint(err))
+			os.Exit(1)
+		}
+		defer a.Close()
+		summary, mergeErr := a.Merge(ctx, args[0], nil)
+		if err := summary.Write(os.Stdout); err != nil {
+			log.Printf("failed to print summary: %s", err)
+		}
+		if mergeErr != nil {
+			log.Printf("failed to merge archive: %s", mergeErr)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+
+	mergeCmd.PersistentFlags().StringP(directoryParameterName, "", "", "archive directory to import into")
+	mergeCmd.PersistentFlags().String("journal", "", "append all created files and links to this journal file. The merge can be reverted with the undo command")
+	mergeCmd.PersistentFlags().Bool("lock", true, "lock the archive so that no other instance sorts into it at the same time")
+	mergeCmd.PersistentFlags().Duration("lock-wait", 0, "wait this long for another instance to release the lock of the archive instead of failing immediately")
+	addDirModeFlag(mergeCmd.PersistentFlags())
+	addMediaTypeFlags(mergeCmd.PersistentFlags())
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
+)
+
+const setParameterName = "set"
+
+// orientCmd represents the orient command
+var orientCmd = &cobra.Command{
+	Use:   "orient",
+	Short: "Rewrite the EXIF orientation tag of JPEG and TIFF files in place",
+	Long: `Rewrite the EXIF orientation tag of JPEG and TIFF files in place. Only the orientation value is changed, the
+image itself is not re-encoded. Nothing is written unless --set is given.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		orientation, err := cmd.Flags().GetInt(setParameterName)
+		if err != nil || orientation == 0 {
+			fmt.Println("no orientation given, use --set to rewrite the orientation tag")
+			os.Exit(1)
+		}
+		failed := false
+		for _, f := range args {
+			previous, err := extraction.SetOrientation(f, orientation)
+			if err != nil {
+				fmt.Printf("could not set orientation of %s: %s\n", f, err.Error())
+				failed = true
+				continue
+			}
+			fmt.Printf("orientation of %s changed from %d to %d\n", f, previous, orientation)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(orientCmd)
+
+	orientCmd.Flags().IntP(setParameterName, "", 0, "orientation (1-8) to write, 1 is the normalized orientation")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+)
+
+// pruneCmd represents the prune-empty-dirs command
+var pruneCmd = &cobra.Command{
+	Use:   "prune-empty-dirs",
+	Short: "Remove the empty directories of the archive in the given directory",
+	Long: `Remove the empty directories of the archive in the given directory, e.g. below origin after deduplicating. The
+archive directory itself and its origin and quarantine directories are kept.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		archiveRoot := cmd.Flag(directoryParameterName).Value.String()
+		fs := archive.NewOSFileSystem()
+		dryRun, err := cmd.PersistentFlags().GetBool(dryrunParameterName)
+		if err != nil {
+			log.Printf("expected dry-run flag, didn't found it: %s", err)
+		}
+		if dryRun {
+			fs = archive.NewLoggingFileSystem()
+		}
+		removed, err := archive.PruneEmptyDirs(archiveRoot, fs)
+		fmt.Printf("removed %d empty directories\n", removed)
+		if err != nil {
+			log.Printf("failed to prune empty directories: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.PersistentFlags().StringP(directoryParameterName, "", "", "archive directory to prune")
+	pruneCmd.PersistentFlags().BoolP(dryrunParameterName, "", false, "only print the directories which would be removed")
+}
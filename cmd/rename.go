@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+	"github.com/hikhvar/exifsorter/pkg/exploration"
+)
+
+// renameCmd represents the rename-in-place command
+var renameCmd = &cobra.Command{
+	Use:   "rename-in-place",
+	Short: "Rename the media files in the given directory like the archive names them",
+	Long: `Rename the media files in the given directory like the archive names them, e.g. 20151224_135917_7c0ed5ba.JPG.
+The files stay in their directories, no archive is created. Files whose name is taken by a file with other content are
+not renamed.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := cmd.Flag(directoryParameterName).Value.String()
+		var opts []archive.Option
+		dryRun, err := cmd.PersistentFlags().GetBool(dryrunParameterName)
+		if err != nil {
+			log.Printf("expected dry-run flag, didn't found it: %s", err)
+		}
+		if dryRun {
+			opts = append(opts, archive.WithFileSystem(archive.NewLoggingFileSystem()))
+		}
+		_, files, err := exploration.InitialFiles(dir, nil, exploration.OnWalkError(func(path string, err error) {
+			log.Printf("could not read %s: %s", path, err)
+		}))
+		if err != nil {
+			log.Printf("failed to walk directory: %s", err)
+			os.Exit(1)
+		}
+		a := archive.NewAlgorithm("", "", opts...)
+		var summary archive.SortSummary
+		for _, f := range files {
+			res, err := a.RenameInPlace(f)
+			summary.Add(res, err)
+			if err != nil && !errors.Is(err, archive.ErrNotMediaFile) {
+				textReporter{}.OnError(f, err)
+			} else if res.Action == archive.ActionRenamed {
+				textReporter{}.OnFileDone(f, res.Target, res.Action)
+			}
+		}
+		if err := summary.Write(os.Stdout); err != nil {
+			log.Printf("failed to print summary: %s", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+
+	renameCmd.PersistentFlags().StringP(directoryParameterName, "", "", "directory of the files to rename")
+	renameCmd.PersistentFlags().BoolP(dryrunParameterName, "", false, "only print the files which would be renamed")
+}
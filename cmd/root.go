@@ -15,12 +15,20 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+	// --timezone must work on systems without a time zone database, e.g. Windows
+	_ "time/tzdata"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
 )
 
 var cfgFile string
@@ -33,6 +41,15 @@ var rootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	//	Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(viper.GetViper(), cmd); err != nil {
+			return err
+		}
+		if err := setTimeZone(cmd.Flags()); err != nil {
+			return err
+		}
+		return addFileNamePatterns(cmd.Flags())
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -50,7 +67,9 @@ func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
-	//rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.exifsorter.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./exifsorter.yaml or $HOME/.exifsorter.yaml)")
+	rootCmd.PersistentFlags().String("timezone", "", "IANA time zone of capture dates without a time zone, e.g. Asia/Tokyo for photos of a trip abroad. Time zones recorded in the files take precedence. Defaults to the local time zone")
+	rootCmd.PersistentFlags().StringArray("filename-date-pattern", nil, "regular expression to read the date from file names without a date in their meta data, with the named groups year, month, day and optionally hour, minute and second, e.g. '^IMG-(?P<year>\\d{4})(?P<month>\\d{2})(?P<day>\\d{2})'. Tried after the built-in Android and WhatsApp patterns")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -63,6 +82,17 @@ func initConfig() {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
+		// Search config in the working directory with name "exifsorter" (without extension).
+		viper.AddConfigPath(".")
+		viper.SetConfigName("exifsorter")
+	}
+
+	readEnv(viper.GetViper())
+
+	// If a config file is found, read it in.
+	err := viper.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	if cfgFile == "" && errors.As(err, &notFound) {
 		// Find home directory.
 		home, err := homedir.Dir()
 		if err != nil {
@@ -70,15 +100,93 @@ func initConfig() {
 			os.Exit(1)
 		}
 
-		// Search config in home directory with name ".exifsorter" (without extension).
+		// Fall back to the config in home directory with name ".exifsorter" (without extension).
 		viper.AddConfigPath(home)
 		viper.SetConfigName(".exifsorter")
+		err = viper.ReadInConfig()
+	}
+	if err == nil {
+		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	} else if cfgFile != "" {
+		fmt.Printf("can't read config file %s: %v\n", cfgFile, err)
+		os.Exit(1)
 	}
+}
 
-	viper.AutomaticEnv() // read in environment variables that match
+// readEnv reads the config keys from environment variables prefixed with EXIFSORTER_, e.g. EXIFSORTER_SORT_DRY_RUN
+// for sort.dry-run.
+func readEnv(v *viper.Viper) {
+	v.SetEnvPrefix("exifsorter")
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	v.AutomaticEnv()
+}
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+// applyConfig sets all flags of the command which are not given explicitly to the value from the environment or the
+// config file. The precedence is: explicit flags > environment variables > config file > flag defaults. The flags of
+// the command are scoped by its name, e.g. sort.dry-run or EXIFSORTER_SORT_DRY_RUN, the global flags aren't.
+func applyConfig(v *viper.Viper, cmd *cobra.Command) error {
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == "config" {
+			return
+		}
+		key := configKey(cmd, f.Name)
+		if !v.IsSet(key) {
+			return
+		}
+		switch f.Value.Type() {
+		case "stringArray", "stringSlice":
+			for _, val := range v.GetStringSlice(key) {
+				if err = f.Value.Set(val); err != nil {
+					break
+				}
+			}
+		default:
+			err = f.Value.Set(v.GetString(key))
+		}
+		if err != nil {
+			err = fmt.Errorf("invalid value for %s in config: %w", key, err)
+		}
+	})
+	return err
+}
+
+// configKey returns the key of the flag in the config file. Flags inherited from the root command are global, all
+// others are below the path of the command, e.g. sort.dry-run.
+func configKey(cmd *cobra.Command, flagName string) string {
+	if cmd.InheritedFlags().Lookup(flagName) != nil || !cmd.HasParent() {
+		return flagName
+	}
+	path := strings.Fields(cmd.CommandPath())[1:]
+	return strings.Join(append(path, flagName), ".")
+}
+
+// setTimeZone sets the location of capture dates without a time zone to the time zone of the flags, if any.
+func setTimeZone(flags *pflag.FlagSet) error {
+	name, err := flags.GetString("timezone")
+	if err != nil || name == "" {
+		return err
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid time zone: %w", err)
+	}
+	extraction.DefaultRegistry.SetLocation(loc)
+	return nil
+}
+
+// addFileNamePatterns adds the file name date patterns of the flags to the default registry.
+func addFileNamePatterns(flags *pflag.FlagSet) error {
+	exprs, err := flags.GetStringArray("filename-date-pattern")
+	if err != nil {
+		return err
+	}
+	for _, expr := range exprs {
+		p, err := extraction.NewFileNamePattern(expr)
+		if err != nil {
+			return err
+		}
+		extraction.DefaultRegistry.AddFileNamePatterns(p)
 	}
+	return nil
 }
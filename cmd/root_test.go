@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyConfig(t *testing.T) {
+	root := &cobra.Command{Use: "exifsorter"}
+	root.PersistentFlags().String("timezone", "", "")
+	sort := &cobra.Command{Use: "sort"}
+	sort.PersistentFlags().String("source", "", "")
+	sort.PersistentFlags().String("target", "", "")
+	sort.PersistentFlags().StringArray("ignores", nil, "")
+	sort.PersistentFlags().Bool("dry-run", false, "")
+	dedup := &cobra.Command{Use: "dedup"}
+	dedup.PersistentFlags().String("directory", "", "")
+	dedup.PersistentFlags().Bool("dry-run", false, "")
+	root.AddCommand(sort, dedup)
+
+	v := viper.New()
+	readEnv(v)
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(strings.NewReader(`
+timezone: Asia/Tokyo
+dry-run: true
+sort:
+  source: /from/file
+  target: /from/file
+  dry-run: true
+  ignores:
+    - "**.@__thumb**"
+    - "**.tmp"
+dedup:
+  directory: /archive
+`)))
+	t.Setenv("EXIFSORTER_SORT_SOURCE", "/from/env")
+	t.Setenv("EXIFSORTER_SORT_TARGET", "/from/env")
+
+	assert.NoError(t, sort.ParseFlags([]string{"--source", "/from/flag"}))
+	assert.NoError(t, applyConfig(v, sort))
+	source, _ := sort.Flags().GetString("source")
+	assert.Equal(t, "/from/flag", source, "explicit flags take precedence")
+	target, _ := sort.Flags().GetString("target")
+	assert.Equal(t, "/from/env", target, "environment variables take precedence over the config file")
+	dryRun, _ := sort.Flags().GetBool("dry-run")
+	assert.True(t, dryRun)
+	ignores, _ := sort.Flags().GetStringArray("ignores")
+	assert.Equal(t, []string{"**.@__thumb**", "**.tmp"}, ignores)
+	timezone, _ := sort.Flags().GetString("timezone")
+	assert.Equal(t, "Asia/Tokyo", timezone, "global flags aren't scoped")
+
+	assert.NoError(t, dedup.ParseFlags(nil))
+	assert.NoError(t, applyConfig(v, dedup))
+	directory, _ := dedup.Flags().GetString("directory")
+	assert.Equal(t, "/archive", directory)
+	dryRun, _ = dedup.Flags().GetBool("dry-run")
+	assert.False(t, dryRun, "sort.dry-run and the unscoped dry-run don't apply to dedup")
+}
@@ -16,16 +16,28 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/hikhvar/exifsorter/pkg/archive"
 	"github.com/hikhvar/exifsorter/pkg/exploration"
-	"github.com/hikhvar/exifsorter/pkg/files"
 	"github.com/spf13/cobra"
 )
 
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
 var ignorePatterns []string
 
 // sortCmd represents the sort command
@@ -34,70 +46,383 @@ var sortCmd = &cobra.Command{
 	Short: "sorts media data according to their exif metadata",
 	Long:  `sorts media data according to their exif metadata`,
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancelFunc := context.WithCancel(context.Background())
+		ctx, cancelFunc := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer cancelFunc()
 		srcDir, dstDir := srcAndDstDir(cmd)
-		a := archive.NewAlgorithm(srcDir, dstDir)
-		err := a.Init()
+		output, _ := cmd.Flags().GetString("output")
+		var info io.Writer = os.Stdout
+		var opts []archive.Option
+		var report func(archive.SortResult, error)
+		switch output {
+		case outputText:
+			opts = append(opts, archive.WithReporter(textReporter{}))
+		case outputJSON:
+			info = os.Stderr
+			report = printSortRecord
+		default:
+			fmt.Printf("unknown output format '%s'\n", output)
+			os.Exit(1)
+		}
+		if command, _ := cmd.Flags().GetString("exec"); command != "" {
+			timeout, _ := cmd.Flags().GetDuration("exec-timeout")
+			hook, err := archive.NewExecHook(command, info, timeout)
+			if err != nil {
+				fmt.Printf("invalid --exec: %v\n", err)
+				os.Exit(1)
+			}
+			opts = append(opts, archive.WithExecHook(hook))
+		}
+		if set, err := cmd.Flags().GetBool("hardlink-dedup-source"); err == nil && set {
+			opts = append(opts, archive.WithSourceHardLinks())
+		}
+		if set, err := cmd.Flags().GetBool("force"); err == nil && set {
+			opts = append(opts, archive.WithForce())
+		}
+		if set, err := cmd.Flags().GetBool("hash-index"); err == nil && set {
+			opts = append(opts, archive.WithHashIndex())
+		}
+		if set, err := cmd.Flags().GetBool("dedup-on-import"); err == nil && set {
+			opts = append(opts, archive.WithDedupOnImport())
+		}
+		timeFormat, _ := cmd.Flags().GetString("time-format")
+		if err := archive.ValidTimeFormat(timeFormat); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		hashLength, _ := cmd.Flags().GetInt("hash-length")
+		if hashLength < 1 || hashLength > 2*sha256.Size224 {
+			fmt.Printf("invalid --hash-length %d: expected 1 to %d\n", hashLength, 2*sha256.Size224)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithTimeFormat(timeFormat), archive.WithHashPrefixLength(hashLength))
+		if set, err := cmd.Flags().GetBool("lowercase-ext"); err == nil && set {
+			opts = append(opts, archive.WithLowerCaseExtensions())
+		}
+		if set, err := cmd.Flags().GetBool("subsec-names"); err == nil && set {
+			opts = append(opts, archive.WithSubSecondNames())
+		}
+		if tags, _ := cmd.Flags().GetStringSlice("name-tags"); len(tags) > 0 {
+			opts = append(opts, archive.WithNameTags(tags...))
+		}
+		if set, err := cmd.Flags().GetBool("live-photos"); err == nil && set {
+			opts = append(opts, archive.WithLivePhotos())
+		}
+		linkStrategy, err := archive.ParseLinkStrategy(cmd.Flag("link-strategy").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithLinkStrategy(linkStrategy))
+		linkCheck, err := archive.ParseLinkCheck(cmd.Flag("target-filesystem-check").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithLinkCheck(linkCheck))
+		overwrite, err := archive.ParseOverwritePolicy(cmd.Flag("overwrite-policy").Value.String())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithOverwritePolicy(overwrite))
+		if set, err := cmd.Flags().GetBool("flatten-origin"); err == nil && set {
+			opts = append(opts, archive.WithFlatOrigin())
+		}
+		if set, err := cmd.Flags().GetBool("verify"); err == nil && set {
+			opts = append(opts, archive.WithCopyVerification())
+		}
+		if set, err := cmd.Flags().GetBool("backlink"); err == nil && set {
+			opts = append(opts, archive.WithBacklinks())
+		}
+		if set, err := cmd.Flags().GetBool("keep-archive-names"); err == nil && set {
+			opts = append(opts, archive.WithKeptArchiveNames())
+		}
+		if set, err := cmd.Flags().GetBool("containers"); err == nil && set {
+			opts = append(opts, archive.WithContainers())
+		}
+		if set, err := cmd.Flags().GetBool("quarantine-undated"); err == nil && set {
+			opts = append(opts, archive.WithUndatedQuarantine())
+		}
+		if set, err := cmd.Flags().GetBool("sidecar-dates"); err == nil && set {
+			opts = append(opts, archive.WithSidecarDates())
+		}
+		if set, err := cmd.Flags().GetBool("mtime-from-capture-date"); err == nil && set {
+			opts = append(opts, archive.WithCaptureDateModTime())
+		}
+		if journalFile, _ := cmd.Flags().GetString("journal"); journalFile != "" {
+			journal, err := archive.OpenJournal(journalFile)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			defer journal.Close()
+			opts = append(opts, archive.WithJournal(journal))
+		}
+		if stateFile, _ := cmd.Flags().GetString("state"); stateFile != "" {
+			fresh, _ := cmd.Flags().GetBool("fresh")
+			state, err := archive.OpenState(stateFile, fresh)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			defer state.Close()
+			opts = append(opts, archive.WithState(state))
+		}
+		filter, err := dateFilterFromFlags(cmd.Flags())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithDateFilter(filter))
+		dirMode, err := dirModeFromFlags(cmd.Flags())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithDirPerm(dirMode))
+		minSize, _ := cmd.Flags().GetString("min-size")
+		minSizeBytes, err := exploration.ParseSize(minSize)
+		if err != nil {
+			fmt.Printf("invalid --min-size: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithMinSize(minSizeBytes))
+		minFreeSpace, _ := cmd.Flags().GetString("min-free-space")
+		minFreeSpaceBytes, err := exploration.ParseSize(minFreeSpace)
+		if err != nil {
+			fmt.Printf("invalid --min-free-space: %v\n", err)
+			os.Exit(1)
+		}
+		if minFreeSpaceBytes > 0 {
+			opts = append(opts, archive.WithMinFreeSpace(uint64(minFreeSpaceBytes)))
+		}
+		bufferSize, _ := cmd.Flags().GetString("copy-buffer-size")
+		bufferSizeBytes, err := exploration.ParseSize(bufferSize)
+		if err != nil || bufferSizeBytes < 1 {
+			fmt.Printf("invalid --copy-buffer-size: '%s'\n", bufferSize)
+			os.Exit(1)
+		}
+		opts = append(opts, archive.WithCopyBufferSize(int(bufferSizeBytes)))
+		mediaTypeOpts, err := mediaTypeOptionsFromFlags(cmd.Flags())
 		if err != nil {
-			fmt.Printf("failed to create target directories: %v", err)
+			fmt.Println(err)
 			os.Exit(1)
 		}
+		opts = append(opts, mediaTypeOpts...)
+		if retries, _ := cmd.Flags().GetInt("retries"); retries > 0 {
+			backoff, _ := cmd.Flags().GetDuration("retry-backoff")
+			opts = append(opts, archive.WithRetry(archive.RetryPolicy{Attempts: retries + 1, Backoff: backoff}))
+		}
+		if set, _ := cmd.Flags().GetBool("lock"); set {
+			wait, _ := cmd.Flags().GetDuration("lock-wait")
+			opts = append(opts, archive.WithLock(wait))
+		}
+		a := archive.NewAlgorithm(srcDir, dstDir, opts...)
 
-		ignores, err := exploration.GobwasMatcherFromPatterns(ignorePatterns)
+		ignores, err := exploration.RelativeGobwasMatcherFromPatterns(srcDir, ignorePatterns)
 		if err != nil {
 			fmt.Printf("not valid globs '%v': %v", ignorePatterns, err.Error())
 			os.Exit(1)
 		}
-		dirs, fs, err := exploration.InitialFiles(srcDir, ignores)
+		sizePredicates, _ := cmd.Flags().GetStringArray("ignore-size")
+		for _, p := range sizePredicates {
+			m, err := exploration.SizeMatcherFromPredicate(p)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			ignores = append(ignores, m)
+		}
+		if extensions, _ := cmd.Flags().GetStringSlice("ignore-ext"); len(extensions) > 0 {
+			ignores = append(ignores, exploration.NewExtensionMatcher(extensions))
+		}
+		if excludes, _ := cmd.Flags().GetStringArray("exclude"); len(excludes) > 0 {
+			ignores = append(ignores, exploration.NewPathMatcher(excludes...))
+		}
+		if skip, _ := cmd.Flags().GetBool("skip-hidden"); skip {
+			ignores = append(ignores, exploration.NewHiddenMatcher(srcDir))
+		} else if skip, _ := cmd.Flags().GetBool("skip-appledouble"); skip {
+			ignores = append(ignores, exploration.AppleDoubleMatcher{})
+		}
+		unreadable := 0
+		walkOpts := []exploration.InitialFilesOption{exploration.OnWalkError(func(path string, err error) {
+			unreadable++
+			fmt.Fprintf(info, "could not read %s: %v\n", path, err)
+		})}
+		if set, err := cmd.Flags().GetBool("follow-symlinks"); err == nil && set {
+			walkOpts = append(walkOpts, exploration.FollowSymlinks())
+		}
+		watcherOpts := []exploration.WatcherOption{}
+		if maxDepth, _ := cmd.Flags().GetInt("max-depth"); maxDepth > 0 {
+			walkOpts = append(walkOpts, exploration.MaxDepth(maxDepth))
+			watcherOpts = append(watcherOpts, exploration.WithMaxDepth(srcDir, maxDepth))
+		}
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		watcherOpts = append(watcherOpts, exploration.WithDebounce(debounce))
+		shutdownTimeout, _ := cmd.Flags().GetDuration("shutdown-timeout")
+		stopShutdown := context.AfterFunc(ctx, func() {
+			// a second signal kills the process immediately
+			cancelFunc()
+			fmt.Fprintln(info, "Shutting down, waiting for the files being sorted. Interrupt again to abort them.")
+		})
+		defer stopShutdown()
+		service := archive.NewService(a,
+			archive.WithIgnores(ignores...),
+			archive.WithWalkOptions(walkOpts...),
+			archive.WithWatcherOptions(watcherOpts...),
+			archive.WithShutdownTimeout(shutdownTimeout),
+		)
+		if set, _ := cmd.Flags().GetBool("plan"); set {
+			plan, err := service.PlanTree(ctx)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if err := plan.Write(os.Stdout); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+		err = a.Init()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Printf("failed to create target directories: %v%s", err, lockWaitHint(err))
 			os.Exit(1)
 		}
+		defer a.Close()
+		if a.LinksDisabled() {
+			fmt.Fprintln(info, "the target directory doesn't support hard links, files are only copied into the calendar directories")
+		}
 		if set, err := cmd.Flags().GetBool("watch-only"); err != nil || !set {
-			fmt.Println("Start intial compare run")
-			for _, f := range fs {
-				n, err := a.Sort(f)
-				if err != nil && err.Error() != "given file is not a media file" {
-					fmt.Printf("Can't sort file %v: %v", f, err.Error())
-				} else {
-					fmt.Printf("%s\t-->\t%s\n", f, n)
+			fmt.Fprintln(info, "Start intial compare run")
+			summary, sortErr := service.SortTree(ctx, report)
+			if sortErr != nil && ctx.Err() == nil {
+				if errors.Is(sortErr, archive.ErrLowDiskSpace) {
+					_ = summary.Write(info)
 				}
+				fmt.Println(sortErr)
+				os.Exit(1)
+			}
+			if sortErr != nil {
+				fmt.Fprintln(info, "aborted intial run.")
+			} else {
+				fmt.Fprintln(info, "finished intial run.")
 			}
-			fmt.Println("finished intial run. Watch folder for changes.")
+			if err := summary.Write(info); err != nil {
+				fmt.Println(err)
+			}
+			if unreadable > 0 {
+				fmt.Fprintf(info, "skipped %d unreadable files or directories\n", unreadable)
+			}
+			if sortErr != nil {
+				return
+			}
+			fmt.Fprintln(info, "Watch folder for changes.")
 		}
 
-		watcher, err := exploration.NewRecursiveWatcher(ctx, ignores, dirs...)
+		if set, err := cmd.Flags().GetBool("watch-integrity"); err == nil && set {
+			quarantine, _ := cmd.Flags().GetBool("quarantine")
+			err := watchIntegrity(ctx, a, dstDir, quarantine, info)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		err = service.Watch(ctx, report, func(err error) {
+			fmt.Fprintln(info, err)
+		})
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
+	},
+}
+
+// watchIntegrity warns about overwritten files in the archive until the context is cancelled. Overwritten files are
+// moved into the quarantine directory if quarantine is set.
+func watchIntegrity(ctx context.Context, a *archive.Algorithm, dstDir string, quarantine bool, info io.Writer) error {
+	archiveDirs, _, err := exploration.InitialFiles(dstDir, nil)
+	if err != nil {
+		return err
+	}
+	integrityWatcher, err := exploration.NewRecursiveWatcher(ctx, nil, archiveDirs, exploration.WithOps(fsnotify.Write))
+	if err != nil {
+		return err
+	}
+	go func() {
 		for {
 			select {
-			case err = <-watcher.Errors:
-				fmt.Println(err)
-			case e := <-watcher.Events:
-				if e.Op == fsnotify.Remove {
+			case <-ctx.Done():
+				return
+			case err := <-integrityWatcher.Errors:
+				fmt.Fprintln(info, err)
+			case e := <-integrityWatcher.Events:
+				err := a.CheckIntegrity(e.Name)
+				if err == nil {
 					break
 				}
-				f := e.Name
-				normalFile, err := files.IsNormalFile(f)
-				if err == nil {
-					if normalFile {
-						n, err := a.Sort(f)
-						if err != nil && err.Error() != "given file is not a media file" {
-							fmt.Printf("%v: %v", f, err.Error())
-						} else {
-							fmt.Printf("%s\t-->\t%s\n", f, n)
-						}
-
+				fmt.Fprintf(info, "WARNING: %v\n", err)
+				if quarantine {
+					q, err := a.Quarantine(e.Name)
+					if err != nil {
+						fmt.Fprintf(info, "could not quarantine %s: %v\n", e.Name, err)
+					} else {
+						fmt.Fprintf(info, "%s\t-->\t%s\n", e.Name, q)
 					}
-				} else {
-					fmt.Printf("could not stat file: %v\n", err)
 				}
 			}
 		}
-	},
+	}()
+	return nil
+}
+
+// sortRecord is the JSON representation of a sorted file.
+type sortRecord struct {
+	Source      string         `json:"source"`
+	Target      string         `json:"target,omitempty"`
+	OriginLink  string         `json:"origin_link,omitempty"`
+	CaptureDate *time.Time     `json:"capture_date,omitempty"`
+	Hash        string         `json:"hash,omitempty"`
+	Action      archive.Action `json:"action,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// textReporter prints the progress of sorting as plain text.
+type textReporter struct{}
+
+func (textReporter) OnFileStart(string) {}
+
+func (textReporter) OnFileDone(path, target string, _ archive.Action) {
+	fmt.Printf("%s\t-->\t%s\n", path, target)
+}
+
+func (textReporter) OnError(path string, err error) {
+	fmt.Printf("Can't sort file %v: %v\n", path, err.Error())
+}
+
+// printSortRecord prints the result of sorting a single file as JSON object.
+func printSortRecord(res archive.SortResult, err error) {
+	rec := sortRecord{Source: res.Source, Target: res.Target, OriginLink: res.OriginLink, Action: res.Action}
+	if !res.CaptureDate.IsZero() {
+		rec.CaptureDate = &res.CaptureDate
+	}
+	if res.Hash != nil {
+		rec.Hash = hex.EncodeToString(res.Hash)
+	}
+	if err != nil && !errors.Is(err, archive.ErrNotMediaFile) {
+		rec.Error = err.Error()
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(rec); err != nil {
+		fmt.Fprintf(os.Stderr, "could not write result: %v\n", err)
+	}
+}
+
+// lockWaitHint returns advice to append to errors of Init caused by the lock of another instance.
+func lockWaitHint(err error) string {
+	if errors.Is(err, archive.ErrArchiveLocked) {
+		return ", use --lock-wait to wait for it to finish"
+	}
+	return ""
 }
 
 func srcAndDstDir(cmd *cobra.Command) (string, string) {
@@ -115,8 +440,57 @@ func init() {
 
 	sortCmd.PersistentFlags().StringP("target", "t", "", "target directory")
 
-	sortCmd.PersistentFlags().StringArrayVarP(&ignorePatterns, "ignores", "i", []string{"**.@__thumb**", "**.syncthing.*tmp", "**.!sync"}, "file patterns to ignore. For supported patterns see https://github.com/gobwas/glob .")
+	sortCmd.PersistentFlags().StringArrayVarP(&ignorePatterns, "ignores", "i", []string{"**.@__thumb**", "**.syncthing.*tmp", "**.!sync"}, "file patterns to ignore, matched against the full path and the path relative to the source directory, e.g. tmp/** for all files below <source>/tmp. For supported patterns see https://github.com/gobwas/glob .")
 
+	sortCmd.PersistentFlags().StringArray("ignore-size", nil, "ignore files by size, e.g. '<50k' or '>2G'. The units k, M, G and T are powers of 1024.")
+	sortCmd.PersistentFlags().String("min-size", "1", "skip files smaller than this size, e.g. empty placeholders of sync tools. The units k, M, G and T are powers of 1024")
+	sortCmd.PersistentFlags().String("min-free-space", "0", "stop sorting before the free space of the target directory drops below this size, e.g. 10G. The units k, M, G and T are powers of 1024")
+	sortCmd.PersistentFlags().String("copy-buffer-size", "32k", "size of the buffers to copy files with, e.g. 4M for large videos on fast disks. The units k, M, G and T are powers of 1024")
+	sortCmd.PersistentFlags().Bool("verify", false, "read every copied file again and compare its checksum to the source. Doubles the read I/O of copies")
+	sortCmd.PersistentFlags().StringSlice("ignore-ext", nil, "ignore files with these extensions regardless of their location, e.g. aae,thm")
+	sortCmd.PersistentFlags().Bool("skip-hidden", false, "ignore dotfiles and dot directories of the source directory, including AppleDouble ._ files")
+	sortCmd.PersistentFlags().Bool("skip-appledouble", false, "ignore the AppleDouble ._ resource forks macOS writes next to files on foreign file systems")
+	sortCmd.PersistentFlags().StringArray("exclude", nil, "skip these files and directories of the source directory. An archive inside the source directory is always skipped")
+	sortCmd.PersistentFlags().String("exec", "", "run this command for every file sorted into the archive. The placeholders {source}, {target}, {origin}, {date} and {action} are replaced by the file's values. A failing command doesn't stop sorting")
+	sortCmd.PersistentFlags().Duration("exec-timeout", time.Minute, "kill the --exec command if it runs longer than this. 0 disables the timeout")
+	sortCmd.PersistentFlags().Bool("plan", false, "print the archive tree the initial run would create, including collisions, and exit without writing anything")
 	sortCmd.PersistentFlags().BoolP("dry-run", "d", false, "dry run. Don't edit anything.")
 	sortCmd.PersistentFlags().BoolP("watch-only", "w", false, "only watch new files")
+	sortCmd.PersistentFlags().Int("max-depth", 0, "only sort files up to this depth below the source directory, e.g. 2 for the files in its subdirectories. 0 is unlimited")
+	sortCmd.PersistentFlags().BoolP("follow-symlinks", "", false, "descend into symlinked directories of the source directory")
+	sortCmd.PersistentFlags().BoolP("hardlink-dedup-source", "", false, "hard link source files on the archive device instead of copying them")
+	sortCmd.PersistentFlags().BoolP("force", "f", false, "copy files even if they are already archived")
+	sortCmd.PersistentFlags().BoolP("hash-index", "", false, "index the sizes and checksums of the archive at start to skip already archived files regardless of their capture date. Without it files are only compared with the archived files of their capture date")
+	sortCmd.PersistentFlags().Bool("dedup-on-import", false, "never store the same content twice: files already archived under another capture date only get their origin link to the archived copy. Implies --hash-index")
+	sortCmd.PersistentFlags().String("time-format", "20060102_150405", "layout of the capture date in the archive file names, see https://pkg.go.dev/time#Layout. Use e.g. 20060102_150405.000 for milliseconds. Pass the same --time-format to dedup")
+	sortCmd.PersistentFlags().Int("hash-length", 8, "number of hex characters of the checksum in the archive file names")
+	sortCmd.PersistentFlags().Bool("lowercase-ext", false, "lowercase the extensions of the archive file names to avoid names differing only in case")
+	sortCmd.PersistentFlags().Bool("subsec-names", false, "append the hundredths of a second of the capture date to the date in the archive file names, e.g. 20151224_135917_07_7c0ed5ba.JPG, so that burst photos taken within the same second sort in capture order. Photos less than 10ms apart still sort by checksum. Pass --subsec-names to dedup as well")
+	sortCmd.PersistentFlags().StringSlice("name-tags", nil, "append the values of these EXIF fields to the archive file names, e.g. Model,LensModel. Missing fields are left out")
+	sortCmd.PersistentFlags().Int("retries", 0, "retry file system operations failing with transient errors, e.g. timeouts of network mounts, this many times")
+	sortCmd.PersistentFlags().Duration("retry-backoff", 100*time.Millisecond, "wait before the first retry. The wait doubles with every further retry")
+	sortCmd.PersistentFlags().Bool("live-photos", false, "sort the video of a Live Photo like IMG_1234.MOV by the capture date of its image IMG_1234.HEIC to keep both together")
+	sortCmd.PersistentFlags().Bool("sidecar-dates", false, "use the date of XMP sidecar files like IMG_1234.xmp or IMG_1234.CR2.xmp for media files without an embedded capture date")
+	sortCmd.PersistentFlags().Bool("lock", true, "lock the target directory so that no other instance sorts into it at the same time")
+	sortCmd.PersistentFlags().Duration("lock-wait", 0, "wait this long for another instance to release the lock of the target directory instead of failing immediately")
+	sortCmd.PersistentFlags().String("target-filesystem-check", "fail", "check that the target directory supports hard links before sorting: fail (stop if it doesn't), fallback (only copy files without links) or off")
+	sortCmd.PersistentFlags().String("overwrite-policy", "rename", "what to do if a file name in the archive is taken by a different file: rename (append _1, _2, ... to the new file), skip (keep the existing file), overwrite, keep-larger or keep-newer (by modification time)")
+	sortCmd.PersistentFlags().String("link-strategy", "origin", "mirrors of the calendar files to create: origin (links below origin by source path) or none (only the calendar directories)")
+	sortCmd.PersistentFlags().Bool("backlink", false, "replace every sorted source file by a hard link to its file in the archive, so it stays visible in the source directory without taking space twice. Source and target directory must be on the same file system")
+	sortCmd.PersistentFlags().Bool("keep-archive-names", false, "keep the names of source files already named like archive files, e.g. when importing an archive into a new one. Their capture date is taken from the name")
+	sortCmd.PersistentFlags().Bool("containers", false, "sort the media files inside .zip, .tar, .tar.gz and .tgz archives of the source directory instead of skipping the archives. Only applies to the initial sort, not to watched files")
+	sortCmd.PersistentFlags().Bool("flatten-origin", false, "link files below origin only by the name of their source directory instead of its whole path, e.g. origin/Camera instead of origin/DCIM/2019/Camera")
+	sortCmd.PersistentFlags().Bool("quarantine-undated", false, "copy media files without a capture date in their meta data to quarantine/undated under their source path instead of sorting them by their modification time")
+	sortCmd.PersistentFlags().BoolP("mtime-from-capture-date", "", false, "set the modification time of copied files to their capture date instead of the source modification time")
+	sortCmd.PersistentFlags().StringP("output", "o", outputText, fmt.Sprintf("output format of the sorted files. One of %s, %s. %s prints one JSON object per line", outputText, outputJSON, outputJSON))
+	sortCmd.PersistentFlags().String("journal", "", "append all created files and links to this journal file. The run can be reverted with the undo command")
+	sortCmd.PersistentFlags().String("state", "", "record the sorted source files in this state file and skip them in later runs unless they were modified, e.g. to resume an interrupted import")
+	sortCmd.PersistentFlags().Bool("fresh", false, "ignore the sorted source files recorded in the state file by previous runs")
+	addDateFilterFlags(sortCmd.PersistentFlags())
+	addDirModeFlag(sortCmd.PersistentFlags())
+	addMediaTypeFlags(sortCmd.PersistentFlags())
+	sortCmd.PersistentFlags().Duration("shutdown-timeout", 30*time.Second, "on interrupt, wait up to this duration for the files being sorted before aborting their copies. 0 aborts them immediately")
+	sortCmd.PersistentFlags().Duration("debounce", 500*time.Millisecond, "wait until a watched file had no changes for this duration before sorting it. 0 disables the delay")
+	sortCmd.PersistentFlags().BoolP("watch-integrity", "", false, "warn if files in the target directory are overwritten and don't match their checksum anymore")
+	sortCmd.PersistentFlags().BoolP("quarantine", "", false, "move overwritten files detected by --watch-integrity into the quarantine directory")
 }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo <journal>",
+	Short: "Revert a sort run recorded with --journal",
+	Long: `Revert a sort run recorded with --journal. All files and links created by the run are removed in reverse order.
+Links which were replaced after the run are kept. An interrupted undo can be repeated.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := archive.ReadJournal(args[0])
+		if err != nil {
+			log.Printf("failed to read journal: %s", err)
+			os.Exit(1)
+		}
+		fs := archive.NewOSFileSystem()
+		dryRun, err := cmd.PersistentFlags().GetBool(dryrunParameterName)
+		if err != nil {
+			log.Printf("expected dry-run flag, didn't found it: %s", err)
+		}
+		if dryRun {
+			fs = archive.NewLoggingFileSystem()
+		}
+		err = archive.Undo(entries, fs)
+		if err != nil {
+			log.Printf("failed to undo: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	undoCmd.PersistentFlags().BoolP(dryrunParameterName, "", false, "only print the files which would be removed")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hikhvar/exifsorter/pkg/archive"
+)
+
+const fixParameterName = "fix"
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the link integrity of the archive in the given directory",
+	Long: `Verify the link integrity of the archive in the given directory. Every file below origin must be a hard link to
+its file in the calendar directories, and every calendar file must be linked at least once. The calendar files of
+archives without an origin directory, e.g. sorted with --link-strategy none, don't need to be linked.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		archiveRoot := cmd.Flag(directoryParameterName).Value.String()
+		report, err := archive.Verify(archiveRoot)
+		if err != nil {
+			log.Printf("failed to verify archive: %s", err)
+			os.Exit(1)
+		}
+		err = report.Write(os.Stdout)
+		if err != nil {
+			log.Printf("failed to print report: %s", err)
+			os.Exit(1)
+		}
+
+		fix, err := cmd.PersistentFlags().GetBool(fixParameterName)
+		if err != nil {
+			log.Printf("expected fix flag, didn't found it: %s", err)
+		}
+		if fix {
+			dirMode, err := dirModeFromFlags(cmd.Flags())
+			if err != nil {
+				log.Printf("%s", err)
+				os.Exit(1)
+			}
+			err = report.Fix(archive.NewOSFileSystem().WithDirPerm(dirMode))
+			if err != nil {
+				log.Printf("failed to fix archive: %s", err)
+				os.Exit(1)
+			}
+			report, err = archive.Verify(archiveRoot)
+			if err != nil {
+				log.Printf("failed to verify fixed archive: %s", err)
+				os.Exit(1)
+			}
+		}
+		if !report.Ok() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.PersistentFlags().StringP(directoryParameterName, "", "", "archive directory to verify")
+	verifyCmd.PersistentFlags().BoolP(fixParameterName, "", false, "re-create detached links")
+	addDirModeFlag(verifyCmd.PersistentFlags())
+}
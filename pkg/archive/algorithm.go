@@ -1,12 +1,14 @@
 package archive
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
-	"fmt"
 	"hash"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -16,105 +18,564 @@ import (
 	"github.com/hikhvar/exifsorter/pkg/files"
 )
 
-const targetTimeFormat = "20060102_150405"
+// ErrNotMediaFile is returned for files which are neither images nor videos.
+var ErrNotMediaFile = errors.New("given file is not a media file")
 
 type Watcher interface {
 	Channels() (chan fsnotify.Event, chan error)
 }
 
-type Copier func(src, dst string, hFunc hash.Hash) (hashSum []byte, err error)
+type Copier func(ctx context.Context, src, dst string, hFunc hash.Hash) (hashSum []byte, err error)
 type Linker func(oldName, newName string) error
+type Renamer func(oldName, newName string) error
 type Stater func(filename string) (os.FileInfo, error)
 type DirectoryCreator func(dirPath string, perm os.FileMode) error
 type DateExtractor func(fname string) (time.Time, error)
+type TagReader func(fname string, fieldName string) (string, error)
 
 type IsMedia func(fname string) (bool, error)
+type CaptureDateChecker func(fname string) (bool, error)
+
+type Hasher func(fname string, hFunc hash.Hash) (hashSum []byte, err error)
+type DeviceComparer func(a, b string) (bool, error)
 
 type Algorithm struct {
-	archiveDir string
-	sourceDir  string
-	copier     Copier
-	fileSystem FileSystem
-	extractor  DateExtractor
-	isMedia    IsMedia
+	archiveDir        string
+	sourceDir         string
+	copier            Copier
+	hasher            Hasher
+	sameDevice        DeviceComparer
+	linkSource        bool
+	captureMTime      bool
+	force             bool
+	minSize           int64
+	photosDir         string
+	videosDir         string
+	mergedOrigin      bool
+	livePhotos        bool
+	linkStrategy      LinkStrategy
+	flatOrigin        bool
+	verifyCopies      bool
+	containers        bool
+	keepArchiveNames  bool
+	backlinks         bool
+	backlinked        *backlinkedFiles
+	overwrite         OverwritePolicy
+	linkCheck         LinkCheck
+	linksDisabled     bool
+	state             *State
+	clock             Clock
+	retry             *RetryPolicy
+	lock              bool
+	lockWait          time.Duration
+	archiveLock       *files.FileLock
+	freeSpace         *freeSpaceGuard
+	quarantineUndated bool
+	journal           *Journal
+	index             *hashIndex
+	filter            DateFilter
+	fileSystem        FileSystem
+	registry          *extraction.Registry
+	sidecarDates      bool
+	extractor         DateExtractor
+	isMedia           IsMedia
+	isImage           IsMedia
+	hasCaptureDate    CaptureDateChecker
+	nameTags          []string
+	deviceTags        []string
+	naming            naming
+	tagReader         TagReader
+	reporter          Reporter
+	hook              *ExecHook
+}
+
+// Option configures optional behaviour of the Algorithm
+type Option func(a *Algorithm)
+
+// WithSourceHardLinks hard links the source file into the calendar directory instead of copying it, if the source
+// and the archive are on the same device. Files on other devices are still copied.
+func WithSourceHardLinks() Option {
+	return func(a *Algorithm) {
+		a.linkSource = true
+	}
+}
+
+// WithCaptureDateModTime sets the modification time of copied files to their capture date instead of preserving the
+// modification time of the source. Hard linked sources keep their modification time.
+func WithCaptureDateModTime() Option {
+	return func(a *Algorithm) {
+		a.captureMTime = true
+	}
+}
+
+// WithForce copies every file, even if a file with the same capture date and content is already archived.
+func WithForce() Option {
+	return func(a *Algorithm) {
+		a.force = true
+	}
+}
+
+// WithCopyBufferSize copies files with buffers of the given size in bytes instead of 32KB. The buffers are reused by
+// concurrent copies.
+func WithCopyBufferSize(size int) Option {
+	return func(a *Algorithm) {
+		a.copier = files.NewBufferedCopier(size).CopyContext
+	}
+}
+
+// WithMinSize skips files smaller than the given number of bytes, e.g. empty placeholders of sync tools.
+func WithMinSize(size int64) Option {
+	return func(a *Algorithm) {
+		a.minSize = size
+	}
+}
+
+// WithDateExtractor replaces the capture date extraction. Use WithRegistry for an extraction.Registry, so that the
+// undated quarantine and the date filter check for capture dates with it as well.
+func WithDateExtractor(e DateExtractor) Option {
+	return func(a *Algorithm) {
+		a.extractor = e
+	}
+}
+
+// WithRegistry extracts the capture dates with the given registry instead of the extraction.DefaultRegistry, e.g. one
+// with extractors for additional file types.
+func WithRegistry(reg *extraction.Registry) Option {
+	return func(a *Algorithm) {
+		a.registry = reg
+		a.useRegistry()
+	}
+}
+
+// WithSidecarDates uses the date of the XMP sidecar file of media files without an embedded capture date before
+// falling back to their modification time.
+func WithSidecarDates() Option {
+	return func(a *Algorithm) {
+		a.sidecarDates = true
+		a.useRegistry()
+	}
+}
+
+// useRegistry extracts the capture dates with the registry of the Algorithm.
+func (a *Algorithm) useRegistry() {
+	if a.sidecarDates {
+		a.extractor, a.hasCaptureDate = a.registry.CaptureDateWithSidecar, a.registry.HasCaptureDateWithSidecar
+		return
+	}
+	a.extractor, a.hasCaptureDate = a.registry.CaptureDate, a.registry.HasCaptureDate
+}
+
+// WithNameTags appends the values of the given EXIF fields to the archive file names, e.g. "Model" for
+// 20190417_133044_0e1b2a6e_Canon-EOS-5D.jpg. Missing fields are left out. The capture date and checksum always start
+// the name.
+func WithNameTags(fieldNames ...string) Option {
+	return func(a *Algorithm) {
+		a.nameTags = fieldNames
+	}
+}
+
+// WithDirPerm creates the directories of the archive with the given permissions instead of os.ModePerm.
+func WithDirPerm(perm os.FileMode) Option {
+	return func(a *Algorithm) {
+		a.fileSystem = a.fileSystem.WithDirPerm(perm)
+	}
 }
 
 // NewAlgorithm returns a new Algorithm.
-func NewAlgorithm(src, dst string) *Algorithm {
-	return &Algorithm{
-		archiveDir: dst,
-		sourceDir:  src,
-		copier:     files.Copy,
-		fileSystem: NewOSFileSystem(),
-		extractor:  extraction.CaptureDate,
-		isMedia:    extraction.IsVideoOrImage,
+func NewAlgorithm(src, dst string, opts ...Option) *Algorithm {
+	a := &Algorithm{
+		archiveDir:     dst,
+		sourceDir:      src,
+		copier:         files.CopyContext,
+		hasher:         files.Hash,
+		sameDevice:     files.SameDevice,
+		fileSystem:     NewOSFileSystem(),
+		registry:       extraction.DefaultRegistry,
+		extractor:      extraction.CaptureDate,
+		isMedia:        extraction.IsVideoOrImage,
+		isImage:        extraction.IsImage,
+		hasCaptureDate: extraction.HasCaptureDate,
+		tagReader:      extraction.Tag,
+		naming:         defaultNaming,
+		clock:          realClock{},
+	}
+	for _, opt := range opts {
+		opt(a)
 	}
+	a.fileSystem = a.fileSystem.WithClock(a.clock)
+	if a.retry != nil {
+		a.fileSystem = a.fileSystem.WithRetry(*a.retry)
+	}
+	return a
 }
 
-// Init creates all required target directories
+// Init creates all required target directories. With WithLock it first takes the lock of the archive.
 func (a *Algorithm) Init() error {
-	err := a.fileSystem.EnsureDirectory(a.originArchiveDir())
-	if err != nil {
-		return errors.Wrapf(err, "could not create target dir '%s'", a.originArchiveDir())
+	if a.lock && a.archiveLock == nil {
+		if err := a.fileSystem.EnsureDirectory(a.archiveDir); err != nil {
+			return errors.Wrapf(err, "could not create archive dir '%s'", a.archiveDir)
+		}
+		if err := a.lockArchive(); err != nil {
+			return err
+		}
+	}
+	if err := a.checkHardLinks(); err != nil {
+		return err
+	}
+	var dirs []string
+	if a.linkStrategy == LinkOrigin {
+		dirs = a.originDirs()
+	}
+	for _, dir := range dirs {
+		err := a.fileSystem.EnsureDirectory(dir)
+		if err != nil {
+			return errors.Wrapf(err, "could not create target dir '%s'", dir)
+		}
+	}
+	if a.index != nil {
+		return a.index.scan(a.archiveDir, a.naming)
 	}
 	return nil
 }
 
+// Action is the action taken to archive a file
+type Action string
+
+const (
+	// ActionCopied means the file was copied into the archive
+	ActionCopied Action = "copied"
+	// ActionLinked means the source file was hard linked into the archive
+	ActionLinked Action = "linked"
+	// ActionSkipped means the file was already archived and only linked into origin
+	ActionSkipped Action = "skipped"
+	// ActionIgnored means the file is not a media file
+	ActionIgnored Action = "ignored"
+	// ActionFiltered means the capture date is outside the range of the date filter
+	ActionFiltered Action = "filtered"
+	// ActionTooSmall means the file is smaller than the minimum size or too short to detect its file type
+	ActionTooSmall Action = "too-small"
+	// ActionQuarantined means the file has no capture date in its meta data and was copied into the quarantine
+	// directory, see WithUndatedQuarantine
+	ActionQuarantined Action = "quarantined"
+	// ActionKept means the file wasn't archived because a different file of the same name was kept, see
+	// WithOverwritePolicy
+	ActionKept Action = "kept"
+)
+
+// SortResult describes how a single file was archived.
+type SortResult struct {
+	Source string
+	// Target is the calendar file of the archive. It is empty if the file wasn't archived.
+	Target string
+	// OriginLink is the link to Target below origin. It is empty if the link wasn't created.
+	OriginLink  string
+	CaptureDate time.Time
+	Hash        []byte
+	Action      Action
+}
+
+// Sort archives the given file and returns the path of the file in the archive. Use SortFile for the full result.
 func (a *Algorithm) Sort(fname string) (string, error) {
+	res, err := a.SortFile(fname)
+	return res.Target, err
+}
+
+// SortFile archives the given file and returns what was done.
+func (a *Algorithm) SortFile(fname string) (SortResult, error) {
+	return a.sortFile(context.Background(), fname)
+}
+
+// archiveFile archives the given file. Copying the file is aborted if the context is cancelled.
+func (a *Algorithm) archiveFile(ctx context.Context, fname string) (SortResult, error) {
+	res := SortResult{Source: fname}
+	if a.minSize > 0 {
+		fInfo, err := os.Stat(fname)
+		if err != nil {
+			return res, errors.Wrap(err, "could not determine file size")
+		}
+		if fInfo.Size() < a.minSize {
+			res.Action = ActionTooSmall
+			return res, nil
+		}
+	}
 	isMedia, err := a.isMedia(fname)
+	if errors.Is(err, extraction.ErrTruncatedHeader) {
+		res.Action = ActionTooSmall
+		return res, nil
+	}
 	if err != nil {
-		return "", errors.Wrap(err, "could not determine media type")
+		return res, errors.Wrap(err, "could not determine media type")
 	}
 	if !isMedia {
-		return "", errors.New("given file is not a media file")
+		res.Action = ActionIgnored
+		return res, ErrNotMediaFile
 	}
 
-	date, err := a.extractor(fname)
+	dateSource := a.dateSource(fname)
+	date, kept := a.keptDate(fname)
+	if !kept {
+		date, err = a.extractor(dateSource)
+		if err != nil {
+			return res, errors.Wrap(err, "could not determine creation date of media file")
+		}
+	}
+	res.CaptureDate = date
+	filtered, err := a.filtered(dateSource, date)
 	if err != nil {
-		return "", errors.Wrap(err, "could not determine creation date of media file")
+		return res, err
+	}
+	if filtered {
+		res.Action = ActionFiltered
+		return res, nil
+	}
+	if a.quarantineUndated && !kept {
+		dated, err := a.hasCaptureDate(dateSource)
+		if err != nil {
+			return res, errors.Wrap(err, "could not check for capture date")
+		}
+		if !dated {
+			return a.copyUndated(ctx, fname, res)
+		}
 	}
 
-	year, month := getYearMonth(date)
-
-	targetDir, err := path.Join(a.archiveDir, fmt.Sprintf("%d/%02d", year, month)), nil
+	targetDir, err := a.calendarDir(dateSource, date)
+	if err != nil {
+		return res, err
+	}
 
 	err = a.fileSystem.EnsureDirectory(targetDir)
 	if err != nil {
-		return "", errors.Wrapf(err, "could not create target dir '%s'", targetDir)
+		return res, errors.Wrapf(err, "could not create target dir '%s'", targetDir)
+	}
+
+	if !a.force {
+		existing, sum, err := a.archivedCopy(fname, targetDir, date)
+		if err == nil && existing == "" && kept {
+			existing, sum, err = a.keptCopy(fname, targetDir)
+		}
+		if err != nil {
+			return res, errors.Wrap(err, "could not check for already archived copy")
+		}
+		if existing != "" {
+			res.Hash = sum
+			return a.keepExisting(fname, res, ActionSkipped, existing)
+		}
 	}
 
-	tmpFile := path.Join(targetDir, "exifsorter.tmp")
-	sum, err := a.copier(fname, tmpFile, sha256.New224())
+	var targetFileName, targetFilePath string
+	var action Action
+	linked, err := a.shouldLinkSource(fname, targetDir)
 	if err != nil {
-		return tmpFile, errors.Wrap(err, "could not copy file and compute checksum")
+		return res, errors.Wrap(err, "could not compare devices of source and target")
+	}
+	if linked {
+		sum, err := a.hasher(fname, sha256.New224())
+		if err != nil {
+			return res, errors.Wrap(err, "could not compute checksum")
+		}
+		res.Action, res.Hash = ActionLinked, sum
+		targetFileName = a.targetFileName(fname, targetDir, date, sum, kept)
+		targetFilePath, action, err = a.resolveTarget(fname, path.Join(targetDir, targetFileName), sum)
+		if err != nil {
+			return res, err
+		}
+		if action != "" {
+			return a.keepExisting(fname, res, action, targetFilePath)
+		}
+		err = a.createLink(targetFilePath, fname)
+		if err != nil {
+			return res, errors.Wrap(err, "could not hard link source to target name")
+		}
+	} else {
+		if err := a.reserveSpace(fname, targetDir); err != nil {
+			return res, err
+		}
+		tmpFile := path.Join(targetDir, tmpFileName)
+		sum, err := a.copyToTemp(ctx, fname, tmpFile)
+		if err != nil {
+			return res, errors.Wrap(err, "could not copy file and compute checksum")
+		}
+		res.Action, res.Hash = ActionCopied, sum
+
+		targetFileName = a.targetFileName(fname, targetDir, date, sum, kept)
+		targetFilePath, action, err = a.resolveTarget(fname, path.Join(targetDir, targetFileName), sum)
+		if err == nil && action != "" {
+			err = a.fileSystem.EnsureAbsent(tmpFile)
+			if err == nil {
+				return a.keepExisting(fname, res, action, targetFilePath)
+			}
+		}
+		if err != nil {
+			_ = a.fileSystem.EnsureAbsent(tmpFile)
+			return res, err
+		}
+		_, existed := os.Lstat(targetFilePath)
+		err = a.fileSystem.Rename(tmpFile, targetFilePath)
+		if err != nil {
+			return res, errors.Wrap(err, "could not mv temporary file to target name")
+		}
+		res.Target = targetFilePath
+		if existed != nil {
+			if err := a.record(JournalCreated, targetFilePath, ""); err != nil {
+				return res, err
+			}
+		}
+		if a.captureMTime {
+			err = os.Chtimes(targetFilePath, date, date)
+			if err != nil {
+				return res, errors.Wrap(err, "could not set modification time to capture date")
+			}
+		}
 	}
 
-	targetFileName := fmt.Sprintf("%s_%s%s", date.Format(targetTimeFormat), fmt.Sprintf("%x", sum)[0:8], path.Ext(fname))
-	targetFilePath := path.Join(targetDir, targetFileName)
-	err = os.Rename(tmpFile, targetFilePath)
+	res.Target = targetFilePath
+	a.addToIndex(targetFilePath, res.Hash)
+	res.OriginLink, err = a.linkOrigin(fname, targetFilePath)
 	if err != nil {
-		return tmpFile, errors.Wrap(err, "could not mv temporary file to target name")
+		return res, err
 	}
+	return res, a.backlink(fname, res)
+}
 
-	originArchiveName, err := a.originArchiveFileName(fname, targetFileName)
+// linkOrigin links the archived file into the origin directory according to the path of the source file and returns
+// the path of the link. Nothing is linked with LinkNone.
+func (a *Algorithm) linkOrigin(fname string, targetFilePath string) (string, error) {
+	if a.linkStrategy == LinkNone {
+		return "", nil
+	}
+	originArchiveName, err := a.originArchiveFileName(fname, targetFilePath)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to determine relative path")
+	}
+	err = a.createLink(originArchiveName, targetFilePath)
 	if err != nil {
-		return targetFilePath, errors.Wrap(err, "failed to determine relative path")
+		return "", err
+	}
+	return originArchiveName, nil
+}
+
+// createLink hard links target to name and records the link in the journal, if it didn't exist before.
+func (a *Algorithm) createLink(name string, target string) error {
+	_, existed := os.Lstat(name)
+	err := a.fileSystem.CreateLinks([]string{name}, target)
+	if err != nil || existed == nil {
+		return err
 	}
-	return targetFilePath, a.fileSystem.CreateLinks([]string{originArchiveName}, targetFilePath)
+	return a.record(JournalLinked, name, target)
 }
 
-func (a *Algorithm) originArchiveFileName(sourceFileName string, targetFileName string) (string, error) {
-	pathInSrc, err := filepath.Rel(a.sourceDir, sourceFileName)
+// archivedCopy returns the calendar file with the same content as fname together with its checksum. Without an index
+// only the files in targetDir with the same capture date are candidates.
+func (a *Algorithm) archivedCopy(fname string, targetDir string, date time.Time) (string, []byte, error) {
+	if a.index != nil {
+		return a.indexedCopy(fname)
+	}
+	return a.existingCopy(fname, targetDir, date)
+}
+
+// existingCopy returns the file in targetDir which has the same capture date and content as fname together with its
+// checksum. Candidates are first compared by size, only files of equal size are hashed. It returns an empty string if
+// there is no such file.
+func (a *Algorithm) existingCopy(fname string, targetDir string, date time.Time) (string, []byte, error) {
+	entries, err := os.ReadDir(targetDir)
+	if os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, errors.Wrap(err, "could not list target dir")
+	}
+	prefix := a.naming.formatDate(date) + "_"
+	var sum []byte
+	for _, e := range entries {
+		// the extension may differ in case, e.g. if the archive lowercases extensions
+		if e.IsDir() || !strings.HasPrefix(e.Name(), prefix) || !strings.EqualFold(path.Ext(e.Name()), path.Ext(fname)) {
+			continue
+		}
+		candidate := path.Join(targetDir, e.Name())
+		equal, err := a.fileSystem.EqualSize(fname, candidate)
+		if err != nil || !equal {
+			continue
+		}
+		if sum == nil {
+			sum, err = a.hasher(fname, sha256.New224())
+			if err != nil {
+				return "", nil, errors.Wrap(err, "could not compute checksum")
+			}
+		}
+		if prefix, err := a.naming.hashFromName(e.Name()); err != nil || prefix != a.naming.hashPrefix(sum) {
+			continue
+		}
+		candidateSum, err := a.hasher(candidate, sha256.New224())
+		if err != nil {
+			return "", nil, errors.Wrap(err, "could not compute checksum of archived file")
+		}
+		if bytes.Equal(sum, candidateSum) {
+			return candidate, sum, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// shouldLinkSource returns true if the source file should be hard linked into the given target directory.
+func (a *Algorithm) shouldLinkSource(fname string, targetDir string) (bool, error) {
+	if !a.linkSource {
+		return false, nil
+	}
+	return a.sameDevice(fname, targetDir)
+}
+
+// originArchiveFileName returns the origin link of the given calendar file. It keeps the directories of the source
+// file below the source directory, only its parent directory with WithFlatOrigin.
+func (a *Algorithm) originArchiveFileName(sourceFileName string, targetFilePath string) (string, error) {
+	pathInSrc, err := a.pathInSource(sourceFileName)
+	if err != nil {
+		return "", err
+	}
+	dirName := path.Dir(pathInSrc)
+	if a.flatOrigin {
+		dirName = path.Base(dirName)
+	}
+	pathInOrigin := path.Join(dirName, filepath.Base(targetFilePath))
+	return path.Join(a.originDirFor(targetFilePath), pathInOrigin), nil
+}
+
+// pathInSource returns the slash separated path of fname relative to the source directory. Either may be given as a
+// relative or absolute path.
+func (a *Algorithm) pathInSource(fname string) (string, error) {
+	srcDir, err := filepath.Abs(a.sourceDir)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve source directory")
+	}
+	absName, err := filepath.Abs(fname)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve source file")
+	}
+	pathInSrc, err := filepath.Rel(srcDir, absName)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to compute relative path in source")
 	}
-	dirName := filepath.Dir(pathInSrc)
-	pathInOrigin := path.Join(dirName, targetFileName)
-	return path.Join(a.originArchiveDir(), pathInOrigin), nil
+	pathInSrc = filepath.ToSlash(pathInSrc)
+	if pathInSrc == ".." || strings.HasPrefix(pathInSrc, "../") {
+		return "", errors.Errorf("'%s' is not in the source directory", fname)
+	}
+	return pathInSrc, nil
 }
 
 func (a *Algorithm) originArchiveDir() string {
-	return path.Join(a.archiveDir, "origin")
+	return path.Join(a.archiveDir, originDirName)
+}
+
+// tags returns the values of the name tags of the given file. Missing tags are empty.
+func (a *Algorithm) tags(fname string) []string {
+	ret := make([]string, 0, len(a.nameTags))
+	for _, field := range a.nameTags {
+		val, err := a.tagReader(fname, field)
+		if err != nil {
+			val = ""
+		}
+		ret = append(ret, val)
+	}
+	return ret
 }
 
 func getYearMonth(t time.Time) (int, int) {
@@ -0,0 +1,235 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+func TestSortWithCaptureDateModTime(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		expectSource bool
+	}{
+		{
+			name:         "preserve source modtime",
+			expectSource: true,
+		},
+		{
+			name: "capture date as modtime",
+			opts: []Option{WithCaptureDateModTime()},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			fname := copyFixture(t, "sample1.JPG", src)
+			sourceModTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			assert.NoError(t, os.Chtimes(fname, sourceModTime, sourceModTime))
+			captureDate := time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC)
+
+			a := NewAlgorithm(src, dst, test.opts...)
+			a.extractor = func(fname string) (time.Time, error) {
+				return captureDate, nil
+			}
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			target, err := a.Sort(fname)
+			if !assert.NoError(t, err) {
+				return
+			}
+			info, err := os.Stat(target)
+			assert.NoError(t, err)
+			expected := captureDate
+			if test.expectSource {
+				expected = sourceModTime
+			}
+			assert.True(t, expected.Equal(info.ModTime()), "expected modtime %v, got %v", expected, info.ModTime())
+		})
+	}
+}
+
+func TestSortSkipsAlreadyArchivedFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		expectCopies int
+	}{
+		{
+			name:         "skip already archived file",
+			expectCopies: 1,
+		},
+		{
+			name:         "force copy",
+			opts:         []Option{WithForce()},
+			expectCopies: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			fname := copyFixture(t, "sample1.JPG", src)
+			a := NewAlgorithm(src, dst, test.opts...)
+			copies := 0
+			a.copier = func(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+				copies++
+				return files.CopyContext(ctx, src, dst, hFunc)
+			}
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			first, err := a.Sort(fname)
+			assert.NoError(t, err)
+
+			// the same content in another source directory
+			other := filepath.Join(src, "other")
+			assert.NoError(t, os.MkdirAll(other, os.ModePerm))
+			second, err := a.Sort(copyFixture(t, "sample1.JPG", other))
+			assert.NoError(t, err)
+
+			assert.Equal(t, first, second)
+			assert.Equal(t, test.expectCopies, copies)
+			same, err := sameFile(first, filepath.Join(dst, originDirName, "other", filepath.Base(first)))
+			assert.NoError(t, err)
+			assert.True(t, same)
+		})
+	}
+}
+
+func TestSortFileResult(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	fname := copyFixture(t, "sample1.JPG", src)
+	a := NewAlgorithm(src, dst)
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	first, err := a.SortFile(fname)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, fname, first.Source)
+	assert.Equal(t, ActionCopied, first.Action)
+	assert.False(t, first.CaptureDate.IsZero())
+	assert.Len(t, first.Hash, sha256.Size224)
+	assert.FileExists(t, first.Target)
+	assert.Equal(t, filepath.Join(dst, originDirName, filepath.Base(first.Target)), first.OriginLink)
+
+	second, err := a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, second.Action)
+	assert.Equal(t, first.Target, second.Target)
+	assert.Equal(t, first.OriginLink, second.OriginLink)
+	assert.Equal(t, first.Hash, second.Hash)
+
+	text := filepath.Join(src, "notes.txt")
+	assert.NoError(t, os.WriteFile(text, bytes.Repeat([]byte("no media\n"), 32), 0644))
+	ignored, err := a.SortFile(text)
+	assert.Error(t, err)
+	assert.Equal(t, ActionIgnored, ignored.Action)
+
+	empty := filepath.Join(src, "empty.jpg")
+	assert.NoError(t, os.WriteFile(empty, nil, 0644))
+	tooSmall, err := a.SortFile(empty)
+	assert.NoError(t, err, "files too short to detect their type are skipped")
+	assert.Equal(t, ActionTooSmall, tooSmall.Action)
+	short := filepath.Join(src, "short.txt")
+	assert.NoError(t, os.WriteFile(short, []byte("notes"), 0644))
+	tooSmall, err = a.SortFile(short)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionTooSmall, tooSmall.Action)
+
+	// failed files have no target, not even the temporary file
+	a.fileSystem.renamer = func(string, string) error {
+		return errors.New("rename failed")
+	}
+	failed, err := a.SortFile(copyFixture(t, "sample2.mp4", src))
+	assert.ErrorContains(t, err, "rename failed")
+	assert.Empty(t, failed.Target)
+	assert.Empty(t, failed.OriginLink)
+}
+
+func TestSortWithMinSize(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithMinSize(1))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	empty := filepath.Join(src, "empty.jpg")
+	assert.NoError(t, os.WriteFile(empty, nil, 0644))
+	res, err := a.SortFile(empty)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionTooSmall, res.Action)
+	assert.Empty(t, archiveFiles(t, dst))
+
+	res, err = a.SortFile(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+}
+
+func TestSortWithCopyBufferSize(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	// a tiny buffer copies the file in many chunks
+	a := NewAlgorithm(src, dst, WithCopyBufferSize(7))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	fname := copyFixture(t, "sample1.JPG", src)
+	res, err := a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assert.Equal(t, "7c0ed5ba", hex.EncodeToString(res.Hash)[:8])
+	expected, _ := os.ReadFile(fname)
+	actual, err := os.ReadFile(res.Target)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func copyFixture(t *testing.T, fixtureName string, dir string) string {
+	wd, _ := os.Getwd()
+	content, err := os.ReadFile(filepath.Join(wd, "../../fixtures", fixtureName))
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	fname := filepath.Join(dir, fixtureName)
+	if err := os.WriteFile(fname, content, 0644); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	return fname
+}
+
+func TestSortWithNameTags(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithNameTags("Make", "LensModel", "Model"), WithDateExtractor(func(string) (time.Time, error) {
+		return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+	}))
+	a.tagReader = func(fname string, fieldName string) (string, error) {
+		if fieldName == "LensModel" {
+			return "", errors.New("tag not present")
+		}
+		return map[string]string{"Make": "Canon", "Model": "Canon-EOS-5D"}[fieldName], nil
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	target, err := a.Sort(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba_Canon_Canon-EOS-5D.JPG"), target)
+
+	// the tags don't prevent detecting the archived copy
+	res, err := a.SortFile(filepath.Join(src, "sample1.JPG"))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+}
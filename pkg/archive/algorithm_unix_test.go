@@ -0,0 +1,59 @@
+//go:build unix
+
+package archive
+
+import (
+	"context"
+	"hash"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortWithSourceHardLinks(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	fname := copyFixture(t, "sample1.JPG", src)
+
+	a := NewAlgorithm(src, dst, WithSourceHardLinks())
+	a.copier = func(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+		t.Errorf("unexpected copy of %s to %s", src, dst)
+		return nil, errors.New("unexpected copy")
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	target, err := a.Sort(fname)
+	if !assert.NoError(t, err) {
+		return
+	}
+	same, err := sameFile(fname, target)
+	assert.NoError(t, err)
+	assert.True(t, same, "expected %s to be a hard link to %s", target, fname)
+
+	origin := filepath.Join(dst, originDirName, filepath.Base(target))
+	same, err = sameFile(origin, target)
+	assert.NoError(t, err)
+	assert.True(t, same, "expected %s to be a hard link to %s", origin, target)
+}
+
+func TestSortWithSourceHardLinksOnOtherDevice(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	fname := copyFixture(t, "sample1.JPG", src)
+
+	a := NewAlgorithm(src, dst, WithSourceHardLinks())
+	a.sameDevice = func(a, b string) (bool, error) {
+		return false, nil
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	target, err := a.Sort(fname)
+	if !assert.NoError(t, err) {
+		return
+	}
+	same, err := sameFile(fname, target)
+	assert.NoError(t, err)
+	assert.False(t, same, "expected %s to be a copy of %s", target, fname)
+}
@@ -0,0 +1,48 @@
+package archive
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// backlinkSuffix is appended to the source file name for the hard link which replaces the source file
+const backlinkSuffix = ".exifsorter-backlink"
+
+// WithBacklinks replaces every source file which is copied into the archive or already archived with a hard link to
+// its calendar file. The files stay visible in the source directory, e.g. during a slow migration, but don't take
+// their disk space twice. The source directory and the archive must be on the same file system.
+func WithBacklinks() Option {
+	return func(a *Algorithm) {
+		a.backlinks = true
+		a.backlinked = &backlinkedFiles{targets: make(map[string]string)}
+	}
+}
+
+// backlink replaces the source file by a hard link to its calendar file. The link is created next to the source file
+// and renamed over it, so the source file is kept if the link can't be created.
+func (a *Algorithm) backlink(fname string, res SortResult) error {
+	if !a.backlinks || (res.Action != ActionCopied && res.Action != ActionSkipped) {
+		return nil
+	}
+	sourceInfo, err := os.Stat(fname)
+	if err != nil {
+		return errors.Wrap(err, "could not stat source file")
+	}
+	targetInfo, err := os.Stat(res.Target)
+	if err != nil {
+		return errors.Wrap(err, "could not stat calendar file")
+	}
+	if os.SameFile(sourceInfo, targetInfo) {
+		return nil
+	}
+	link := fname + backlinkSuffix
+	if err := a.fileSystem.CreateLinks([]string{link}, res.Target); err != nil {
+		return errors.Wrap(err, "could not link source file back to archive")
+	}
+	if err := a.fileSystem.Rename(link, fname); err != nil {
+		return errors.Wrap(err, "could not replace source file by link to archive")
+	}
+	a.backlinked.add(fname, res.Target)
+	return nil
+}
@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortWithBacklinks(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithBacklinks())
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	fname := copyFixture(t, "sample1.JPG", src)
+	res, err := a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assertSameFile(t, fname, res.Target)
+	assert.NoFileExists(t, fname+backlinkSuffix)
+
+	other := filepath.Join(src, "copy.JPG")
+	assert.NoError(t, os.WriteFile(other, fixtureContent(t, "sample1.JPG"), 0644))
+	res, err = a.SortFile(other)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+	assertSameFile(t, other, res.Target)
+
+	res, err = a.SortFile(fname)
+	assert.NoError(t, err, "an already linked source file is kept")
+	assertSameFile(t, fname, res.Target)
+}
+
+// assertSameFile asserts that both paths are links to the same file.
+func assertSameFile(t *testing.T, a, b string) {
+	aInfo, err := os.Stat(a)
+	assert.NoError(t, err)
+	bInfo, err := os.Stat(b)
+	assert.NoError(t, err)
+	assert.True(t, os.SameFile(aInfo, bInfo), "%s is no link to %s", a, b)
+}
@@ -0,0 +1,27 @@
+package archive
+
+import "time"
+
+// Clock is the wall clock of the archive, e.g. to wait between retries or for the lock of the archive.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is the clock of the operating system.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// WithClock replaces the clock of the operating system, e.g. to test time dependent behavior deterministically.
+func WithClock(c Clock) Option {
+	return func(a *Algorithm) {
+		a.clock = c
+	}
+}
@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock advances its time only by sleeping.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+func TestWithClock(t *testing.T) {
+	t.Run("retry backoff", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		clock := &fakeClock{now: time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC)}
+		fs := NewOSFileSystem()
+		attempts := 0
+		fs.linker = func(oldName, newName string) error {
+			attempts++
+			return &os.LinkError{Op: "link", Err: syscall.EINTR}
+		}
+		// the retry is applied to the given file system with the given clock regardless of the order of the options
+		a := NewAlgorithm(src, dst, WithRetry(RetryPolicy{Attempts: 4, Backoff: time.Hour}), WithFileSystem(fs), WithClock(clock))
+		assert.ErrorIs(t, a.fileSystem.CreateLinks([]string{filepath.Join(dst, "link")}, filepath.Join(src, "target")), syscall.EINTR)
+		assert.Equal(t, 4, attempts)
+		assert.Equal(t, []time.Duration{time.Hour, 2 * time.Hour, 4 * time.Hour}, clock.sleeps)
+	})
+
+	t.Run("lock wait", func(t *testing.T) {
+		src, dst := t.TempDir(), t.TempDir()
+		first := NewAlgorithm(src, dst, WithLock(0))
+		if !assert.NoError(t, first.Init()) {
+			return
+		}
+		defer first.Close()
+		start := time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC)
+		clock := &fakeClock{now: start}
+		second := NewAlgorithm(src, dst, WithLock(time.Minute), WithClock(clock))
+		assert.ErrorIs(t, second.Init(), ErrArchiveLocked)
+		assert.Equal(t, time.Minute, clock.now.Sub(start), "the lock is polled for the whole wait")
+	})
+}
@@ -0,0 +1,173 @@
+package archive
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
+)
+
+type ThumbnailExtractor func(fname string, size int) ([]byte, error)
+
+var contactSheetTemplates = template.Must(template.New("header").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Month}}</title>
+<style>
+body { font-family: sans-serif; }
+.grid { display: flex; flex-wrap: wrap; gap: 4px; }
+.grid a { display: flex; width: {{.Size}}px; height: {{.Size}}px; align-items: center; justify-content: center; background: #eee; overflow: hidden; font-size: small; word-break: break-all; }
+.grid img { max-width: {{.Size}}px; max-height: {{.Size}}px; }
+</style>
+</head>
+<body>
+<h1>{{.Month}}</h1>
+<div class="grid">
+`))
+
+func init() {
+	template.Must(contactSheetTemplates.New("item").Parse(`{{if .Thumbnail}}<a href="{{.Link}}" title="{{.Name}}"><img src="{{.Thumbnail}}" alt="{{.Name}}" loading="lazy"></a>
+{{else}}<a href="{{.Link}}" title="{{.Name}}">{{.Name}}</a>
+{{end}}`))
+	template.Must(contactSheetTemplates.New("footer").Parse(`</div>
+</body>
+</html>
+`))
+}
+
+// ContactSheetWriter writes one HTML page per month of an archive. Every page shows a grid of thumbnails
+// linking to the original files.
+type ContactSheetWriter struct {
+	archiveDir string
+	outputDir  string
+	size       int
+	thumbnail  ThumbnailExtractor
+}
+
+// NewContactSheetWriter returns a ContactSheetWriter writing the pages of the archive into outputDir. Thumbnails fit
+// into a square of size pixels.
+func NewContactSheetWriter(archiveDir, outputDir string, size int) *ContactSheetWriter {
+	return &ContactSheetWriter{
+		archiveDir: archiveDir,
+		outputDir:  outputDir,
+		size:       size,
+		thumbnail:  extraction.Thumbnail,
+	}
+}
+
+// WriteAll writes the pages of all months and returns the written pages. A page shows the calendar files of its month
+// from all month directories, including those below media type and device directories. Months are processed one after
+// another, thumbnails are streamed into the page and never kept in memory.
+func (c *ContactSheetWriter) WriteAll() ([]string, error) {
+	byMonth, err := c.calendarFilesByMonth()
+	if err != nil {
+		return nil, err
+	}
+	months := make([]string, 0, len(byMonth))
+	for m := range byMonth {
+		months = append(months, m)
+	}
+	sort.Strings(months)
+	err = os.MkdirAll(c.outputDir, os.ModePerm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not create output dir '%s'", c.outputDir)
+	}
+	var pages []string
+	for _, month := range months {
+		page := filepath.Join(c.outputDir, month+".html")
+		err = c.writePage(page, month, byMonth[month])
+		if err != nil {
+			return pages, errors.Wrapf(err, "could not write contact sheet for %s", month)
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// calendarFilesByMonth returns the calendar files of the archive by their month, e.g. 2019-04. The files of a month
+// are sorted by name, so files of different month directories are shown in capture order.
+func (c *ContactSheetWriter) calendarFilesByMonth() (map[string][]string, error) {
+	byMonth := make(map[string][]string)
+	err := filepath.WalkDir(c.archiveDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		inArchive, err := pathInArchive(c.archiveDir, p)
+		if err != nil {
+			return err
+		}
+		if !isCalendarStoredFile(inArchive) {
+			return nil
+		}
+		monthDir := filepath.Dir(p)
+		month := fmt.Sprintf("%s-%s", filepath.Base(filepath.Dir(monthDir)), filepath.Base(monthDir))
+		byMonth[month] = append(byMonth[month], p)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list calendar files")
+	}
+	for _, files := range byMonth {
+		sort.Slice(files, func(i, j int) bool {
+			if bi, bj := filepath.Base(files[i]), filepath.Base(files[j]); bi != bj {
+				return bi < bj
+			}
+			return files[i] < files[j]
+		})
+	}
+	return byMonth, nil
+}
+
+func (c *ContactSheetWriter) writePage(page, month string, files []string) error {
+	f, err := os.Create(page)
+	if err != nil {
+		return errors.Wrap(err, "could not create page")
+	}
+	defer f.Close()
+	err = contactSheetTemplates.ExecuteTemplate(f, "header", struct {
+		Month string
+		Size  int
+	}{month, c.size})
+	if err != nil {
+		return err
+	}
+	for _, fname := range files {
+		err = c.writeItem(f, page, fname)
+		if err != nil {
+			return err
+		}
+	}
+	err = contactSheetTemplates.ExecuteTemplate(f, "footer", nil)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (c *ContactSheetWriter) writeItem(w io.Writer, page, fname string) error {
+	link, err := filepath.Rel(filepath.Dir(page), fname)
+	if err != nil {
+		return errors.Wrap(err, "could not compute link to original")
+	}
+	var thumbnail template.URL
+	if thumb, err := c.thumbnail(fname, c.size); err == nil {
+		thumbnail = template.URL("data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(thumb))
+	}
+	return contactSheetTemplates.ExecuteTemplate(w, "item", struct {
+		Name      string
+		Link      string
+		Thumbnail template.URL
+	}{filepath.Base(fname), filepath.ToSlash(link), thumbnail})
+}
@@ -0,0 +1,41 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContactSheetWriter(t *testing.T) {
+	root := t.TempDir()
+	month := filepath.Join(root, "2015", "12")
+	if err := os.MkdirAll(month, os.ModePerm); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	copyFixture(t, "sample1.JPG", month)
+	writeArchiveFile(t, root, "2016/01/20160101_000000_aaaaaaaa.txt", "no image")
+	writeArchiveFile(t, root, "origin/foo/20160101_000000_aaaaaaaa.txt", "not a month directory")
+	writeArchiveFile(t, root, "videos/Canon-EOS-5D/2016/01/20160101_120000_bbbbbbbb.mp4", "split by media type and device")
+	writeArchiveFile(t, root, "quarantine/undated/2016/01/IMG_0001.JPG", "not a calendar file")
+	out := filepath.Join(root, "contactsheets")
+
+	pages, err := NewContactSheetWriter(root, out, 120).WriteAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(out, "2015-12.html"), filepath.Join(out, "2016-01.html")}, pages)
+
+	content, err := os.ReadFile(filepath.Join(out, "2015-12.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `<a href="../2015/12/sample1.JPG" title="sample1.JPG"><img src="data:image/jpeg;base64,`)
+	assert.Contains(t, string(content), "width: 120px")
+
+	content, err = os.ReadFile(filepath.Join(out, "2016-01.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `<a href="../2016/01/20160101_000000_aaaaaaaa.txt" title="20160101_000000_aaaaaaaa.txt">20160101_000000_aaaaaaaa.txt</a>`)
+	assert.Contains(t, string(content), `<a href="../videos/Canon-EOS-5D/2016/01/20160101_120000_bbbbbbbb.mp4"`)
+	assert.Less(t, strings.Index(string(content), "20160101_000000_aaaaaaaa.txt"), strings.Index(string(content), "20160101_120000_bbbbbbbb.mp4"), "files are in capture order")
+	assert.NotContains(t, string(content), "IMG_0001.JPG")
+	assert.False(t, strings.Contains(string(content), "<img"))
+}
@@ -0,0 +1,230 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
+)
+
+// containerHeaderSize is the number of bytes of an entry read to decide if it is a media file
+const containerHeaderSize = 512
+
+// WithContainers sorts the media files inside zip and tar archives given to SortAll, e.g. photo dumps received as
+// .zip or .tar.gz, instead of skipping the archives as non media files. The entries are read one after another and
+// only media entries are written to a temporary file while they are sorted. Their origin links are named after the
+// path of the archive followed by the path of the entry. Sidecars inside the archive aren't considered.
+func WithContainers() Option {
+	return func(a *Algorithm) {
+		a.containers = true
+	}
+}
+
+// containerEntry is a regular file inside a zip or tar archive.
+type containerEntry struct {
+	name    string
+	modTime time.Time
+	r       io.Reader
+}
+
+// containerFormat returns the format of the archive by the extension of its name or an empty string if it isn't a
+// supported archive.
+func containerFormat(fname string) string {
+	lower := strings.ToLower(fname)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// walkContainer calls fn for every regular file inside the archive in the order they are stored. Walking stops at the
+// first error returned by fn.
+func walkContainer(fname string, format string, fn func(containerEntry) error) error {
+	if format == "zip" {
+		r, err := zip.OpenReader(fname)
+		if err != nil {
+			return fmt.Errorf("could not open zip archive: %w", err)
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("could not open %s in zip archive: %w", f.Name, err)
+			}
+			err = fn(containerEntry{name: f.Name, modTime: f.Modified, r: rc})
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(fname)
+	if err != nil {
+		return fmt.Errorf("could not open tar archive: %w", err)
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if format == "tar.gz" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("could not decompress tar archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read tar archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(containerEntry{name: hdr.Name, modTime: hdr.ModTime, r: tr}); err != nil {
+			return err
+		}
+	}
+}
+
+// sortContainerEntries sorts the entries of the archive for SortAll and adds their results to the summary. An archive
+// which can't be read is counted as failed file. Only errors which stop SortAll are returned.
+func (a *Algorithm) sortContainerEntries(ctx context.Context, sortCtx context.Context, fname string, s *SortSummary, report func(SortResult, error)) error {
+	add := func(res SortResult, err error) {
+		s.Add(res, err)
+		if report != nil {
+			report(res, err)
+		}
+	}
+	err := a.sortContainer(ctx, sortCtx, fname, add)
+	if ctxErr := ctx.Err(); ctxErr != nil && err != nil {
+		return ctxErr
+	}
+	if err == nil || errors.Is(err, ErrLowDiskSpace) {
+		return err
+	}
+	res := SortResult{Source: fname}
+	a.reportDone(sortCtx, res, err)
+	add(res, err)
+	return nil
+}
+
+// sortContainer sorts all entries of the archive and passes their results to report. The source of the results is the
+// path of the archive joined with the path of the entry. An error is returned if the archive can't be read, the
+// context is cancelled or the archive runs out of disk space. The entries are sorted with sortCtx, see sortAll.
+func (a *Algorithm) sortContainer(ctx context.Context, sortCtx context.Context, fname string, report func(SortResult, error)) error {
+	tmp, err := os.MkdirTemp("", "exifsorter-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	rel, err := a.pathInSource(fname)
+	if err != nil {
+		rel = filepath.Base(fname)
+	}
+	// the entries are sorted as if the archive was a directory of the temporary source directory
+	c := *a
+	c.sourceDir, c.linkSource, c.backlinks, c.state = tmp, false, false, nil
+	spoolDir := filepath.Join(tmp, filepath.FromSlash(rel))
+	return walkContainer(fname, containerFormat(fname), func(e containerEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		source := filepath.Join(fname, filepath.FromSlash(e.name))
+		a.reportStart(source)
+		res, err := c.sortEntry(sortCtx, e, spoolDir)
+		res.Source = source
+		a.reportDone(sortCtx, res, err)
+		if ctxErr := ctx.Err(); ctxErr != nil && err != nil {
+			return ctxErr
+		}
+		report(res, err)
+		if errors.Is(err, ErrLowDiskSpace) {
+			return err
+		}
+		return nil
+	})
+}
+
+// sortEntry writes the entry below spoolDir and archives it, if its header is the header of a media file.
+func (a *Algorithm) sortEntry(ctx context.Context, e containerEntry, spoolDir string) (SortResult, error) {
+	var res SortResult
+	name := path.Clean(strings.TrimPrefix(e.name, "/"))
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return res, fmt.Errorf("entry '%s' is outside of the archive", e.name)
+	}
+	br := bufio.NewReaderSize(e.r, containerHeaderSize)
+	head, err := br.Peek(containerHeaderSize)
+	if err != nil && err != io.EOF {
+		return res, fmt.Errorf("could not read entry: %w", err)
+	}
+	isMedia, err := extraction.IsVideoOrImageFromReader(bytes.NewReader(head))
+	if errors.Is(err, extraction.ErrTruncatedHeader) {
+		res.Action = ActionTooSmall
+		return res, nil
+	}
+	if err != nil {
+		return res, fmt.Errorf("could not determine media type: %w", err)
+	}
+	if !isMedia {
+		res.Action = ActionIgnored
+		return res, ErrNotMediaFile
+	}
+
+	spool := filepath.Join(spoolDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(spool), 0700); err != nil {
+		return res, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.Remove(spool)
+	if err := writeEntry(spool, br, e.modTime); err != nil {
+		return res, err
+	}
+	return a.archiveFile(ctx, spool)
+}
+
+// writeEntry writes the content read from r to fname and sets its modification time, so that it is used as capture
+// date fallback like the modification time of a regular file.
+func writeEntry(fname string, r io.Reader, modTime time.Time) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return fmt.Errorf("could not create temporary file: %w", err)
+	}
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not extract entry: %w", err)
+	}
+	if !modTime.IsZero() {
+		if err := os.Chtimes(fname, modTime, modTime); err != nil {
+			return fmt.Errorf("could not set modification time of temporary file: %w", err)
+		}
+	}
+	return nil
+}
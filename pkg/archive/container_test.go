@@ -0,0 +1,85 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixtureContent returns the content of the given fixture.
+func fixtureContent(t *testing.T, fixtureName string) []byte {
+	content, err := os.ReadFile(filepath.Join("../../fixtures", fixtureName))
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	return content
+}
+
+func TestSortAllContainers(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	modTime := time.Date(2017, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	zipFile, err := os.Create(filepath.Join(src, "dump.zip"))
+	assert.NoError(t, err)
+	zw := zip.NewWriter(zipFile)
+	for name, content := range map[string][]byte{"DCIM/sample1.JPG": fixtureContent(t, "sample1.JPG"), "readme.md": []byte(strings.Repeat("holiday photos\n", 100))} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+		assert.NoError(t, err)
+		_, err = w.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, zipFile.Close())
+
+	tarFile, err := os.Create(filepath.Join(src, "dump.tgz"))
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(tarFile)
+	tw := tar.NewWriter(gz)
+	video := fixtureContent(t, "sample2.mp4")
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "videos/sample2.mp4", Mode: 0644, Size: int64(len(video)), ModTime: modTime, Typeflag: tar.TypeReg}))
+	_, err = tw.Write(video)
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, tarFile.Close())
+
+	a := NewAlgorithm(src, dst, WithContainers())
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	var sources []string
+	summary, err := a.SortAll(context.Background(), []string{filepath.Join(src, "dump.zip"), filepath.Join(src, "dump.tgz")}, func(res SortResult, err error) {
+		sources = append(sources, res.Source)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, summary.Scanned)
+	assert.Equal(t, 2, summary.Sorted)
+	assert.Equal(t, 1, summary.NotMedia)
+	assert.ElementsMatch(t, []string{filepath.Join(src, "dump.zip/DCIM/sample1.JPG"), filepath.Join(src, "dump.zip/readme.md"), filepath.Join(src, "dump.tgz/videos/sample2.mp4")}, sources)
+	assert.FileExists(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba.JPG"))
+	assert.FileExists(t, filepath.Join(dst, "2017/03/20170304_100000_6bd02fe8.mp4"), "the modification time of the entry is the fallback capture date")
+	assert.FileExists(t, filepath.Join(dst, "origin/dump.zip/DCIM/20151224_135917_7c0ed5ba.JPG"))
+	assert.FileExists(t, filepath.Join(dst, "origin/dump.tgz/videos/20170304_100000_6bd02fe8.mp4"))
+	assert.Len(t, archiveFiles(t, dst), 4, "the readme isn't archived")
+}
+
+func TestSortAllBrokenContainer(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	fname := filepath.Join(src, "broken.zip")
+	assert.NoError(t, os.WriteFile(fname, []byte("no zip archive"), 0644))
+	a := NewAlgorithm(src, dst, WithContainers())
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	summary, err := a.SortAll(context.Background(), []string{fname}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Failed)
+}
@@ -0,0 +1,48 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrCopyMismatch is returned if a copied file doesn't have the checksum of its source.
+var ErrCopyMismatch = errors.New("copy does not match source")
+
+// WithCopyVerification reads every copied file again after it was written and compares its checksum to the one computed
+// while copying. A mismatching copy is removed and the file fails. This doubles the read I/O of copies.
+func WithCopyVerification() Option {
+	return func(a *Algorithm) {
+		a.verifyCopies = true
+	}
+}
+
+// copyToTemp copies fname to tmpFile and returns the checksum of the content. The temporary file is removed if copying
+// or the verification with WithCopyVerification fails.
+func (a *Algorithm) copyToTemp(ctx context.Context, fname string, tmpFile string) ([]byte, error) {
+	sum, err := a.copier(ctx, fname, tmpFile, sha256.New224())
+	if err == nil && a.verifyCopies {
+		err = a.verifyCopy(fname, tmpFile, sum)
+	}
+	if err != nil {
+		_ = a.fileSystem.EnsureAbsent(tmpFile)
+		return nil, err
+	}
+	return sum, nil
+}
+
+// verifyCopy returns ErrCopyMismatch if the written file doesn't have the expected checksum.
+func (a *Algorithm) verifyCopy(fname string, written string, expected []byte) error {
+	actual, err := a.hasher(written, sha256.New224())
+	if err != nil {
+		return fmt.Errorf("could not read copy for verification: %w", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		return fmt.Errorf("%w: copy of '%s' has checksum %s instead of %s", ErrCopyMismatch, fname,
+			hex.EncodeToString(actual), hex.EncodeToString(expected))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package archive
+
+import (
+	"context"
+	"hash"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+func TestSortWithCopyVerification(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithCopyVerification())
+	corrupt := false
+	a.copier = func(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+		sum, err := files.CopyContext(ctx, src, dst, hFunc)
+		if err == nil && corrupt {
+			// the disk silently wrote something else
+			err = os.WriteFile(dst, []byte("garbage"), 0644)
+		}
+		return sum, err
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	fname := copyFixture(t, "sample1.JPG", src)
+	corrupt = true
+	res, err := a.SortFile(fname)
+	assert.ErrorIs(t, err, ErrCopyMismatch)
+	assert.ErrorContains(t, err, "instead of 7c0ed5ba")
+	assert.Empty(t, res.Target)
+	assert.Empty(t, archiveFiles(t, dst), "the corrupt copy is removed")
+
+	corrupt = false
+	res, err = a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+}
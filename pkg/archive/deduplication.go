@@ -2,14 +2,44 @@ package archive
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
 )
 
 type FileDeleter func(file string) error
 
+// KeepPolicy decides which of multiple calendar files of a duplicate group is kept.
+type KeepPolicy int
+
+const (
+	// KeepFirstLexical keeps the calendar file whose path sorts first
+	KeepFirstLexical KeepPolicy = iota
+	// KeepEarliest keeps the calendar file with the earliest capture date encoded in its name
+	KeepEarliest
+	// KeepLatest keeps the calendar file with the latest capture date encoded in its name
+	KeepLatest
+)
+
+// ParseKeepPolicy returns the KeepPolicy with the given name.
+func ParseKeepPolicy(name string) (KeepPolicy, error) {
+	switch name {
+	case "first":
+		return KeepFirstLexical, nil
+	case "earliest":
+		return KeepEarliest, nil
+	case "latest":
+		return KeepLatest, nil
+	}
+	return KeepFirstLexical, fmt.Errorf("unknown keep policy '%s'", name)
+}
+
 type DeDupTask struct {
 	// ToKeep is the file path of the original to keep
 	ToKeep string
@@ -19,11 +49,62 @@ type DeDupTask struct {
 	DeleteFiles []string
 }
 
-// DeduplicateAll deduplicates all given files in the directory. This method actually executes the file operations if noDryRun is set.
-func DeduplicateAll(archiveRoot string, duplicates [][]string, creator FileSystem) error {
+// DedupOption configures optional behaviour of the deduplication
+type DedupOption func(c *dedupConfig)
+
+type dedupConfig struct {
+	originFallback bool
+	dateExtractor  SourcedDateExtractor
+	naming         naming
+}
+
+// SourcedDateExtractor returns the capture date of a file and where it was read from, e.g.
+// extraction.CaptureDateWithSource.
+type SourcedDateExtractor func(fname string) (time.Time, extraction.DateSource, error)
+
+// WithOriginFallback keeps a file below origin if none of the duplicates is in a calendar directory anymore. The kept
+// file is linked into its calendar directory again, if its name contains the capture date. Without this option such
+// groups are an error.
+func WithOriginFallback() DedupOption {
+	return func(c *dedupConfig) {
+		c.originFallback = true
+	}
+}
+
+// WithMetadataDates prefers the calendar files named with the capture date read from their meta data. Byte-identical
+// duplicates may be named with different dates, e.g. if one was sorted by its modification time before its meta data
+// was fixed. The extractor reads the date of every calendar file of a group. Files whose date fell back to the
+// modification time or doesn't match their name are only kept if no file of the group is named with its meta data
+// date. The policy decides among the preferred files.
+func WithMetadataDates(e SourcedDateExtractor) DedupOption {
+	return func(c *dedupConfig) {
+		c.dateExtractor = e
+	}
+}
 
+// WithDedupTimeFormat parses the capture dates in the names of the archive files with the time format the archive was
+// sorted with, see WithTimeFormat. The earliest and latest policies and WithOriginFallback depend on these dates.
+func WithDedupTimeFormat(format string) DedupOption {
+	return func(c *dedupConfig) {
+		c.naming.timeFormat = format
+	}
+}
+
+// WithDedupSubSecondNames parses the names of an archive sorted with WithSubSecondNames.
+func WithDedupSubSecondNames() DedupOption {
+	return func(c *dedupConfig) {
+		c.naming.subSec = true
+	}
+}
+
+// DeduplicateAll deduplicates all groups of the source in the directory. The file operations are executed by creator.
+func DeduplicateAll(archiveRoot string, source DuplicateSource, policy KeepPolicy, creator FileSystem, opts ...DedupOption) error {
+	duplicates, err := source.Groups()
+	if err != nil {
+		return fmt.Errorf("failed to read duplicates: %w", err)
+	}
 	for _, duplicateFiles := range duplicates {
-		task, err := DeDuplicate(archiveRoot, duplicateFiles)
+		task, err := DeDuplicate(archiveRoot, duplicateFiles, policy, opts...)
 		if err != nil {
 			return fmt.Errorf("failed to compute deduplicateTask for %s: %w", duplicateFiles, err)
 		}
@@ -41,6 +122,116 @@ func DeduplicateAll(archiveRoot string, duplicates [][]string, creator FileSyste
 	return nil
 }
 
+// DeduplicationGroup is the planned deduplication of a single group of duplicated files.
+type DeduplicationGroup struct {
+	DeDupTask
+	// FreedBytes is the disk space released by executing the task
+	FreedBytes int64
+}
+
+// DeduplicationSummary is the plan to deduplicate all groups of duplicated files.
+type DeduplicationSummary struct {
+	Groups []DeduplicationGroup
+	// FreedBytes is the disk space released by executing all tasks
+	FreedBytes int64
+}
+
+// PlanDeduplication computes the deduplication tasks for all groups of the source without touching any file. The
+// stater is used to compute the disk space freed by the tasks. Files which are already hard links to the kept file
+// don't free any space.
+func PlanDeduplication(archiveRoot string, source DuplicateSource, policy KeepPolicy, stat Stater, opts ...DedupOption) (DeduplicationSummary, error) {
+	duplicates, err := source.Groups()
+	if err != nil {
+		return DeduplicationSummary{}, fmt.Errorf("failed to read duplicates: %w", err)
+	}
+	var ret DeduplicationSummary
+	for _, duplicateFiles := range duplicates {
+		task, err := DeDuplicate(archiveRoot, duplicateFiles, policy, opts...)
+		if err != nil {
+			return DeduplicationSummary{}, fmt.Errorf("failed to compute deduplicateTask for %s: %w", duplicateFiles, err)
+		}
+		freed, err := freedBytes(task, stat)
+		if err != nil {
+			return DeduplicationSummary{}, fmt.Errorf("failed to compute freed space for %s: %w", duplicateFiles, err)
+		}
+		ret.Groups = append(ret.Groups, DeduplicationGroup{DeDupTask: task, FreedBytes: freed})
+		ret.FreedBytes += freed
+	}
+	return ret, nil
+}
+
+// freedBytes sums up the size of all files of the task which don't share their inode with the kept file or an
+// already counted file.
+func freedBytes(task DeDupTask, stat Stater) (int64, error) {
+	keep, err := stat(task.ToKeep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat file to keep: %w", err)
+	}
+	seen := []os.FileInfo{keep}
+	var freed int64
+	for _, f := range append(append([]string{}, task.ReCreateLinks...), task.DeleteFiles...) {
+		info, err := stat(f)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat file: %w", err)
+		}
+		if sameFileAsAny(info, seen) {
+			continue
+		}
+		seen = append(seen, info)
+		freed += info.Size()
+	}
+	return freed, nil
+}
+
+func sameFileAsAny(info os.FileInfo, others []os.FileInfo) bool {
+	for _, o := range others {
+		if os.SameFile(info, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// Write prints the summary grouped by duplicate group to the given writer.
+func (s DeduplicationSummary) Write(w io.Writer) error {
+	for i, g := range s.Groups {
+		lines := []string{
+			fmt.Sprintf("group %d: frees %s", i+1, formatBytes(g.FreedBytes)),
+			fmt.Sprintf("  keep     %s", g.ToKeep),
+		}
+		for _, l := range g.ReCreateLinks {
+			lines = append(lines, fmt.Sprintf("  relink   %s", l))
+		}
+		for _, d := range g.DeleteFiles {
+			lines = append(lines, fmt.Sprintf("  delete   %s", d))
+		}
+		for _, l := range lines {
+			if _, err := fmt.Fprintln(w, l); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d groups, %s to be freed\n", len(s.Groups), formatBytes(s.FreedBytes))
+	return err
+}
+
+// formatBytes returns the given number of bytes in a human readable form.
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
 // DeDuplicate files in the given archiveRoot. All files in duplicateFiles must start with the prefix archiveRoot.
 // This function assumes the canonical archive layout:
 // /archiveRoot/
@@ -52,11 +243,29 @@ func DeduplicateAll(archiveRoot string, duplicates [][]string, creator FileSyste
 //	   / dirOne
 //	   / dirTwo
 //
-// The file in DedupTask.ToKeep will be in the directory /YEAR/MONTH. If there are multiple files in the /YEAR/MONTH directories, the first file is kept.
-// At most one file in every directory below /origin is kept.
-func DeDuplicate(archiveRoot string, duplicateFiles []string) (DeDupTask, error) {
+// The file in DedupTask.ToKeep will be in the directory /YEAR/MONTH. If there are multiple files in the /YEAR/MONTH directories,
+// the policy decides which file is kept. Files whose name doesn't start with a date are only kept by the KeepEarliest and
+// KeepLatest policies if no other calendar file is present.
+// At most one file in every directory below /origin is kept. If there is no file in the /YEAR/MONTH directories and
+// WithOriginFallback is given, one of the files below /origin is kept instead.
+func DeDuplicate(archiveRoot string, duplicateFiles []string, policy KeepPolicy, opts ...DedupOption) (DeDupTask, error) {
+	cfg := dedupConfig{naming: defaultNaming}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	sort.Strings(duplicateFiles)
 	ret := DeDupTask{}
+	var calendarFiles []string
+	for _, f := range duplicateFiles {
+		inArchive, err := pathInArchive(archiveRoot, f)
+		if err != nil {
+			return DeDupTask{}, fmt.Errorf("failed to find path in directory: %w", err)
+		}
+		if isCalendarStoredFile(inArchive) {
+			calendarFiles = append(calendarFiles, f)
+		}
+	}
+	toKeep := selectToKeep(cfg.preferred(calendarFiles), policy, cfg.naming)
 	foundInDirectory := make(map[string]struct{})
 	for _, f := range duplicateFiles {
 		inArchive, err := pathInArchive(archiveRoot, f)
@@ -64,7 +273,7 @@ func DeDuplicate(archiveRoot string, duplicateFiles []string) (DeDupTask, error)
 			return DeDupTask{}, fmt.Errorf("failed to find path in directory: %w", err)
 		}
 		if isCalendarStoredFile(inArchive) {
-			if ret.ToKeep == "" {
+			if f == toKeep {
 				ret.ToKeep = f
 			} else {
 				ret.DeleteFiles = append(ret.DeleteFiles, f)
@@ -79,12 +288,87 @@ func DeDuplicate(archiveRoot string, duplicateFiles []string) (DeDupTask, error)
 		foundInDirectory[dir] = struct{}{}
 		ret.ReCreateLinks = append(ret.ReCreateLinks, f)
 	}
+	if ret.ToKeep == "" && cfg.originFallback && len(ret.ReCreateLinks) > 0 {
+		return promoteOriginFile(archiveRoot, ret, policy, cfg.naming), nil
+	}
 	if ret.ToKeep == "" {
 		return DeDupTask{}, fmt.Errorf("there is no file in calendar directory")
 	}
 	return ret, nil
 }
 
+// preferred returns the calendar files named with the capture date read from their meta data, see WithMetadataDates.
+// All files are returned if there is no such file or no extractor is configured.
+func (c dedupConfig) preferred(calendarFiles []string) []string {
+	if c.dateExtractor == nil {
+		return calendarFiles
+	}
+	var ret []string
+	for _, f := range calendarFiles {
+		date, source, err := c.dateExtractor(f)
+		if err != nil || !source.FromMetadata() {
+			continue
+		}
+		nameDate, err := c.naming.dateFromName(filepath.Base(f))
+		if err == nil && c.naming.formatDate(nameDate) == c.naming.formatDate(date) {
+			ret = append(ret, f)
+		}
+	}
+	if len(ret) == 0 {
+		return calendarFiles
+	}
+	return ret
+}
+
+// promoteOriginFile keeps one of the links of the task instead of the missing calendar file. The kept file is linked
+// into its calendar directory again, if its name contains the capture date.
+func promoteOriginFile(archiveRoot string, task DeDupTask, policy KeepPolicy, n naming) DeDupTask {
+	task.ToKeep = selectToKeep(task.ReCreateLinks, policy, n)
+	links := make([]string, 0, len(task.ReCreateLinks))
+	for _, l := range task.ReCreateLinks {
+		if l != task.ToKeep {
+			links = append(links, l)
+		}
+	}
+	// a file below the origin directory of a media type directory belongs into the calendar directories next to it
+	calendarRoot := archiveRoot
+	if inArchive, err := pathInArchive(archiveRoot, task.ToKeep); err == nil {
+		calendarRoot = path.Join(archiveRoot, path.Dir(originRootOf(inArchive)))
+	}
+	if calendarFile, err := n.calendarPath(calendarRoot, filepath.Base(task.ToKeep)); err == nil {
+		links = append(links, calendarFile)
+	}
+	task.ReCreateLinks = links
+	return task
+}
+
+// selectToKeep returns the calendar file to keep according to the policy. The calendar files must be sorted and named
+// by the given naming.
+func selectToKeep(calendarFiles []string, policy KeepPolicy, n naming) string {
+	if len(calendarFiles) == 0 {
+		return ""
+	}
+	if policy == KeepFirstLexical {
+		return calendarFiles[0]
+	}
+	toKeep := ""
+	var toKeepDate time.Time
+	for _, f := range calendarFiles {
+		date, err := n.dateFromName(filepath.Base(f))
+		if err != nil {
+			continue
+		}
+		if toKeep == "" || policy == KeepEarliest && date.Before(toKeepDate) || policy == KeepLatest && date.After(toKeepDate) {
+			toKeep = f
+			toKeepDate = date
+		}
+	}
+	if toKeep == "" {
+		return calendarFiles[0]
+	}
+	return toKeep
+}
+
 // pathInArchive returns the relative path within the archive. Returns an error if the file is not within the archiveRoot
 func pathInArchive(archiveRoot string, filename string) (string, error) {
 	rel, err := filepath.Rel(archiveRoot, filename)
@@ -94,9 +378,26 @@ func pathInArchive(archiveRoot string, filename string) (string, error) {
 	return rel, err
 }
 
-// isCalendarStoredFile returns true if the file is stored in a calendar directory within the archive. The filename must be a relative path within the archive.
+// isCalendarStoredFile returns true if the file is stored in a calendar directory within the archive. The calendar
+// directories may be below a media type directory and a device directory, e.g. photos/Canon-EOS-5D/2019/04. The
+// filename must be a relative path within the archive in slash or OS separated form, absolute paths are never
+// calendar files. There is no day level below the month directories.
 func isCalendarStoredFile(filename string) bool {
-	matched, err := path.Match("[0-9][0-9][0-9][0-9]/[0-9][0-9]/*", filename)
+	filename = filepath.ToSlash(filename)
+	if path.IsAbs(filename) || filepath.IsAbs(filename) {
+		return false
+	}
+	parts := strings.Split(filename, "/")
+	if len(parts) < 3 || len(parts) > 5 {
+		return false
+	}
+	prefixes := parts[:len(parts)-3]
+	for i, prefix := range prefixes {
+		if prefix == "" || prefix == "." || prefix == ".." || prefix == originDirName || (i == 0 && prefix == quarantineDirName) {
+			return false
+		}
+	}
+	matched, err := path.Match("[0-9][0-9][0-9][0-9]/[0-9][0-9]/*", strings.Join(parts[len(parts)-3:], "/"))
 	if err != nil {
 		panic(err)
 	}
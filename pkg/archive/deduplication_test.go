@@ -1,15 +1,24 @@
 package archive
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
 )
 
 func TestDeDuplicate(t *testing.T) {
 	type args struct {
 		archiveRoot    string
 		duplicateFiles []string
+		policy         KeepPolicy
+		opts           []DedupOption
 	}
 	tests := []struct {
 		name      string
@@ -35,6 +44,33 @@ func TestDeDuplicate(t *testing.T) {
 			want:      DeDupTask{},
 			errAssert: assert.Error,
 		},
+		{
+			name: "keep file in origin as fallback",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/origin/foo/20190417_151708_537842c8.jpg", "Archive/origin/bar/20190417_151708_537842c8.jpg", "Archive/origin/bar/IMG_0001.jpg"},
+				opts:           []DedupOption{WithOriginFallback()},
+			},
+			want: DeDupTask{
+				ToKeep:        "Archive/origin/bar/20190417_151708_537842c8.jpg",
+				ReCreateLinks: []string{"Archive/origin/foo/20190417_151708_537842c8.jpg", "Archive/2019/04/20190417_151708_537842c8.jpg"},
+				DeleteFiles:   []string{"Archive/origin/bar/IMG_0001.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
+		{
+			name: "fallback without date in name",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/origin/foo/IMG_0001.jpg", "Archive/origin/bar/IMG_0001.jpg"},
+				opts:           []DedupOption{WithOriginFallback()},
+			},
+			want: DeDupTask{
+				ToKeep:        "Archive/origin/bar/IMG_0001.jpg",
+				ReCreateLinks: []string{"Archive/origin/foo/IMG_0001.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
 		{
 			name: "deduplicate files in calendar directory",
 			args: args{
@@ -60,12 +96,183 @@ func TestDeDuplicate(t *testing.T) {
 			},
 			errAssert: assert.NoError,
 		},
+		{
+			name: "keep earliest file in calendar directory",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/2019/04/20190417_133044_537842c8.jpg", "Archive/2018/12/20181224_080000_537842c8.jpg", "Archive/2019/04/20190417_151708_537842c8.jpg"},
+				policy:         KeepEarliest,
+			},
+			want: DeDupTask{
+				ToKeep:      "Archive/2018/12/20181224_080000_537842c8.jpg",
+				DeleteFiles: []string{"Archive/2019/04/20190417_133044_537842c8.jpg", "Archive/2019/04/20190417_151708_537842c8.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
+		{
+			name: "keep latest file in calendar directory",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/2019/04/20190417_133044_537842c8.jpg", "Archive/2018/12/20181224_080000_537842c8.jpg", "Archive/2019/04/20190417_151708_537842c8.jpg"},
+				policy:         KeepLatest,
+			},
+			want: DeDupTask{
+				ToKeep:      "Archive/2019/04/20190417_151708_537842c8.jpg",
+				DeleteFiles: []string{"Archive/2018/12/20181224_080000_537842c8.jpg", "Archive/2019/04/20190417_133044_537842c8.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
+		{
+			name: "keep earliest ignores files without date in name",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/2019/04/20190417_133044_537842c8.jpg", "Archive/2019/04/IMG_0001.jpg"},
+				policy:         KeepEarliest,
+			},
+			want: DeDupTask{
+				ToKeep:      "Archive/2019/04/20190417_133044_537842c8.jpg",
+				DeleteFiles: []string{"Archive/2019/04/IMG_0001.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
+		{
+			name: "keep latest file with custom time format",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/2019/04/20190417_133044.100_537842c8.jpg", "Archive/2019/04/20190417_133044.900_537842c8.jpg"},
+				policy:         KeepLatest,
+				opts:           []DedupOption{WithDedupTimeFormat("20060102_150405.000")},
+			},
+			want: DeDupTask{
+				ToKeep:      "Archive/2019/04/20190417_133044.900_537842c8.jpg",
+				DeleteFiles: []string{"Archive/2019/04/20190417_133044.100_537842c8.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
+		{
+			name: "keep file in origin as fallback with sub second names",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/origin/foo/20190417_151708_07_537842c8.jpg", "Archive/origin/bar/20190417_151708_07_537842c8.jpg"},
+				opts:           []DedupOption{WithOriginFallback(), WithDedupSubSecondNames()},
+			},
+			want: DeDupTask{
+				ToKeep:        "Archive/origin/bar/20190417_151708_07_537842c8.jpg",
+				ReCreateLinks: []string{"Archive/origin/foo/20190417_151708_07_537842c8.jpg", "Archive/2019/04/20190417_151708_07_537842c8.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
+		{
+			name: "metadata dates keep the file named with the exif date",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/2019/04/20190417_133044_537842c8.jpg", "Archive/2018/11/20181102_080000_537842c8.jpg"},
+				opts:           []DedupOption{WithMetadataDates(fixedDate(time.Date(2019, 4, 17, 13, 30, 44, 0, time.Local), extraction.SourceExifDateTimeOriginal))},
+			},
+			want: DeDupTask{
+				ToKeep:      "Archive/2019/04/20190417_133044_537842c8.jpg",
+				DeleteFiles: []string{"Archive/2018/11/20181102_080000_537842c8.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
+		{
+			name: "metadata dates fall back to the policy without a metadata date",
+			args: args{
+				archiveRoot:    "Archive",
+				duplicateFiles: []string{"Archive/2019/04/20190417_133044_537842c8.jpg", "Archive/2018/11/20181102_080000_537842c8.jpg"},
+				opts:           []DedupOption{WithMetadataDates(fixedDate(time.Date(2019, 4, 17, 13, 30, 44, 0, time.Local), extraction.SourceModTime))},
+			},
+			want: DeDupTask{
+				ToKeep:      "Archive/2018/11/20181102_080000_537842c8.jpg",
+				DeleteFiles: []string{"Archive/2019/04/20190417_133044_537842c8.jpg"},
+			},
+			errAssert: assert.NoError,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := DeDuplicate(tt.args.archiveRoot, tt.args.duplicateFiles)
+			got, err := DeDuplicate(tt.args.archiveRoot, tt.args.duplicateFiles, tt.args.policy, tt.args.opts...)
 			tt.errAssert(t, err)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+// fixedDate returns a SourcedDateExtractor which returns the given date and source for every file.
+func fixedDate(date time.Time, source extraction.DateSource) SourcedDateExtractor {
+	return func(string) (time.Time, extraction.DateSource, error) {
+		return date, source, nil
+	}
+}
+
+func TestPlanDeduplication(t *testing.T) {
+	root := t.TempDir()
+	writeArchiveFile(t, root, "2019/04/20190417_133044_537842c8.jpg", "0123456789")
+	writeArchiveFile(t, root, "2019/04/20190417_151708_537842c8.jpg", "0123456789")
+	writeArchiveFile(t, root, "origin/bar/20190417_151708_537842c8.jpg", "0123456789")
+	linkArchiveFile(t, root, "2019/04/20190417_133044_537842c8.jpg", "origin/foo/20190417_133044_537842c8.jpg")
+	writeArchiveFile(t, root, "2020/01/20200101_000000_aaaaaaaa.jpg", "01234")
+	writeArchiveFile(t, root, "2020/01/20200101_000001_aaaaaaaa.jpg", "01234")
+
+	duplicates := [][]string{
+		{
+			filepath.Join(root, "2019/04/20190417_133044_537842c8.jpg"),
+			filepath.Join(root, "2019/04/20190417_151708_537842c8.jpg"),
+			filepath.Join(root, "origin/bar/20190417_151708_537842c8.jpg"),
+			filepath.Join(root, "origin/foo/20190417_133044_537842c8.jpg"),
+		},
+		{
+			filepath.Join(root, "2020/01/20200101_000000_aaaaaaaa.jpg"),
+			filepath.Join(root, "2020/01/20200101_000001_aaaaaaaa.jpg"),
+		},
+	}
+	summary, err := PlanDeduplication(root, DuplicateGroups(duplicates), KeepFirstLexical, os.Stat)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int64(25), summary.FreedBytes)
+	if assert.Len(t, summary.Groups, 2) {
+		assert.Equal(t, int64(20), summary.Groups[0].FreedBytes)
+		assert.Equal(t, int64(5), summary.Groups[1].FreedBytes)
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, summary.Write(&out))
+	expected := strings.ReplaceAll(`group 1: frees 20 B
+  keep     ROOT/2019/04/20190417_133044_537842c8.jpg
+  relink   ROOT/origin/bar/20190417_151708_537842c8.jpg
+  relink   ROOT/origin/foo/20190417_133044_537842c8.jpg
+  delete   ROOT/2019/04/20190417_151708_537842c8.jpg
+group 2: frees 5 B
+  keep     ROOT/2020/01/20200101_000000_aaaaaaaa.jpg
+  delete   ROOT/2020/01/20200101_000001_aaaaaaaa.jpg
+2 groups, 25 B to be freed
+`, "ROOT", root)
+	assert.Equal(t, expected, out.String())
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "1023 B", formatBytes(1023))
+	assert.Equal(t, "1.0 KiB", formatBytes(1024))
+	assert.Equal(t, "1.5 MiB", formatBytes(1024*1024*3/2))
+}
+
+func writeArchiveFile(t *testing.T, root, name, content string) {
+	p := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+}
+
+func linkArchiveFile(t *testing.T, root, target, name string) {
+	p := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	if err := os.Link(filepath.Join(root, target), p); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+}
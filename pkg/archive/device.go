@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"strings"
+)
+
+// unknownDevice is the device directory of files without the device fields
+const unknownDevice = "unknown-device"
+
+// WithDeviceDirs sorts the files into calendar directories below a directory per capturing device, e.g.
+// Canon-EOS-5D/2019/04. The device directory is named by the values of the given EXIF fields, e.g. "Make" and "Model".
+// A value is left out if the following value starts with it, like the make in most model names. Files without any
+// of the fields, or with values made only of dots, are sorted below unknown-device. The device directories are below
+// the media type directories.
+func WithDeviceDirs(fieldNames ...string) Option {
+	return func(a *Algorithm) {
+		a.deviceTags = fieldNames
+	}
+}
+
+// deviceDir returns the name of the device directory for the given file or an empty string if the archive isn't
+// split by devices.
+func (a *Algorithm) deviceDir(fname string) string {
+	if len(a.deviceTags) == 0 {
+		return ""
+	}
+	var values []string
+	for _, field := range a.deviceTags {
+		val, err := a.tagReader(fname, field)
+		if err != nil || val == "" {
+			continue
+		}
+		if n := len(values); n > 0 && strings.HasPrefix(strings.ToLower(val), strings.ToLower(values[n-1])) {
+			values = values[:n-1]
+		}
+		values = append(values, val)
+	}
+	device := strings.Join(values, "-")
+	if strings.Trim(device, ".") == "" || device == originDirName || device == quarantineDirName {
+		return unknownDevice
+	}
+	return device
+}
@@ -0,0 +1,76 @@
+package archive
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDeviceDirs(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []Option
+		tags           map[string]string
+		expectedTarget string
+	}{
+		{
+			name:           "make and model",
+			opts:           []Option{WithDeviceDirs("Make", "Model")},
+			tags:           map[string]string{"Make": "Apple", "Model": "iPhone-12"},
+			expectedTarget: "Apple-iPhone-12/2015/12/20151224_135917_7c0ed5ba.JPG",
+		},
+		{
+			name:           "make in model",
+			opts:           []Option{WithDeviceDirs("Make", "Model")},
+			tags:           map[string]string{"Make": "Canon", "Model": "Canon-EOS-5D"},
+			expectedTarget: "Canon-EOS-5D/2015/12/20151224_135917_7c0ed5ba.JPG",
+		},
+		{
+			name:           "unknown device",
+			opts:           []Option{WithDeviceDirs("Make", "Model")},
+			expectedTarget: "unknown-device/2015/12/20151224_135917_7c0ed5ba.JPG",
+		},
+		{
+			name:           "dots only",
+			opts:           []Option{WithDeviceDirs("Make", "Model")},
+			tags:           map[string]string{"Make": ".."},
+			expectedTarget: "unknown-device/2015/12/20151224_135917_7c0ed5ba.JPG",
+		},
+		{
+			name:           "below media type dir",
+			opts:           []Option{WithDeviceDirs("Make"), WithMediaTypeDirs("photos", "videos")},
+			tags:           map[string]string{"Make": "Canon"},
+			expectedTarget: "photos/Canon/2015/12/20151224_135917_7c0ed5ba.JPG",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			opts := append([]Option{WithDateExtractor(func(string) (time.Time, error) {
+				return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+			})}, test.opts...)
+			a := NewAlgorithm(src, dst, opts...)
+			a.tagReader = func(fname string, fieldName string) (string, error) {
+				if val, found := test.tags[fieldName]; found {
+					return val, nil
+				}
+				return "", errors.New("tag not present")
+			}
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			target, err := a.Sort(copyFixture(t, "sample1.JPG", src))
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join(dst, test.expectedTarget), target)
+			assert.True(t, strings.HasPrefix(target, dst+string(filepath.Separator)), "%s is outside of the archive", target)
+
+			report, err := Verify(dst)
+			assert.NoError(t, err)
+			assert.True(t, report.Ok(), "%+v", report)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The formats of the groups of duplicates read by NewDuplicateReader.
+const (
+	// DelimitedFormat is one group per line, the files are separated by a delimiter
+	DelimitedFormat = "delimited"
+	// JSONFormat is an array of groups, each group is an array of files
+	JSONFormat = "json"
+	// NULFormat is NUL separated files, each group is terminated by an empty entry
+	NULFormat = "nul"
+)
+
+// DuplicateSource provides the groups of duplicated files to deduplicate, e.g. from a file or a database.
+type DuplicateSource interface {
+	Groups() ([][]string, error)
+}
+
+// DuplicateGroups is a DuplicateSource of the given groups.
+type DuplicateGroups [][]string
+
+// Groups returns the groups.
+func (g DuplicateGroups) Groups() ([][]string, error) {
+	return g, nil
+}
+
+// DuplicateSourceFunc is a DuplicateSource computing the groups by calling the function, e.g. a closure over
+// FindDuplicates.
+type DuplicateSourceFunc func() ([][]string, error)
+
+// Groups calls f.
+func (f DuplicateSourceFunc) Groups() ([][]string, error) {
+	return f()
+}
+
+// duplicateReader reads the groups of duplicates in a format.
+type duplicateReader struct {
+	r         io.Reader
+	format    string
+	delimiter string
+}
+
+// NewDuplicateReader returns a DuplicateSource reading the groups from r in the given format, e.g. the output of
+// https://gitlab.com/opennota/findimagedupes in DelimitedFormat. The delimiter is only used by DelimitedFormat.
+func NewDuplicateReader(r io.Reader, format string, delimiter string) DuplicateSource {
+	return duplicateReader{r: r, format: format, delimiter: delimiter}
+}
+
+// Groups reads all groups.
+func (d duplicateReader) Groups() ([][]string, error) {
+	switch d.format {
+	case DelimitedFormat:
+		return readDelimitedInput(d.r, d.delimiter)
+	case JSONFormat:
+		return readJSONInput(d.r)
+	case NULFormat:
+		return readNULInput(d.r)
+	}
+	return nil, fmt.Errorf("unknown input format '%s'", d.format)
+}
+
+// readDelimitedInput reads one group of duplicates per line. The files of a group are separated by the delimiter.
+func readDelimitedInput(reader io.Reader, delimiter string) ([][]string, error) {
+	var ret [][]string
+	s := bufio.NewScanner(reader)
+	for s.Scan() {
+		line := strings.Split(s.Text(), delimiter)
+		ret = append(ret, line)
+	}
+	return ret, s.Err()
+}
+
+// readJSONInput reads the groups of duplicates as JSON array of arrays.
+func readJSONInput(reader io.Reader) ([][]string, error) {
+	var ret [][]string
+	err := json.NewDecoder(reader).Decode(&ret)
+	return ret, err
+}
+
+// readNULInput reads files separated by NUL characters. A group of duplicates is terminated by an empty entry,
+// e.g. two consecutive NUL characters.
+func readNULInput(reader io.Reader) ([][]string, error) {
+	var ret [][]string
+	var group []string
+	s := bufio.NewScanner(reader)
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for s.Scan() {
+		if s.Text() == "" {
+			if len(group) > 0 {
+				ret = append(ret, group)
+			}
+			group = nil
+			continue
+		}
+		group = append(group, s.Text())
+	}
+	if len(group) > 0 {
+		ret = append(ret, group)
+	}
+	return ret, s.Err()
+}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDuplicateReader(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		format    string
+		delimiter string
+		want      [][]string
+		wantErr   bool
+	}{
+		{
+			name:      "delimited",
+			input:     "a.jpg b.jpg\nc.jpg d.jpg e.jpg\n",
+			format:    DelimitedFormat,
+			delimiter: " ",
+			want:      [][]string{{"a.jpg", "b.jpg"}, {"c.jpg", "d.jpg", "e.jpg"}},
+		},
+		{
+			name:   "json",
+			input:  `[["a b.jpg","c.jpg"]]`,
+			format: JSONFormat,
+			want:   [][]string{{"a b.jpg", "c.jpg"}},
+		},
+		{
+			name:   "nul",
+			input:  "a\nb.jpg\x00c.jpg\x00\x00d.jpg\x00e.jpg",
+			format: NULFormat,
+			want:   [][]string{{"a\nb.jpg", "c.jpg"}, {"d.jpg", "e.jpg"}},
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDuplicateReader(strings.NewReader(tt.input), tt.format, tt.delimiter).Groups()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
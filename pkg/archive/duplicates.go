@@ -0,0 +1,94 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FindDuplicates returns all groups of calendar files below archiveRoot with identical content. Only files of equal
+// size are hashed. Hard links to the same file aren't duplicates. The groups are in the format DeduplicateAll expects.
+func FindDuplicates(archiveRoot string, hasher Hasher) ([][]string, error) {
+	bySize := make(map[int64][]string)
+	err := filepath.WalkDir(archiveRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		inArchive, err := pathInArchive(archiveRoot, p)
+		if err != nil {
+			return err
+		}
+		if !isCalendarStoredFile(inArchive) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk archive: %w", err)
+	}
+	var ret [][]string
+	for _, candidates := range bySize {
+		if len(candidates) < 2 {
+			continue
+		}
+		candidates, err := withoutHardLinks(candidates)
+		if err != nil {
+			return nil, err
+		}
+		byHash := make(map[string][]string)
+		for _, c := range candidates {
+			sum, err := hasher(c, sha256.New224())
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", c, err)
+			}
+			key := hex.EncodeToString(sum)
+			byHash[key] = append(byHash[key], c)
+		}
+		for _, group := range byHash {
+			if len(group) > 1 {
+				sort.Strings(group)
+				ret = append(ret, group)
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i][0] < ret[j][0]
+	})
+	return ret, nil
+}
+
+// withoutHardLinks removes all files which are hard links to a file earlier in the list.
+func withoutHardLinks(files []string) ([]string, error) {
+	ret := make([]string, 0, len(files))
+	infos := make([]os.FileInfo, 0, len(files))
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat %s: %w", f, err)
+		}
+		linked := false
+		for _, i := range infos {
+			if os.SameFile(i, info) {
+				linked = true
+				break
+			}
+		}
+		if !linked {
+			ret = append(ret, f)
+			infos = append(infos, info)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,28 @@
+package archive
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	root := t.TempDir()
+	writeArchiveFile(t, root, "2019/04/20190417_133044_aaaaaaaa.jpg", "same")
+	writeArchiveFile(t, root, "2020/01/20200101_000000_aaaaaaaa.jpg", "same")
+	writeArchiveFile(t, root, "2019/04/20190417_133045_bbbbbbbb.jpg", "other")
+	writeArchiveFile(t, root, "2019/04/20190417_133046_cccccccc.jpg", "diff!")
+	linkArchiveFile(t, root, "2019/04/20190417_133045_bbbbbbbb.jpg", "2019/05/20190517_133045_bbbbbbbb.jpg")
+	linkArchiveFile(t, root, "2019/04/20190417_133044_aaaaaaaa.jpg", "origin/20190417_133044_aaaaaaaa.jpg")
+	writeArchiveFile(t, root, "quarantine/2019/04/20190417_133044_aaaaaaaa.jpg", "same")
+
+	dups, err := FindDuplicates(root, files.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{
+		filepath.Join(root, "2019/04/20190417_133044_aaaaaaaa.jpg"),
+		filepath.Join(root, "2020/01/20200101_000000_aaaaaaaa.jpg"),
+	}}, dups)
+}
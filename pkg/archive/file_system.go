@@ -17,10 +17,13 @@ func NewOSFileSystem() FileSystem {
 	return FileSystem{
 		fd:            os.Remove,
 		linker:        os.Link,
+		renamer:       os.Rename,
 		mkdir:         os.MkdirAll,
+		dirPerm:       os.ModePerm,
 		stater:        os.Stat,
 		isMedia:       extraction.IsVideoOrImage,
 		dateExtractor: extraction.CaptureDate,
+		clock:         realClock{},
 	}
 }
 
@@ -34,6 +37,10 @@ func NewLoggingFileSystem() FileSystem {
 			log.Printf("[DRY-RUN] link %s to %s", old, new)
 			return nil
 		},
+		renamer: func(oldName, newName string) error {
+			log.Printf("[DRY-RUN] rename %s to %s", oldName, newName)
+			return nil
+		},
 		mkdir: func(dirPath string, perm os.FileMode) error {
 			log.Printf("[DRY-RUN] create directory %s with mode %s", dirPath, perm)
 			return nil
@@ -42,16 +49,21 @@ func NewLoggingFileSystem() FileSystem {
 			log.Printf("[DRY-RUN] stat %s", name)
 			return FakeFileInfo{name}, nil
 		},
+		dirPerm: os.ModePerm,
+		clock:   realClock{},
 	}
 }
 
 type FileSystem struct {
 	fd            FileDeleter
 	linker        Linker
+	renamer       Renamer
 	stater        Stater
 	mkdir         DirectoryCreator
+	dirPerm       os.FileMode
 	isMedia       IsMedia
 	dateExtractor DateExtractor
+	clock         Clock
 }
 
 // EnsureAbsent removes the given directory and returns an error if file is not deleted
@@ -63,30 +75,89 @@ func (fs FileSystem) EnsureAbsent(name string) error {
 	return nil
 }
 
+// WithDirPerm returns a copy of the FileSystem creating directories with the given permissions. The default is
+// os.ModePerm, both are subject to the umask.
+func (fs FileSystem) WithDirPerm(perm os.FileMode) FileSystem {
+	fs.dirPerm = perm
+	return fs
+}
+
+// WithClock returns a copy of the FileSystem using the given clock. It must be set before WithRetry.
+func (fs FileSystem) WithClock(c Clock) FileSystem {
+	fs.clock = c
+	return fs
+}
+
+// Rename moves oldName to newName.
+func (fs FileSystem) Rename(oldName, newName string) error {
+	return fs.renamer(oldName, newName)
+}
+
 // EnsureDirectory creates the directory recursive
 func (fs FileSystem) EnsureDirectory(name string) error {
-	return fs.mkdir(name, os.ModePerm)
+	return fs.mkdir(name, fs.dirPerm)
 }
 
-// createLinks create a symlink from every path in paths to the given target
-func (fs FileSystem) CreateLinks(paths []string, target string) error {
+// CreateLinks hard links every path in paths to the given target, replacing existing files. The links are created
+// next to their paths first and only renamed into place once all of them exist, so the existing files are kept if a
+// link can't be created. Paths already linked to the target are left alone.
+func (fs FileSystem) CreateLinks(paths []string, target string) (retErr error) {
+	created := make([]string, 0, len(paths))
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		for _, c := range created {
+			if err := fs.EnsureAbsent(c); err != nil {
+				retErr = fmt.Errorf("%w; failed to roll back link %s: %v", retErr, c, err)
+			}
+		}
+	}()
+	var linked []string
 	for _, p := range paths {
-		err := fs.EnsureAbsent(p)
-		if err != nil {
-			return errors.Wrap(err, "can't ensure file is not currently absent")
+		if fs.sameFile(p, target) {
+			continue
 		}
-		err = fs.EnsureDirectory(filepath.Dir(p))
+		err := fs.EnsureDirectory(filepath.Dir(p))
 		if err != nil {
 			return errors.Wrap(err, "can not create directory for link")
 		}
-		err = fs.linker(target, p)
+		tmp := p + linkSuffix
+		err = fs.EnsureAbsent(tmp)
+		if err != nil {
+			return errors.Wrap(err, "can't remove stale link")
+		}
+		err = fs.linker(target, tmp)
 		if err != nil {
 			return errors.Wrap(err, "can not hard link to all archive")
 		}
+		created = append(created, tmp)
+		linked = append(linked, p)
+	}
+	for i, p := range linked {
+		err := fs.renamer(created[i], p)
+		if err != nil {
+			// the links renamed before replaced their files already
+			created = created[i:]
+			return errors.Wrap(err, "can not move link into place")
+		}
 	}
 	return nil
 }
 
+// sameFile returns true if both names are the same file, e.g. hard links to each other.
+func (fs FileSystem) sameFile(name, other string) bool {
+	info, err := fs.stater(name)
+	if err != nil {
+		return false
+	}
+	otherInfo, err := fs.stater(other)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(info, otherInfo)
+}
+
 func (fs FileSystem) EqualSize(oldFile, newFile string) (bool, error) {
 	oldStats, err := fs.stater(oldFile)
 	if err != nil {
@@ -0,0 +1,140 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateLinksRollback(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	assert.NoError(t, os.WriteFile(target, []byte("content"), 0644))
+	paths := []string{filepath.Join(dir, "a", "first"), filepath.Join(dir, "b", "second"), filepath.Join(dir, "c", "third")}
+
+	fs := NewOSFileSystem()
+	fs.linker = func(oldName, newName string) error {
+		if newName == paths[1]+linkSuffix {
+			return errors.New("link failed")
+		}
+		return os.Link(oldName, newName)
+	}
+	err := fs.CreateLinks(paths, target)
+	assert.ErrorContains(t, err, "link failed")
+	for _, p := range paths {
+		assert.NoFileExists(t, p)
+		assert.NoFileExists(t, p+linkSuffix)
+	}
+	assert.FileExists(t, target)
+}
+
+func TestCreateLinksReplacesExisting(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	assert.NoError(t, os.WriteFile(target, []byte("content"), 0644))
+	paths := []string{filepath.Join(dir, "first"), filepath.Join(dir, "second"), filepath.Join(dir, "third")}
+	for _, p := range paths {
+		assert.NoError(t, os.WriteFile(p, []byte("existing "+filepath.Base(p)), 0644))
+	}
+
+	fs := NewOSFileSystem()
+	fs.linker = func(oldName, newName string) error {
+		if newName == paths[2]+linkSuffix {
+			return errors.New("link failed")
+		}
+		return os.Link(oldName, newName)
+	}
+	assert.ErrorContains(t, fs.CreateLinks(paths, target), "link failed")
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		assert.NoError(t, err)
+		assert.Equal(t, "existing "+filepath.Base(p), string(content), "existing files are kept if a link fails")
+		assert.NoFileExists(t, p+linkSuffix)
+	}
+
+	fs = NewOSFileSystem()
+	assert.NoError(t, fs.CreateLinks(paths, target))
+	assert.NoError(t, fs.CreateLinks(paths, target), "paths already linked are left alone")
+	targetInfo, err := os.Stat(target)
+	assert.NoError(t, err)
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if assert.NoError(t, err) {
+			assert.True(t, os.SameFile(targetInfo, info), "%s is not linked to the target", p)
+		}
+		assert.NoFileExists(t, p+linkSuffix)
+	}
+}
+
+func TestEnsureDirectoryPerm(t *testing.T) {
+	var perms []os.FileMode
+	fs := NewOSFileSystem()
+	fs.mkdir = func(dirPath string, perm os.FileMode) error {
+		perms = append(perms, perm)
+		return nil
+	}
+	assert.NoError(t, fs.EnsureDirectory("foo"))
+	assert.NoError(t, fs.WithDirPerm(0750).EnsureDirectory("foo"))
+	assert.Equal(t, []os.FileMode{os.ModePerm, 0750}, perms)
+}
+
+func TestWithRetry(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	assert.NoError(t, os.WriteFile(target, []byte("content"), 0644))
+
+	tests := []struct {
+		name             string
+		errs             []error
+		expectedAttempts int
+		expectedError    error
+	}{
+		{
+			name:             "transient errors",
+			errs:             []error{&os.LinkError{Op: "link", Err: syscall.EINTR}, &os.LinkError{Op: "link", Err: syscall.ETIMEDOUT}},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "not existing",
+			errs:             []error{&os.LinkError{Op: "link", Err: syscall.ENOENT}},
+			expectedAttempts: 1,
+			expectedError:    syscall.ENOENT,
+		},
+		{
+			name:             "attempts exhausted",
+			errs:             []error{syscall.EINTR, syscall.EINTR, syscall.EINTR, syscall.EINTR},
+			expectedAttempts: 3,
+			expectedError:    syscall.EINTR,
+		},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			fs := NewOSFileSystem()
+			fs.linker = func(oldName, newName string) error {
+				attempts++
+				if attempts <= len(test.errs) {
+					return test.errs[attempts-1]
+				}
+				return os.Link(oldName, newName)
+			}
+			fs = fs.WithRetry(RetryPolicy{Attempts: 3, Backoff: time.Millisecond})
+			link := filepath.Join(dir, fmt.Sprintf("link%d", i))
+			err := fs.CreateLinks([]string{link}, target)
+			assert.Equal(t, test.expectedAttempts, attempts)
+			if test.expectedError != nil {
+				assert.ErrorIs(t, err, test.expectedError)
+				assert.NoFileExists(t, link)
+			} else {
+				assert.NoError(t, err)
+				assert.FileExists(t, link)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package archive
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DateFilter restricts a sort run to files captured in a time range.
+type DateFilter struct {
+	// Since is the inclusive start of the range. The zero value means no start.
+	Since time.Time
+	// Until is the exclusive end of the range. The zero value means no end.
+	Until time.Time
+	// IncludeUndated sorts files without a capture date in their meta data, which are otherwise skipped.
+	IncludeUndated bool
+}
+
+// Active returns true if the filter restricts the capture date.
+func (f DateFilter) Active() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero()
+}
+
+// Includes returns true if the capture date is within the range.
+func (f DateFilter) Includes(date time.Time) bool {
+	if !f.Since.IsZero() && date.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !date.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// WithDateFilter skips all files captured outside the range of the filter before they are hashed or copied.
+func WithDateFilter(f DateFilter) Option {
+	return func(a *Algorithm) {
+		a.filter = f
+	}
+}
+
+// filtered returns true if the file should be skipped according to the date filter.
+func (a *Algorithm) filtered(fname string, date time.Time) (bool, error) {
+	if !a.filter.Active() {
+		return false, nil
+	}
+	if !a.filter.IncludeUndated {
+		dated, err := a.hasCaptureDate(fname)
+		if err != nil {
+			return false, errors.Wrap(err, "could not check for capture date")
+		}
+		if !dated {
+			return true, nil
+		}
+	}
+	return !a.filter.Includes(date), nil
+}
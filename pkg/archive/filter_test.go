@@ -0,0 +1,81 @@
+package archive
+
+import (
+	"context"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortWithDateFilter(t *testing.T) {
+	captureDate := time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC)
+	tests := []struct {
+		name           string
+		filter         DateFilter
+		dated          bool
+		expectedAction Action
+	}{
+		{
+			name:           "no filter",
+			expectedAction: ActionCopied,
+		},
+		{
+			name:           "within range",
+			filter:         DateFilter{Since: time.Date(2015, 12, 24, 0, 0, 0, 0, time.UTC), Until: time.Date(2015, 12, 25, 0, 0, 0, 0, time.UTC)},
+			dated:          true,
+			expectedAction: ActionCopied,
+		},
+		{
+			name:           "before since",
+			filter:         DateFilter{Since: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)},
+			dated:          true,
+			expectedAction: ActionFiltered,
+		},
+		{
+			name:           "until is exclusive",
+			filter:         DateFilter{Until: captureDate},
+			dated:          true,
+			expectedAction: ActionFiltered,
+		},
+		{
+			name:           "undated skipped",
+			filter:         DateFilter{Since: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)},
+			expectedAction: ActionFiltered,
+		},
+		{
+			name:           "undated included",
+			filter:         DateFilter{Since: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), IncludeUndated: true},
+			expectedAction: ActionCopied,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			fname := copyFixture(t, "sample1.JPG", src)
+			a := NewAlgorithm(src, dst, WithDateFilter(test.filter))
+			a.extractor = func(fname string) (time.Time, error) {
+				return captureDate, nil
+			}
+			a.hasCaptureDate = func(fname string) (bool, error) {
+				return test.dated, nil
+			}
+			copied := false
+			a.copier = func(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+				copied = true
+				return nil, assert.AnError
+			}
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			res, _ := a.SortFile(fname)
+			if test.expectedAction == ActionFiltered {
+				assert.Equal(t, ActionFiltered, res.Action)
+				assert.False(t, copied, "filtered files must not be copied")
+			} else {
+				assert.True(t, copied)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+// ErrLowDiskSpace is returned if copying a file would leave less free space in the archive than the minimum of
+// WithMinFreeSpace. Sort runs stop at the first such error.
+var ErrLowDiskSpace = errors.New("free space of the archive below minimum")
+
+// freeSpaceCheckInterval is the time after which the free space of the archive is queried again
+const freeSpaceCheckInterval = 10 * time.Second
+
+// freeSpaceGuard keeps a minimum of free space in the archive during a run.
+type freeSpaceGuard struct {
+	min          uint64
+	interval     time.Duration
+	freeDiskSize func(dir string) (uint64, error)
+
+	mtx     sync.Mutex
+	checked time.Time
+	// estimate is the free space of the last query minus the bytes reserved since
+	estimate uint64
+}
+
+// WithMinFreeSpace stops sorting once copying a file would leave less than the given number of bytes free in the
+// archive. The free space is only queried periodically, in between the sizes of the copied files are subtracted.
+func WithMinFreeSpace(bytes uint64) Option {
+	return func(a *Algorithm) {
+		a.freeSpace = &freeSpaceGuard{min: bytes, interval: freeSpaceCheckInterval, freeDiskSize: files.FreeDiskSize}
+	}
+}
+
+// reserve returns ErrLowDiskSpace if writing size bytes into dir would leave less than the minimum free. now is the
+// current time.
+func (g *freeSpaceGuard) reserve(dir string, size uint64, now time.Time) error {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	fresh := false
+	if g.checked.IsZero() || now.Sub(g.checked) >= g.interval {
+		if err := g.query(dir, now); err != nil {
+			return err
+		}
+		fresh = true
+	}
+	if !g.sufficient(size) && !fresh {
+		// space may have been freed by others since the last query
+		if err := g.query(dir, now); err != nil {
+			return err
+		}
+	}
+	if !g.sufficient(size) {
+		return errors.Wrapf(ErrLowDiskSpace, "%s free, copying %s would leave less than %s", formatBytes(int64(g.estimate)), formatBytes(int64(size)), formatBytes(int64(g.min)))
+	}
+	g.estimate -= size
+	return nil
+}
+
+func (g *freeSpaceGuard) query(dir string, now time.Time) error {
+	free, err := g.freeDiskSize(dir)
+	if err != nil {
+		return errors.Wrap(err, "could not determine free space of the archive")
+	}
+	g.estimate, g.checked = free, now
+	return nil
+}
+
+func (g *freeSpaceGuard) sufficient(size uint64) bool {
+	return g.estimate >= size && g.estimate-size >= g.min
+}
+
+// reserveSpace checks that copying the file into dir keeps the minimum free space of WithMinFreeSpace.
+func (a *Algorithm) reserveSpace(fname string, dir string) error {
+	if a.freeSpace == nil {
+		return nil
+	}
+	fInfo, err := os.Stat(fname)
+	if err != nil {
+		return errors.Wrap(err, "could not determine file size")
+	}
+	return a.freeSpace.reserve(dir, uint64(fInfo.Size()), a.clock.Now())
+}
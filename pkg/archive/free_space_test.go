@@ -0,0 +1,53 @@
+package archive
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeSpaceGuard(t *testing.T) {
+	free, queries := uint64(1000), 0
+	g := &freeSpaceGuard{min: 100, interval: time.Hour, freeDiskSize: func(string) (uint64, error) {
+		queries++
+		return free, nil
+	}}
+	now := time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC)
+	assert.NoError(t, g.reserve("dir", 400, now))
+	assert.NoError(t, g.reserve("dir", 400, now.Add(time.Minute)))
+	assert.Equal(t, 1, queries, "the written bytes are subtracted until the interval passed")
+
+	free = 180
+	assert.ErrorIs(t, g.reserve("dir", 150, now.Add(time.Minute)), ErrLowDiskSpace)
+	assert.Equal(t, 2, queries, "the free space is queried again before failing")
+
+	free = 1000
+	assert.NoError(t, g.reserve("dir", 150, now.Add(time.Minute)))
+	assert.Equal(t, 3, queries)
+
+	assert.NoError(t, g.reserve("dir", 150, now.Add(2*time.Hour)))
+	assert.Equal(t, 4, queries, "the free space is queried again after the interval")
+}
+
+func TestSortAllStopsOnLowDiskSpace(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithMinFreeSpace(1<<20))
+	a.freeSpace.freeDiskSize = func(string) (uint64, error) {
+		return 1 << 20, nil
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	var reported int
+	summary, err := a.SortAll(context.Background(), []string{copyFixture(t, "sample1.JPG", src), copyFixture(t, "sample2.mp4", src)}, func(SortResult, error) {
+		reported++
+	})
+	assert.ErrorIs(t, err, ErrLowDiskSpace)
+	assert.Equal(t, 1, summary.Scanned)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 1, reported)
+	assert.NoFileExists(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba.JPG"))
+}
@@ -0,0 +1,67 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExecHook runs a command for every file sorted into the archive, e.g. to notify a photo server.
+type ExecHook struct {
+	args    []string
+	output  io.Writer
+	timeout time.Duration
+}
+
+// NewExecHook parses the command template, e.g. "curl -d path={target} http://localhost/scan". The template is split
+// at white space and not run by a shell. The placeholders {source}, {target}, {origin}, {date} and {action} are
+// replaced in every argument by the fields of the SortResult, the date is formatted as RFC 3339. The command is killed
+// if it runs longer than the timeout. Failures of the command are written to output together with its output.
+func NewExecHook(template string, output io.Writer, timeout time.Duration) (*ExecHook, error) {
+	args := strings.Fields(template)
+	if len(args) == 0 {
+		return nil, errors.New("empty hook command")
+	}
+	return &ExecHook{args: args, output: output, timeout: timeout}, nil
+}
+
+// WithExecHook runs the hook for every file sorted into the archive. The hook runs with the context the file was sorted
+// with, so it is killed like the copy of the file, e.g. after the shutdown timeout of the Service.
+func WithExecHook(h *ExecHook) Option {
+	return func(a *Algorithm) {
+		a.hook = h
+	}
+}
+
+// Run runs the command if the file was copied or linked into the archive. The command is killed if the context is
+// cancelled or the timeout passed. A failing command doesn't fail the sort.
+func (h *ExecHook) Run(ctx context.Context, res SortResult, err error) {
+	if err != nil || (res.Action != ActionCopied && res.Action != ActionLinked) {
+		return
+	}
+	replacer := strings.NewReplacer(
+		"{source}", res.Source,
+		"{target}", res.Target,
+		"{origin}", res.OriginLink,
+		"{date}", res.CaptureDate.Format(time.RFC3339),
+		"{action}", string(res.Action),
+	)
+	args := make([]string, len(h.args))
+	for i, arg := range h.args {
+		args[i] = replacer.Replace(arg)
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		fmt.Fprintf(h.output, "hook for %s failed: %v: %s\n", res.Target, err, strings.TrimSpace(string(out)))
+	}
+}
@@ -0,0 +1,86 @@
+//go:build unix
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook.txt")
+	var failures bytes.Buffer
+	hook, err := NewExecHook("cp {source} "+out, &failures, time.Minute)
+	if !assert.NoError(t, err) {
+		return
+	}
+	res := SortResult{
+		Source:      filepath.Join(t.TempDir(), "{target} {date}"),
+		Target:      "/archive/2015/12/20151224_135917_7c0ed5ba.JPG",
+		CaptureDate: time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC),
+		Action:      ActionCopied,
+	}
+	assert.NoError(t, os.WriteFile(res.Source, []byte("content"), 0644))
+
+	hook.Run(context.Background(), SortResult{Source: res.Source, Action: ActionSkipped}, nil)
+	hook.Run(context.Background(), res, errors.New("failed"))
+	assert.NoFileExists(t, out, "only sorted files run the hook")
+
+	hook.Run(context.Background(), res, nil)
+	assert.FileExists(t, out, "placeholders in the substituted values are kept")
+	assert.Empty(t, failures.String())
+
+	hook, err = NewExecHook("false {action}", &failures, time.Minute)
+	assert.NoError(t, err)
+	hook.Run(context.Background(), res, nil)
+	assert.Contains(t, failures.String(), "hook for /archive/2015/12/20151224_135917_7c0ed5ba.JPG failed: exit status 1")
+
+	_, err = NewExecHook(" ", &failures, time.Minute)
+	assert.Error(t, err)
+}
+
+func TestExecHookTimeout(t *testing.T) {
+	var failures bytes.Buffer
+	hook, err := NewExecHook("sleep 10", &failures, 50*time.Millisecond)
+	if !assert.NoError(t, err) {
+		return
+	}
+	res := SortResult{Target: "/archive/2015/12/20151224_135917_7c0ed5ba.JPG", Action: ActionCopied}
+	start := time.Now()
+	hook.Run(context.Background(), res, nil)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Contains(t, failures.String(), "signal: killed")
+
+	failures.Reset()
+	hook, err = NewExecHook("sleep 10", &failures, 0)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	hook.Run(ctx, res, nil)
+	assert.Contains(t, failures.String(), "hook for /archive/2015/12/20151224_135917_7c0ed5ba.JPG failed", "the hook is killed with the sort context")
+}
+
+func TestSortWithExecHook(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	out := filepath.Join(t.TempDir(), "hook.txt")
+	var failures bytes.Buffer
+	hook, err := NewExecHook("cp {target} "+out, &failures, time.Minute)
+	if !assert.NoError(t, err) {
+		return
+	}
+	a := NewAlgorithm(src, dst, WithExecHook(hook))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	_, err = a.SortFile(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+	assert.FileExists(t, out)
+	assert.Empty(t, failures.String())
+}
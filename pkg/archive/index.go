@@ -0,0 +1,150 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// hashIndex knows the size and checksum prefix of every calendar file of the archive. It is safe for concurrent use.
+type hashIndex struct {
+	mtx sync.RWMutex
+	// bySize maps the file sizes to the calendar files of that size
+	bySize map[int64][]indexEntry
+}
+
+// indexEntry is a calendar file in the hashIndex
+type indexEntry struct {
+	path   string
+	prefix string
+}
+
+// WithHashIndex keeps the sizes and checksum prefixes of all calendar files in memory. The archive is scanned once by
+// Init. Files are only hashed to check for an archived copy if a calendar file of the same size exists, regardless of
+// its capture date.
+func WithHashIndex() Option {
+	return func(a *Algorithm) {
+		a.index = &hashIndex{bySize: make(map[int64][]indexEntry)}
+	}
+}
+
+// WithDedupOnImport never stores the same content twice: a file whose content is already archived under any capture
+// date is skipped and only its origin link to the archived calendar file is created. It keeps the hash index of
+// WithHashIndex, so the archive is scanned once by Init.
+func WithDedupOnImport() Option {
+	return func(a *Algorithm) {
+		if a.index == nil {
+			WithHashIndex()(a)
+		}
+	}
+}
+
+// scan adds all calendar files of the archive named by n to the index.
+func (idx *hashIndex) scan(archiveRoot string, n naming) error {
+	err := filepath.WalkDir(archiveRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		inArchive, err := pathInArchive(archiveRoot, p)
+		if err != nil {
+			return err
+		}
+		if !isCalendarStoredFile(inArchive) {
+			return nil
+		}
+		prefix, err := n.hashFromName(d.Name())
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		idx.add(p, info.Size(), prefix)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to index archive: %w", err)
+	}
+	return nil
+}
+
+// add records a calendar file in the index.
+func (idx *hashIndex) add(path string, size int64, prefix string) {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	for _, e := range idx.bySize[size] {
+		if e.path == path {
+			return
+		}
+	}
+	idx.bySize[size] = append(idx.bySize[size], indexEntry{path: path, prefix: prefix})
+}
+
+// hasSize returns true if there is a calendar file of the given size.
+func (idx *hashIndex) hasSize(size int64) bool {
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+	return len(idx.bySize[size]) > 0
+}
+
+// lookup returns the calendar files with the given size and checksum prefix.
+func (idx *hashIndex) lookup(size int64, prefix string) []string {
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+	var ret []string
+	for _, e := range idx.bySize[size] {
+		if e.prefix == prefix {
+			ret = append(ret, e.path)
+		}
+	}
+	return ret
+}
+
+// indexedCopy returns the calendar file with the same content as fname together with the checksum of fname. It
+// returns an empty string if there is no such file. fname is only hashed if there is a calendar file of its size.
+func (a *Algorithm) indexedCopy(fname string) (string, []byte, error) {
+	info, err := os.Stat(fname)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "could not determine file size")
+	}
+	if !a.index.hasSize(info.Size()) {
+		return "", nil, nil
+	}
+	sum, err := a.hasher(fname, sha256.New224())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "could not compute checksum")
+	}
+	for _, candidate := range a.index.lookup(info.Size(), a.naming.hashPrefix(sum)) {
+		candidateSum, err := a.hasher(candidate, sha256.New224())
+		if err != nil {
+			// the calendar file was removed since it was indexed
+			continue
+		}
+		if bytes.Equal(sum, candidateSum) {
+			return candidate, sum, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// addToIndex records a new calendar file with the given checksum in the index, if any.
+func (a *Algorithm) addToIndex(calendarFile string, sum []byte) {
+	if a.index == nil {
+		return
+	}
+	info, err := os.Stat(calendarFile)
+	if err != nil {
+		return
+	}
+	a.index.add(calendarFile, info.Size(), a.naming.hashPrefix(sum))
+}
@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+func TestSortWithHashIndex(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	first, err := NewAlgorithm(src, dst).SortFile(copyFixture(t, "sample1.JPG", src))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the capture date differs, e.g. because the file was edited
+	a := NewAlgorithm(src, dst, WithHashIndex(), WithDateExtractor(func(string) (time.Time, error) {
+		return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	}))
+	hashed := make(map[string]int)
+	a.hasher = func(fname string, hFunc hash.Hash) ([]byte, error) {
+		hashed[filepath.Base(fname)]++
+		return files.Hash(fname, hFunc)
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	other := filepath.Join(src, "other")
+	assert.NoError(t, os.MkdirAll(other, os.ModePerm))
+	res, err := a.SortFile(copyFixture(t, "sample1.JPG", other))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+	assert.Equal(t, first.Target, res.Target)
+
+	// files of a size not in the archive aren't hashed before they are copied
+	res, err = a.SortFile(copyFixture(t, "sample2.mp4", other))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assert.Equal(t, 0, hashed["sample2.mp4"])
+
+	// copied files are added to the index
+	again := filepath.Join(src, "again")
+	assert.NoError(t, os.MkdirAll(again, os.ModePerm))
+	res2, err := a.SortFile(copyFixture(t, "sample2.mp4", again))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res2.Action)
+	assert.Equal(t, res.Target, res2.Target)
+}
+
+func TestSortWithDedupOnImport(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	dates := []time.Time{time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC), time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var results []SortResult
+	for i, date := range dates {
+		a := NewAlgorithm(src, dst, WithDedupOnImport(), WithDateExtractor(func(string) (time.Time, error) {
+			return date, nil
+		}))
+		if !assert.NoError(t, a.Init()) {
+			return
+		}
+		dir := filepath.Join(src, fmt.Sprintf("import%d", i))
+		assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+		res, err := a.SortFile(copyFixture(t, "sample1.JPG", dir))
+		assert.NoError(t, err)
+		results = append(results, res)
+	}
+	if !assert.Len(t, results, 2) {
+		return
+	}
+	assert.Equal(t, ActionCopied, results[0].Action)
+	assert.Equal(t, ActionSkipped, results[1].Action, "the content is archived under another capture date")
+	assert.Equal(t, results[0].Target, results[1].Target)
+	assert.Equal(t, filepath.Join(dst, "origin/import1/20190417_133044_7c0ed5ba.JPG"), results[1].OriginLink)
+	assert.ElementsMatch(t, []string{results[0].Target, results[0].OriginLink, results[1].OriginLink}, archiveFiles(t, dst))
+	targetInfo, err := os.Stat(results[0].Target)
+	assert.NoError(t, err)
+	linkInfo, err := os.Stat(results[1].OriginLink)
+	if assert.NoError(t, err) {
+		assert.True(t, os.SameFile(targetInfo, linkInfo), "the origin link points to the archived copy")
+	}
+}
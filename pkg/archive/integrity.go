@@ -0,0 +1,64 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const quarantineDirName = "quarantine"
+
+// IntegrityError is returned if the content of an archive file doesn't match the checksum in its name anymore.
+type IntegrityError struct {
+	File     string
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("content of %s doesn't match its name: expected checksum %s, got %s", e.File, e.Expected, e.Actual)
+}
+
+// CheckIntegrity verifies that the content of the given archive file still matches the checksum in its name. It
+// returns an *IntegrityError on mismatch. Files which aren't named by the archive are ignored.
+func (a *Algorithm) CheckIntegrity(fname string) error {
+	expected, err := a.naming.hashFromName(filepath.Base(fname))
+	if err != nil {
+		return nil
+	}
+	sum, err := a.hasher(fname, sha256.New224())
+	if err != nil {
+		return errors.Wrap(err, "could not compute checksum")
+	}
+	actual := a.naming.hashPrefix(sum)
+	if actual != expected {
+		return &IntegrityError{File: fname, Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// Quarantine moves the given archive file into the quarantine directory of the archive. The path within the archive
+// is preserved.
+func (a *Algorithm) Quarantine(fname string) (string, error) {
+	inArchive, err := pathInArchive(a.archiveDir, fname)
+	if err != nil {
+		return "", errors.Wrap(err, "can only quarantine files within the archive")
+	}
+	if strings.HasPrefix(filepath.ToSlash(inArchive), quarantineDirName+"/") {
+		return fname, nil
+	}
+	target := path.Join(a.archiveDir, quarantineDirName, inArchive)
+	err = a.fileSystem.EnsureDirectory(path.Dir(target))
+	if err != nil {
+		return "", errors.Wrapf(err, "could not create quarantine dir '%s'", path.Dir(target))
+	}
+	err = a.fileSystem.Rename(fname, target)
+	if err != nil {
+		return "", errors.Wrap(err, "could not move file into quarantine")
+	}
+	return target, nil
+}
@@ -0,0 +1,52 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIntegrity(t *testing.T) {
+	root := t.TempDir()
+	sum := sha256.Sum224([]byte("original"))
+	name := filepath.Join("2019/04", defaultNaming.targetName(time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC), sum[:], ".jpg"))
+	writeArchiveFile(t, root, name, "original")
+	writeArchiveFile(t, root, "2019/04/notes.txt", "not named by the archive")
+	fname := filepath.Join(root, name)
+
+	a := NewAlgorithm(t.TempDir(), root)
+	assert.NoError(t, a.CheckIntegrity(fname))
+	assert.NoError(t, a.CheckIntegrity(filepath.Join(root, "2019/04/notes.txt")))
+
+	writeArchiveFile(t, root, name, "overwritten by an editor")
+	err := a.CheckIntegrity(fname)
+	var integrityErr *IntegrityError
+	if assert.ErrorAs(t, err, &integrityErr) {
+		assert.Equal(t, fname, integrityErr.File)
+		assert.Equal(t, filepath.Base(name)[16:24], integrityErr.Expected)
+		assert.NotEqual(t, integrityErr.Expected, integrityErr.Actual)
+	}
+
+	quarantined, err := a.Quarantine(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, quarantineDirName, name), quarantined)
+	_, err = os.Stat(fname)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(quarantined)
+	assert.NoError(t, err)
+}
+
+func TestHashFromName(t *testing.T) {
+	sum, err := defaultNaming.hashFromName("20190417_133044_537842c8.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, "537842c8", sum)
+
+	_, err = defaultNaming.hashFromName("IMG_0001.jpg")
+	assert.Error(t, err)
+	_, err = defaultNaming.hashFromName("20190417_133044_nothexxx.jpg")
+	assert.Error(t, err)
+}
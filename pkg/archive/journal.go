@@ -0,0 +1,114 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// JournalOp is the kind of change recorded in a journal
+type JournalOp string
+
+const (
+	// JournalCreated records a file created in the archive
+	JournalCreated JournalOp = "created"
+	// JournalLinked records a hard link created in the archive
+	JournalLinked JournalOp = "linked"
+)
+
+// JournalEntry is a single change of a sort run.
+type JournalEntry struct {
+	Op   JournalOp `json:"op"`
+	Path string    `json:"path"`
+	// Target is the file Path is linked to
+	Target string `json:"target,omitempty"`
+}
+
+// Journal appends every change of a sort run as newline delimited JSON to a file.
+type Journal struct {
+	mtx sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// OpenJournal opens the given journal file for appending. The file is created if it doesn't exist.
+func OpenJournal(fname string) (*Journal, error) {
+	f, err := os.OpenFile(fname, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	return &Journal{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record appends the entry to the journal.
+func (j *Journal) Record(e JournalEntry) error {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	if err := j.enc.Encode(e); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// WithJournal records every file and link created in the archive in the given journal.
+func WithJournal(j *Journal) Option {
+	return func(a *Algorithm) {
+		a.journal = j
+	}
+}
+
+// ReadJournal returns all entries of the given journal file. A truncated last entry of an interrupted run is ignored.
+func ReadJournal(fname string) ([]JournalEntry, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+	var entries []JournalEntry
+	dec := json.NewDecoder(f)
+	for {
+		var e JournalEntry
+		err := dec.Decode(&e)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal entry %d: %w", len(entries)+1, err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+// Undo reverts all changes recorded in the journal in reverse order. Links which don't point to their recorded target
+// anymore are kept. Undo can be repeated if it was interrupted.
+func Undo(entries []JournalEntry, fs FileSystem) error {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Op == JournalLinked {
+			same, err := sameFile(e.Path, e.Target)
+			if err == nil && !same {
+				continue
+			}
+		}
+		err := fs.EnsureAbsent(e.Path)
+		if err != nil {
+			return fmt.Errorf("failed to undo %s of %s: %w", e.Op, e.Path, err)
+		}
+	}
+	return nil
+}
+
+// record appends the change to the journal, if any.
+func (a *Algorithm) record(op JournalOp, path, target string) error {
+	if a.journal == nil {
+		return nil
+	}
+	return a.journal.Record(JournalEntry{Op: op, Path: path, Target: target})
+}
@@ -0,0 +1,67 @@
+package archive
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndo(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	journalFile := filepath.Join(t.TempDir(), "journal")
+	journal, err := OpenJournal(journalFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	a := NewAlgorithm(src, dst, WithJournal(journal))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	fname := copyFixture(t, "sample1.JPG", src)
+	target, err := a.Sort(fname)
+	assert.NoError(t, err)
+	// sorting again doesn't create anything
+	_, err = a.Sort(fname)
+	assert.NoError(t, err)
+	assert.NoError(t, journal.Close())
+
+	entries, err := ReadJournal(journalFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []JournalEntry{
+		{Op: JournalCreated, Path: target},
+		{Op: JournalLinked, Path: filepath.Join(dst, originDirName, filepath.Base(target)), Target: target},
+	}, entries)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, Undo(entries, NewOSFileSystem()))
+		assert.Empty(t, archiveFiles(t, dst))
+		assert.FileExists(t, fname)
+	}
+}
+
+func TestReadJournalTruncated(t *testing.T) {
+	journalFile := filepath.Join(t.TempDir(), "journal")
+	content := `{"op":"created","path":"/a"}` + "\n" + `{"op":"linked","path":"/b","tar`
+	assert.NoError(t, os.WriteFile(journalFile, []byte(content), 0644))
+	entries, err := ReadJournal(journalFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []JournalEntry{{Op: JournalCreated, Path: "/a"}}, entries)
+}
+
+// archiveFiles returns all regular files below root.
+func archiveFiles(t *testing.T, root string) []string {
+	var ret []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			ret = append(ret, p)
+		}
+		return err
+	})
+	assert.NoError(t, err)
+	return ret
+}
@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WithKeptArchiveNames keeps the names of source files which are already named like archive files, e.g. when an
+// archive is imported into a new one. The capture date is parsed from the name instead of the file, and the checksum
+// prefix and tags of the name are kept even if they don't match the content anymore. A kept name is only replaced by
+// a new one if the calendar directory already contains a different file of that name.
+func WithKeptArchiveNames() Option {
+	return func(a *Algorithm) {
+		a.keepArchiveNames = true
+	}
+}
+
+// parseName returns the capture date encoded in name if name is an archive file name of this naming, i.e. a date
+// followed by a checksum prefix, optional tags and an extension.
+func (n naming) parseName(name string) (time.Time, bool) {
+	date, err := n.dateFromName(name)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if _, err := n.hashFromName(name); err != nil {
+		return time.Time{}, false
+	}
+	rest := strings.TrimSuffix(name, path.Ext(name))
+	end := n.dateLength() + 1 + n.hashLength
+	if len(rest) < end || (len(rest) > end && rest[end] != '_') {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// keptName returns the archive file name of the source file which is already named like an archive file.
+func (n naming) keptName(name string) string {
+	if n.lowerExt {
+		return strings.TrimSuffix(name, path.Ext(name)) + strings.ToLower(path.Ext(name))
+	}
+	return name
+}
+
+// keptDate returns the capture date encoded in the name of fname if its name is kept, see WithKeptArchiveNames.
+func (a *Algorithm) keptDate(fname string) (time.Time, bool) {
+	if !a.keepArchiveNames {
+		return time.Time{}, false
+	}
+	return a.naming.parseName(path.Base(fname))
+}
+
+// keptCopy returns the file of targetDir with the kept name of fname and the checksum of fname, if the file has the
+// same content. It returns an empty string otherwise.
+func (a *Algorithm) keptCopy(fname string, targetDir string) (string, []byte, error) {
+	candidate := path.Join(targetDir, a.naming.keptName(path.Base(fname)))
+	if _, err := os.Lstat(candidate); err != nil {
+		return "", nil, nil
+	}
+	sum, err := a.hasher(fname, sha256.New224())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "could not compute checksum")
+	}
+	same, err := a.sameContent(candidate, sum)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "could not compute checksum of archived file")
+	}
+	if !same {
+		return "", nil, nil
+	}
+	return candidate, sum, nil
+}
+
+// targetFileName returns the archive file name of fname with the given checksum. A kept name is only returned if
+// targetDir doesn't contain a different file of that name.
+func (a *Algorithm) targetFileName(fname string, targetDir string, date time.Time, sum []byte, kept bool) string {
+	if kept {
+		name := a.naming.keptName(path.Base(fname))
+		same, err := a.sameContent(path.Join(targetDir, name), sum)
+		if os.IsNotExist(err) || (err == nil && same) {
+			return name
+		}
+	}
+	return a.naming.targetName(date, sum, path.Ext(fname), a.tags(fname)...)
+}
@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamingParseName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "20190417_133044_537842c8.jpg", expected: true},
+		{name: "20190417_133044_537842c8_Canon_EOS.JPG", expected: true},
+		{name: "20190417_133044_537842c8", expected: true},
+		{name: "20190417_133044_537842c8a.jpg", expected: false},
+		{name: "20190417_133044_5378.jpg", expected: false},
+		{name: "20190417_133044_nothexxx.jpg", expected: false},
+		{name: "IMG_20190417_133044.jpg", expected: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			date, ok := defaultNaming.parseName(test.name)
+			assert.Equal(t, test.expected, ok)
+			if ok {
+				assert.Equal(t, time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC), date)
+			}
+		})
+	}
+}
+
+func TestSortKeptArchiveNames(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	fname := filepath.Join(src, "20190417_133044_537842c8_Canon.JPG")
+	assert.NoError(t, os.Rename(copyFixture(t, "sample1.JPG", src), fname))
+	a := NewAlgorithm(src, dst, WithKeptArchiveNames())
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	res, err := a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assert.Equal(t, filepath.Join(dst, "2019/04/20190417_133044_537842c8_Canon.JPG"), res.Target, "the date of the name is used instead of the EXIF date")
+
+	res, err = a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+	assert.Equal(t, filepath.Join(dst, "2019/04/20190417_133044_537842c8_Canon.JPG"), res.Target)
+
+	other := filepath.Join(src, "other", "20190417_133044_537842c8_Canon.JPG")
+	assert.NoError(t, os.Mkdir(filepath.Dir(other), 0755))
+	assert.NoError(t, os.WriteFile(other, append(fixtureContent(t, "sample1.JPG"), 0), 0644))
+	res, err = a.SortFile(other)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assert.NotEqual(t, filepath.Join(dst, "2019/04/20190417_133044_537842c8_Canon.JPG"), res.Target, "a different file of the kept name isn't replaced")
+	assert.Equal(t, filepath.Join(dst, "2019/04"), filepath.Dir(res.Target))
+}
@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrHardLinksUnsupported is returned by Init if the file system of the archive doesn't support hard links, e.g. FAT32
+// or many network shares, see WithLinkCheck.
+var ErrHardLinksUnsupported = errors.New("the file system of the archive doesn't support hard links")
+
+// LinkCheck decides what Init does if the file system of the archive doesn't support hard links.
+type LinkCheck int
+
+const (
+	// LinkCheckOff doesn't check the file system. Every hard link fails while sorting then.
+	LinkCheckOff LinkCheck = iota
+	// LinkCheckFail fails Init with ErrHardLinksUnsupported
+	LinkCheckFail
+	// LinkCheckFallback only copies files into the calendar directories, as with LinkNone and without
+	// WithSourceHardLinks and WithBacklinks
+	LinkCheckFallback
+)
+
+// ParseLinkCheck returns the LinkCheck with the given name.
+func ParseLinkCheck(name string) (LinkCheck, error) {
+	switch name {
+	case "off":
+		return LinkCheckOff, nil
+	case "fail":
+		return LinkCheckFail, nil
+	case "fallback":
+		return LinkCheckFallback, nil
+	}
+	return LinkCheckOff, fmt.Errorf("unknown target file system check '%s'", name)
+}
+
+// WithLinkCheck creates a throwaway hard link in the archive in Init to detect file systems without hard links before
+// the first file is sorted. The check is skipped if neither origin links, WithSourceHardLinks nor WithBacklinks are
+// used.
+func WithLinkCheck(c LinkCheck) Option {
+	return func(a *Algorithm) {
+		a.linkCheck = c
+	}
+}
+
+// LinksDisabled returns true if Init disabled all hard links because of LinkCheckFallback.
+func (a *Algorithm) LinksDisabled() bool {
+	return a.linksDisabled
+}
+
+// checkHardLinks checks if hard links can be created in the archive and disables them according to the LinkCheck.
+func (a *Algorithm) checkHardLinks() error {
+	if a.linkCheck == LinkCheckOff || (a.linkStrategy == LinkNone && !a.linkSource && !a.backlinks) {
+		return nil
+	}
+	if err := a.fileSystem.EnsureDirectory(a.archiveDir); err != nil {
+		return fmt.Errorf("could not create archive dir '%s': %w", a.archiveDir, err)
+	}
+	supported, err := a.fileSystem.SupportsHardLinks(a.archiveDir)
+	if err != nil || supported {
+		return err
+	}
+	if a.linkCheck == LinkCheckFail {
+		return fmt.Errorf("%w, sort without origin links and source hard links or choose another archive", ErrHardLinksUnsupported)
+	}
+	a.linkStrategy, a.linkSource, a.backlinks, a.linksDisabled = LinkNone, false, false, true
+	return nil
+}
+
+// SupportsHardLinks returns true if a hard link can be created in the given directory. It creates a temporary file and
+// a link to it, both are removed again.
+func (fs FileSystem) SupportsHardLinks(dir string) (bool, error) {
+	f, err := os.CreateTemp(dir, linkCheckPrefix+"*")
+	if err != nil {
+		return false, fmt.Errorf("could not create file to check for hard links: %w", err)
+	}
+	name := f.Name()
+	defer fs.EnsureAbsent(name)
+	if err := f.Close(); err != nil {
+		return false, fmt.Errorf("could not create file to check for hard links: %w", err)
+	}
+	link := name + ".link"
+	if err := fs.linker(name, link); err != nil {
+		return false, nil
+	}
+	return true, fs.EnsureAbsent(link)
+}
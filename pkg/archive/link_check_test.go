@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// noLinksFileSystem returns a FileSystem of a file system without hard links.
+func noLinksFileSystem() FileSystem {
+	fs := NewOSFileSystem()
+	fs.linker = func(string, string) error {
+		return errors.New("operation not permitted")
+	}
+	return fs
+}
+
+func TestParseLinkCheck(t *testing.T) {
+	for name, expected := range map[string]LinkCheck{"off": LinkCheckOff, "fail": LinkCheckFail, "fallback": LinkCheckFallback} {
+		c, err := ParseLinkCheck(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, c)
+	}
+	_, err := ParseLinkCheck("symlink")
+	assert.EqualError(t, err, "unknown target file system check 'symlink'")
+}
+
+func TestInitLinkCheck(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithLinkCheck(LinkCheckFail))
+	assert.NoError(t, a.Init())
+	assert.False(t, a.LinksDisabled())
+	assert.Empty(t, archiveFiles(t, dst), "the files of the check are removed")
+
+	a = NewAlgorithm(src, dst, WithLinkCheck(LinkCheckFail), WithFileSystem(noLinksFileSystem()))
+	assert.ErrorIs(t, a.Init(), ErrHardLinksUnsupported)
+
+	a = NewAlgorithm(src, dst, WithLinkCheck(LinkCheckFallback), WithFileSystem(noLinksFileSystem()))
+	assert.NoError(t, a.Init())
+	assert.True(t, a.LinksDisabled())
+	res, err := a.SortFile(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assert.Empty(t, res.OriginLink)
+	assert.FileExists(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba.JPG"))
+
+	a = NewAlgorithm(src, dst, WithLinkCheck(LinkCheckFail), WithLinkStrategy(LinkNone), WithFileSystem(noLinksFileSystem()))
+	assert.NoError(t, a.Init(), "no links are needed")
+}
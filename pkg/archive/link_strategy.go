@@ -0,0 +1,41 @@
+package archive
+
+import "fmt"
+
+// LinkStrategy decides which mirrors of the calendar files are created.
+type LinkStrategy int
+
+const (
+	// LinkOrigin links every calendar file below origin according to the path of its source file
+	LinkOrigin LinkStrategy = iota
+	// LinkNone only creates the calendar files
+	LinkNone
+)
+
+// ParseLinkStrategy returns the LinkStrategy with the given name.
+func ParseLinkStrategy(name string) (LinkStrategy, error) {
+	switch name {
+	case "origin":
+		return LinkOrigin, nil
+	case "none":
+		return LinkNone, nil
+	}
+	return LinkOrigin, fmt.Errorf("unknown link strategy '%s'", name)
+}
+
+// WithLinkStrategy selects the mirrors of the calendar files. The default is LinkOrigin. With LinkNone no origin
+// directories are created and the files are only stored in the calendar directories.
+func WithLinkStrategy(s LinkStrategy) Option {
+	return func(a *Algorithm) {
+		a.linkStrategy = s
+	}
+}
+
+// WithFlatOrigin links the calendar files below origin only by the name of the directory of their source file instead
+// of its whole path within the source directory, e.g. origin/Camera instead of origin/DCIM/2019/Camera. Deep source
+// trees don't recreate all their directories then, but files of directories with the same name share one directory.
+func WithFlatOrigin() Option {
+	return func(a *Algorithm) {
+		a.flatOrigin = true
+	}
+}
@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortWithLinkStrategyNone(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithLinkStrategy(LinkNone))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	assert.NoDirExists(t, filepath.Join(dst, originDirName))
+	fname := copyFixture(t, "sample1.JPG", src)
+	res, err := a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assert.Empty(t, res.OriginLink)
+	assert.Equal(t, []string{res.Target}, archiveFiles(t, dst))
+
+	res, err = a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+	assert.Empty(t, res.OriginLink)
+	assert.NoDirExists(t, filepath.Join(dst, originDirName))
+}
+
+func TestParseLinkStrategy(t *testing.T) {
+	for name, expected := range map[string]LinkStrategy{"origin": LinkOrigin, "none": LinkNone} {
+		s, err := ParseLinkStrategy(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s)
+	}
+	_, err := ParseLinkStrategy("all")
+	assert.Error(t, err)
+}
+
+func TestOriginArchiveFileName(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	target := "/archive/2019/04/20190417_151708_537842c8.jpg"
+	tests := []struct {
+		name      string
+		sourceDir string
+		fname     string
+		opts      []Option
+		want      string
+		errAssert assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "keep source directories",
+			sourceDir: "/photos",
+			fname:     "/photos/DCIM/2019/Camera/IMG_0001.jpg",
+			want:      "/archive/origin/DCIM/2019/Camera/20190417_151708_537842c8.jpg",
+			errAssert: assert.NoError,
+		},
+		{
+			name:      "flat origin",
+			sourceDir: "/photos",
+			fname:     "/photos/DCIM/2019/Camera/IMG_0001.jpg",
+			opts:      []Option{WithFlatOrigin()},
+			want:      "/archive/origin/Camera/20190417_151708_537842c8.jpg",
+			errAssert: assert.NoError,
+		},
+		{
+			name:      "flat origin of file in source directory",
+			sourceDir: "/photos",
+			fname:     "/photos/IMG_0001.jpg",
+			opts:      []Option{WithFlatOrigin()},
+			want:      "/archive/origin/20190417_151708_537842c8.jpg",
+			errAssert: assert.NoError,
+		},
+		{
+			name:      "relative source directory",
+			sourceDir: "photos/",
+			fname:     filepath.Join(wd, "photos", "Camera", "IMG_0001.jpg"),
+			want:      "/archive/origin/Camera/20190417_151708_537842c8.jpg",
+			errAssert: assert.NoError,
+		},
+		{
+			name:      "relative source file",
+			sourceDir: "./photos",
+			fname:     "photos/Camera/../Camera/IMG_0001.jpg",
+			want:      "/archive/origin/Camera/20190417_151708_537842c8.jpg",
+			errAssert: assert.NoError,
+		},
+		{
+			name:      "file outside source directory",
+			sourceDir: "/photos",
+			fname:     "/other/IMG_0001.jpg",
+			errAssert: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAlgorithm(filepath.FromSlash(tt.sourceDir), "/archive", tt.opts...)
+			got, err := a.originArchiveFileName(filepath.FromSlash(tt.fname), target)
+			tt.errAssert(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
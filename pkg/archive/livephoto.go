@@ -0,0 +1,42 @@
+package archive
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+// livePhotoImageExtensions are the extensions of the still image of a Live Photo in the order of preference
+var livePhotoImageExtensions = []string{".heic", ".HEIC", ".jpg", ".JPG", ".jpeg", ".JPEG"}
+
+// WithLivePhotos sorts the video of a Live Photo by the capture date of its still image, e.g. IMG_1234.MOV by the date
+// of IMG_1234.HEIC. Both files end up in the same calendar directory even if the clocks of their time stamps differ.
+// Videos without an image of the same base name in their directory are sorted by their own date.
+func WithLivePhotos() Option {
+	return func(a *Algorithm) {
+		a.livePhotos = true
+	}
+}
+
+// dateSource returns the file whose capture date and media type is used to sort fname. It is the still image if fname
+// is the video of a Live Photo, otherwise fname itself.
+func (a *Algorithm) dateSource(fname string) string {
+	if !a.livePhotos {
+		return fname
+	}
+	if isImage, err := a.isImage(fname); err != nil || isImage {
+		return fname
+	}
+	base := strings.TrimSuffix(fname, filepath.Ext(fname))
+	for _, ext := range livePhotoImageExtensions {
+		candidate := base + ext
+		if normal, err := files.IsNormalFile(candidate); err != nil || !normal {
+			continue
+		}
+		if isImage, err := a.isImage(candidate); err == nil && isImage {
+			return candidate
+		}
+	}
+	return fname
+}
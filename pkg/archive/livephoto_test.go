@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLivePhotos(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []Option
+		imageName      string
+		expectedTarget string
+	}{
+		{
+			name:           "sorted by image date",
+			opts:           []Option{WithLivePhotos()},
+			imageName:      "IMG_0001.HEIC",
+			expectedTarget: "photos/2019/04/20190430_235959_6bd02fe8.MOV",
+		},
+		{
+			name:           "disabled",
+			imageName:      "IMG_0001.HEIC",
+			expectedTarget: "videos/2019/05/20190501_000100_6bd02fe8.MOV",
+		},
+		{
+			name:           "other base name",
+			opts:           []Option{WithLivePhotos()},
+			imageName:      "IMG_0002.HEIC",
+			expectedTarget: "videos/2019/05/20190501_000100_6bd02fe8.MOV",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			// the clock of the video is a little ahead
+			opts := append([]Option{WithMediaTypeDirs("photos", "videos"), WithDateExtractor(func(fname string) (time.Time, error) {
+				if strings.HasSuffix(fname, ".HEIC") {
+					return time.Date(2019, 4, 30, 23, 59, 59, 0, time.UTC), nil
+				}
+				return time.Date(2019, 5, 1, 0, 1, 0, 0, time.UTC), nil
+			})}, test.opts...)
+			a := NewAlgorithm(src, dst, opts...)
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			assert.NoError(t, os.Rename(copyFixture(t, "sample1.JPG", src), filepath.Join(src, test.imageName)))
+			video := filepath.Join(src, "IMG_0001.MOV")
+			assert.NoError(t, os.Rename(copyFixture(t, "sample2.mp4", src), video))
+
+			target, err := a.Sort(video)
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join(dst, test.expectedTarget), target)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package archive
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+const (
+	// lockFileName is the file in the archive root locked by WithLock
+	lockFileName = ".exifsorter.lock"
+	// lockPollInterval is the time between two attempts to take the lock
+	lockPollInterval = 100 * time.Millisecond
+)
+
+// ErrArchiveLocked is returned by Init if another instance holds the lock of WithLock longer than the wait.
+var ErrArchiveLocked = errors.New("archive is locked by another running instance")
+
+// WithLock takes an advisory lock of the archive in Init, so that only one instance sorts into the archive at a time,
+// e.g. two machines sharing a network mount. If another instance holds the lock, Init waits up to the given duration
+// before failing. The lock is released by Close or when the process exits.
+func WithLock(wait time.Duration) Option {
+	return func(a *Algorithm) {
+		a.lock, a.lockWait = true, wait
+	}
+}
+
+// lockArchive takes the lock of the archive.
+func (a *Algorithm) lockArchive() error {
+	fname := filepath.Join(a.archiveDir, lockFileName)
+	deadline := a.clock.Now().Add(a.lockWait)
+	for {
+		l, err := files.TryLock(fname)
+		if err == nil {
+			a.archiveLock = l
+			return nil
+		}
+		if !errors.Is(err, files.ErrLocked) {
+			return errors.Wrapf(err, "could not lock archive")
+		}
+		if a.clock.Now().Add(lockPollInterval).After(deadline) {
+			return errors.Wrap(ErrArchiveLocked, fname)
+		}
+		a.clock.Sleep(lockPollInterval)
+	}
+}
+
+// Close releases the lock of the archive taken by Init, if any.
+func (a *Algorithm) Close() error {
+	if a.archiveLock == nil {
+		return nil
+	}
+	err := a.archiveLock.Unlock()
+	a.archiveLock = nil
+	return errors.Wrap(err, "could not unlock archive")
+}
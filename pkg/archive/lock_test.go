@@ -0,0 +1,38 @@
+package archive
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLock(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "archive")
+	first := NewAlgorithm(src, dst, WithLock(0))
+	if !assert.NoError(t, first.Init()) {
+		return
+	}
+	assert.FileExists(t, filepath.Join(dst, lockFileName))
+
+	second := NewAlgorithm(src, dst, WithLock(0))
+	err := second.Init()
+	assert.ErrorIs(t, err, ErrArchiveLocked)
+	assert.ErrorContains(t, err, filepath.Join(dst, lockFileName), "the message names the lock file")
+
+	go func() {
+		time.Sleep(3 * lockPollInterval)
+		assert.NoError(t, first.Close())
+	}()
+	waiting := NewAlgorithm(src, dst, WithLock(time.Minute))
+	assert.NoError(t, waiting.Init())
+
+	// without the option the lock is ignored
+	assert.NoError(t, NewAlgorithm(src, dst).Init())
+
+	assert.NoError(t, waiting.Close())
+	assert.NoError(t, waiting.Close(), "closing twice is a no-op")
+	assert.NoError(t, second.Init())
+	assert.NoError(t, second.Close())
+}
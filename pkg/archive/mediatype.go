@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WithMediaTypeDirs sorts images into the calendar directories below photos and videos into the calendar directories
+// below videos, e.g. photos/2019/04 and videos/2019/04. Both must be single directory names. The origin directory is
+// split likewise unless WithMergedOrigin is given.
+func WithMediaTypeDirs(photos, videos string) Option {
+	return func(a *Algorithm) {
+		a.photosDir = photos
+		a.videosDir = videos
+	}
+}
+
+// WithMergedOrigin keeps a single origin directory in the archive root if the archive is split by WithMediaTypeDirs.
+func WithMergedOrigin() Option {
+	return func(a *Algorithm) {
+		a.mergedOrigin = true
+	}
+}
+
+// splitByMediaType returns true if images and videos are sorted into separate trees.
+func (a *Algorithm) splitByMediaType() bool {
+	return a.photosDir != "" || a.videosDir != ""
+}
+
+// mediaTypeDir returns the directory of the archive below which the calendar directories for the given file are.
+func (a *Algorithm) mediaTypeDir(fname string) (string, error) {
+	if !a.splitByMediaType() {
+		return a.archiveDir, nil
+	}
+	isImage, err := a.isImage(fname)
+	if err != nil {
+		return "", errors.Wrap(err, "could not determine media type")
+	}
+	if isImage {
+		return path.Join(a.archiveDir, a.photosDir), nil
+	}
+	return path.Join(a.archiveDir, a.videosDir), nil
+}
+
+// calendarDir returns the calendar directory for the given file captured at date. It is below the media type and device
+// directory of the file, if any.
+func (a *Algorithm) calendarDir(fname string, date time.Time) (string, error) {
+	root, err := a.mediaTypeDir(fname)
+	if err != nil {
+		return "", err
+	}
+	year, month := getYearMonth(date)
+	return path.Join(root, a.deviceDir(fname), fmt.Sprintf("%d/%02d", year, month)), nil
+}
+
+// originDirFor returns the origin directory the given calendar file is linked into.
+func (a *Algorithm) originDirFor(calendarFile string) string {
+	if a.mergedOrigin {
+		return a.originArchiveDir()
+	}
+	inArchive, err := pathInArchive(a.archiveDir, calendarFile)
+	if err != nil {
+		return a.originArchiveDir()
+	}
+	for _, dir := range []string{a.photosDir, a.videosDir} {
+		if dir != "" && strings.HasPrefix(filepath.ToSlash(inArchive), dir+"/") {
+			return path.Join(a.archiveDir, dir, originDirName)
+		}
+	}
+	return a.originArchiveDir()
+}
+
+// originDirs returns all origin directories of the archive.
+func (a *Algorithm) originDirs() []string {
+	if a.mergedOrigin {
+		return []string{a.originArchiveDir()}
+	}
+	var dirs []string
+	for _, dir := range []string{a.photosDir, a.videosDir} {
+		if dir != "" {
+			dirs = append(dirs, path.Join(a.archiveDir, dir, originDirName))
+		}
+	}
+	if a.photosDir == "" || a.videosDir == "" {
+		dirs = append(dirs, a.originArchiveDir())
+	}
+	return dirs
+}
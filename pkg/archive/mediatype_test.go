@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMediaTypeDirs(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedFiles []string
+	}{
+		{
+			name: "split origin",
+			opts: []Option{WithMediaTypeDirs("photos", "videos")},
+			expectedFiles: []string{
+				"photos/2015/12/20151224_135917_7c0ed5ba.JPG",
+				"photos/origin/dir/20151224_135917_7c0ed5ba.JPG",
+				"videos/2015/12/20151224_135917_6bd02fe8.mp4",
+				"videos/origin/dir/20151224_135917_6bd02fe8.mp4",
+			},
+		},
+		{
+			name: "merged origin",
+			opts: []Option{WithMediaTypeDirs("photos", "videos"), WithMergedOrigin()},
+			expectedFiles: []string{
+				"origin/dir/20151224_135917_6bd02fe8.mp4",
+				"origin/dir/20151224_135917_7c0ed5ba.JPG",
+				"photos/2015/12/20151224_135917_7c0ed5ba.JPG",
+				"videos/2015/12/20151224_135917_6bd02fe8.mp4",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			dir := filepath.Join(src, "dir")
+			assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+			opts := append([]Option{WithDateExtractor(func(string) (time.Time, error) {
+				return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+			})}, test.opts...)
+			a := NewAlgorithm(src, dst, opts...)
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			for _, f := range []string{"sample1.JPG", "sample2.mp4"} {
+				_, err := a.Sort(copyFixture(t, f, dir))
+				assert.NoError(t, err)
+			}
+			var files []string
+			for _, f := range archiveFiles(t, dst) {
+				rel, _ := filepath.Rel(dst, f)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			assert.Equal(t, test.expectedFiles, files)
+
+			report, err := Verify(dst)
+			assert.NoError(t, err)
+			assert.True(t, report.Ok(), "%+v", report)
+		})
+	}
+}
+
+func TestIsCalendarStoredFile(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"2015/12/a.jpg":               true,
+		"photos/2015/12/a.jpg":        true,
+		"origin/2015/12/a.jpg":        false,
+		"quarantine/2015/12/a.jpg":    false,
+		"photos/origin/a.jpg":         false,
+		"photos/Canon/2015/12/a.jpg":  true,
+		"Canon/2015/12/a.jpg":         true,
+		"photos/origin/2015/12/a.jpg": false,
+		"a/b/c/2015/12/a.jpg":         false,
+		"2015/12/24/a.jpg":            false,
+		"photos/2015/12/24/a.jpg":     false,
+		"/2015/12/a.jpg":              false,
+		"/photos/2015/12/a.jpg":       false,
+		"../2015/12/a.jpg":            false,
+		"photos//2015/12/a.jpg":       false,
+		filepath.Join("photos", "Canon", "2015", "12", "a.jpg"):                     true,
+		filepath.Join(string(filepath.Separator), "archive", "2015", "12", "a.jpg"): false,
+	} {
+		assert.Equal(t, expected, isCalendarStoredFile(name), name)
+	}
+}
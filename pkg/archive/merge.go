@@ -0,0 +1,240 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// merger imports the files of another archive.
+type merger struct {
+	a         *Algorithm
+	otherRoot string
+	// index maps the checksum prefixes of the calendar files to their paths
+	index map[string][]string
+	// imported maps the names of the calendar files of the other archive to the imported files
+	imported map[string]importedFile
+}
+
+// importedFile is a calendar file of the other archive and its calendar file in this archive.
+type importedFile struct {
+	source string
+	target string
+}
+
+// Merge imports all files of the archive in otherRoot. Calendar files are imported under their name without reading
+// their meta data again. Files whose content is already in one of the calendar directories are skipped. The links
+// below origin of the other archive are re-created below origin of this archive. The result of every file is passed to
+// report, if it isn't nil. The merge stops at the first ErrLowDiskSpace.
+func (a *Algorithm) Merge(ctx context.Context, otherRoot string, report func(SortResult, error)) (SortSummary, error) {
+	m := &merger{a: a, otherRoot: otherRoot, imported: make(map[string]importedFile)}
+	var err error
+	m.index, err = calendarIndex(a.archiveDir, a.naming)
+	if err != nil {
+		return SortSummary{}, err
+	}
+	var calendarFiles, originFiles []string
+	err = filepath.WalkDir(otherRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		inArchive, err := pathInArchive(otherRoot, p)
+		if err != nil {
+			return err
+		}
+		if isCalendarStoredFile(inArchive) {
+			calendarFiles = append(calendarFiles, p)
+		} else if isOriginStoredFile(inArchive) {
+			originFiles = append(originFiles, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return SortSummary{}, fmt.Errorf("failed to walk archive to merge: %w", err)
+	}
+
+	var s SortSummary
+	for i, f := range append(calendarFiles, originFiles...) {
+		if err := ctx.Err(); err != nil {
+			return s, err
+		}
+		var res SortResult
+		a.reportStart(f)
+		if i < len(calendarFiles) {
+			res, err = m.importCalendarFile(ctx, f)
+		} else {
+			res, err = m.importOriginFile(ctx, f)
+		}
+		a.reportDone(ctx, res, err)
+		if ctxErr := ctx.Err(); ctxErr != nil && err != nil {
+			return s, ctxErr
+		}
+		s.Add(res, err)
+		if report != nil {
+			report(res, err)
+		}
+		if errors.Is(err, ErrLowDiskSpace) {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// importCalendarFile copies the calendar file of the other archive under its name, if its content isn't archived yet.
+func (m *merger) importCalendarFile(ctx context.Context, fname string) (SortResult, error) {
+	res := SortResult{Source: fname}
+	name := filepath.Base(fname)
+	date, err := m.a.naming.dateFromName(name)
+	if err != nil {
+		return res, errors.Wrap(err, "calendar file isn't named by an archive")
+	}
+	prefix, err := m.a.naming.hashFromName(name)
+	if err != nil {
+		return res, errors.Wrap(err, "calendar file isn't named by an archive")
+	}
+	res.CaptureDate = date
+	if candidates := m.index[prefix]; len(candidates) > 0 {
+		sum, err := m.a.hasher(fname, sha256.New224())
+		if err != nil {
+			return res, errors.Wrap(err, "could not compute checksum")
+		}
+		res.Hash = sum
+		for _, c := range candidates {
+			candidateSum, err := m.a.hasher(c, sha256.New224())
+			if err != nil {
+				return res, errors.Wrap(err, "could not compute checksum of archived file")
+			}
+			if bytes.Equal(sum, candidateSum) {
+				res.Action, res.Target = ActionSkipped, c
+				m.imported[name] = importedFile{source: fname, target: c}
+				return res, nil
+			}
+		}
+	}
+
+	targetDir, err := m.a.calendarDir(fname, date)
+	if err != nil {
+		return res, err
+	}
+	err = m.a.fileSystem.EnsureDirectory(targetDir)
+	if err != nil {
+		return res, errors.Wrapf(err, "could not create target dir '%s'", targetDir)
+	}
+	if err := m.a.reserveSpace(fname, targetDir); err != nil {
+		return res, err
+	}
+	tmpFile := path.Join(targetDir, tmpFileName)
+	sum, err := m.a.copyToTemp(ctx, fname, tmpFile)
+	if err != nil {
+		return res, errors.Wrap(err, "could not copy file and compute checksum")
+	}
+	res.Action, res.Hash = ActionCopied, sum
+	// a file whose content doesn't match its name anymore is named by its actual content
+	targetFilePath := path.Join(targetDir, m.a.naming.targetName(date, sum, path.Ext(name), m.a.tags(fname)...))
+	if m.a.naming.hashPrefix(sum) == prefix {
+		targetFilePath = path.Join(targetDir, name)
+	}
+	targetFilePath, action, err := m.a.resolveTarget(fname, targetFilePath, sum)
+	if err != nil || action != "" {
+		if absentErr := m.a.fileSystem.EnsureAbsent(tmpFile); err == nil {
+			err = absentErr
+		}
+	}
+	if err != nil {
+		return res, err
+	}
+	if action == ActionKept {
+		res.Action = action
+		return res, nil
+	}
+	if action == ActionSkipped {
+		res.Action, res.Target = action, targetFilePath
+		m.imported[name] = importedFile{source: fname, target: targetFilePath}
+		return res, nil
+	}
+	_, existed := os.Lstat(targetFilePath)
+	err = m.a.fileSystem.Rename(tmpFile, targetFilePath)
+	if err != nil {
+		_ = m.a.fileSystem.EnsureAbsent(tmpFile)
+		return res, errors.Wrap(err, "could not mv temporary file to target name")
+	}
+	res.Target = targetFilePath
+	if existed != nil {
+		if err := m.a.record(JournalCreated, targetFilePath, ""); err != nil {
+			return res, err
+		}
+	}
+	m.index[prefix] = append(m.index[prefix], targetFilePath)
+	m.imported[name] = importedFile{source: fname, target: targetFilePath}
+	return res, nil
+}
+
+// importOriginFile links the calendar file its calendar file was imported to below origin. Files which aren't linked
+// to a calendar file of the other archive are sorted.
+func (m *merger) importOriginFile(ctx context.Context, fname string) (SortResult, error) {
+	// the origin links of the other archive are sources relative to their origin directory
+	inArchive, err := pathInArchive(m.otherRoot, fname)
+	if err != nil {
+		return SortResult{Source: fname}, err
+	}
+	a := *m.a
+	a.sourceDir = filepath.Join(m.otherRoot, originRootOf(inArchive))
+	if imported, found := m.imported[filepath.Base(fname)]; found {
+		if same, err := sameFile(fname, imported.source); err == nil && same {
+			res := SortResult{Source: fname, Action: ActionSkipped, Target: imported.target}
+			res.OriginLink, err = a.linkOrigin(fname, imported.target)
+			return res, err
+		}
+	}
+	return a.archiveFile(ctx, fname)
+}
+
+// originRootOf returns the origin directory of the given file below an origin directory. The filename must be a
+// relative path within the archive.
+func originRootOf(filename string) string {
+	filename = filepath.ToSlash(filename)
+	if strings.HasPrefix(filename, originDirName+"/") {
+		return originDirName
+	}
+	prefix, _, _ := strings.Cut(filename, "/")
+	return path.Join(prefix, originDirName)
+}
+
+// calendarIndex returns the calendar files of the archive by the checksum prefix in their name.
+func calendarIndex(archiveRoot string, n naming) (map[string][]string, error) {
+	index := make(map[string][]string)
+	err := filepath.WalkDir(archiveRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		inArchive, err := pathInArchive(archiveRoot, p)
+		if err != nil {
+			return err
+		}
+		if !isCalendarStoredFile(inArchive) {
+			return nil
+		}
+		if prefix, err := n.hashFromName(d.Name()); err == nil {
+			index[prefix] = append(index[prefix], p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index archive: %w", err)
+	}
+	return index, nil
+}
@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	extractor := func(fname string) (time.Time, error) {
+		return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+	}
+	sortInto := func(dst string, dir string, fixtures ...string) {
+		src := t.TempDir()
+		assert.NoError(t, os.MkdirAll(filepath.Join(src, dir), os.ModePerm))
+		a := NewAlgorithm(src, dst, WithDateExtractor(extractor))
+		assert.NoError(t, a.Init())
+		for _, f := range fixtures {
+			_, err := a.Sort(copyFixture(t, f, filepath.Join(src, dir)))
+			assert.NoError(t, err)
+		}
+	}
+	own, other := t.TempDir(), t.TempDir()
+	sortInto(own, "phone", "sample1.JPG")
+	sortInto(other, "camera", "sample1.JPG", "sample2.mp4")
+	// a file below origin which isn't linked to a calendar file
+	assert.NoError(t, os.MkdirAll(filepath.Join(other, originDirName, "unlinked"), os.ModePerm))
+	copyFixture(t, "sample1.JPG", filepath.Join(other, originDirName, "unlinked"))
+
+	a := NewAlgorithm("", own, WithDateExtractor(extractor))
+	var actions []Action
+	s, err := a.Merge(context.Background(), other, func(res SortResult, err error) {
+		assert.NoError(t, err)
+		actions = append(actions, res.Action)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, SortSummary{
+		Scanned:         5,
+		Sorted:          1,
+		AlreadyArchived: 4,
+		SortedByYear:    map[int]int{2015: 1},
+	}, s)
+	assert.Equal(t, []Action{ActionCopied, ActionSkipped, ActionSkipped, ActionSkipped, ActionSkipped}, actions)
+
+	jpg := filepath.Join(own, "2015", "12", "20151224_135917_7c0ed5ba.JPG")
+	mp4 := filepath.Join(own, "2015", "12", "20151224_135917_6bd02fe8.mp4")
+	for link, target := range map[string]string{
+		filepath.Join(own, originDirName, "phone", filepath.Base(jpg)):    jpg,
+		filepath.Join(own, originDirName, "camera", filepath.Base(jpg)):   jpg,
+		filepath.Join(own, originDirName, "camera", filepath.Base(mp4)):   mp4,
+		filepath.Join(own, originDirName, "unlinked", filepath.Base(jpg)): jpg,
+	} {
+		same, err := sameFile(link, target)
+		assert.NoError(t, err)
+		assert.True(t, same, "%s must be linked to %s", link, target)
+	}
+	assert.Len(t, archiveFiles(t, own), 6)
+
+	report, err := Verify(own)
+	assert.NoError(t, err)
+	assert.True(t, report.Ok())
+}
@@ -0,0 +1,174 @@
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	targetTimeFormat = "20060102_150405"
+	hashPrefixLength = 8
+	// subSecDigits is the number of digits of the fraction of a second of WithSubSecondNames
+	subSecDigits = 2
+)
+
+// naming is the layout of the archive file names: the capture date in timeFormat followed by hashLength hex
+// characters of the checksum.
+type naming struct {
+	timeFormat string
+	hashLength int
+	// lowerExt lowercases the extensions of the source files
+	lowerExt bool
+	// subSec appends the hundredths of a second to the date, see WithSubSecondNames
+	subSec bool
+}
+
+// defaultNaming is the naming of archives created without WithTimeFormat and WithHashPrefixLength. The deduplication
+// assumes it unless WithDedupTimeFormat is given.
+var defaultNaming = naming{timeFormat: targetTimeFormat, hashLength: hashPrefixLength}
+
+// WithTimeFormat formats the capture date in the archive file names with the given layout of the time package
+// instead of 20060102_150405, e.g. 20060102_150405.000 to include milliseconds. The formatted date must have a fixed
+// width.
+func WithTimeFormat(format string) Option {
+	return func(a *Algorithm) {
+		a.naming.timeFormat = format
+	}
+}
+
+// WithHashPrefixLength uses the given number of hex characters of the checksum in the archive file names instead of
+// 8. The length is limited to the 56 characters of the checksum.
+func WithHashPrefixLength(length int) Option {
+	return func(a *Algorithm) {
+		a.naming.hashLength = min(max(length, 1), 2*sha256.Size224)
+	}
+}
+
+// WithLowerCaseExtensions lowercases the extensions of the archive file names, e.g. .jpg for IMG_0001.JPG. Names then
+// don't depend on the source of a file and can't collide on case-insensitive file systems.
+func WithLowerCaseExtensions() Option {
+	return func(a *Algorithm) {
+		a.naming.lowerExt = true
+	}
+}
+
+// WithSubSecondNames appends the hundredths of a second of the capture date as two digits to the date in the archive
+// file names, e.g. 20151224_135917_07_7c0ed5ba.JPG. Burst photos taken within the same second then sort in capture
+// order in a file listing instead of by checksum. Photos less than 10ms apart get the same digits and still sort by
+// checksum. The fraction of EXIF dates is read from the SubSecTimeOriginal field, dates without one get 00.
+func WithSubSecondNames() Option {
+	return func(a *Algorithm) {
+		a.naming.subSec = true
+	}
+}
+
+// ValidTimeFormat returns an error if the given time format doesn't produce dates of a fixed width which can be
+// parsed again.
+func ValidTimeFormat(format string) error {
+	dates := []time.Time{
+		time.Date(2019, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		time.Date(2019, 12, 24, 13, 59, 17, 120000000, time.UTC),
+	}
+	for _, d := range dates {
+		formatted := d.Format(format)
+		if len(formatted) != len(dates[0].Format(format)) {
+			return fmt.Errorf("time format %s doesn't have a fixed width", format)
+		}
+		if strings.ContainsAny(formatted, `/\`) {
+			return fmt.Errorf("time format %s contains a path separator", format)
+		}
+		if _, err := time.Parse(format, formatted); err != nil {
+			return fmt.Errorf("time format %s can't be parsed: %w", format, err)
+		}
+	}
+	return nil
+}
+
+// dateLength returns the number of characters of a formatted capture date.
+func (n naming) dateLength() int {
+	return len(n.formatDate(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)))
+}
+
+// formatDate returns the capture date as written at the start of the archive file names.
+func (n naming) formatDate(date time.Time) string {
+	formatted := date.Format(n.timeFormat)
+	if n.subSec {
+		formatted += fmt.Sprintf("_%0*d", subSecDigits, date.Nanosecond()/int(10*time.Millisecond))
+	}
+	return formatted
+}
+
+// parseDate parses a capture date formatted by formatDate.
+func (n naming) parseDate(formatted string) (time.Time, error) {
+	if !n.subSec {
+		return time.Parse(n.timeFormat, formatted)
+	}
+	split := len(formatted) - subSecDigits - 1
+	hundredths, err := strconv.Atoi(formatted[split+1:])
+	if err != nil || hundredths < 0 || formatted[split] != '_' {
+		return time.Time{}, fmt.Errorf("%s does not end with hundredths of a second", formatted)
+	}
+	date, err := time.Parse(n.timeFormat, formatted[:split])
+	return date.Add(time.Duration(hundredths) * 10 * time.Millisecond), err
+}
+
+// targetName returns the archive file name for a file with the given capture date, checksum and extension. The
+// non-empty tags are appended to the checksum.
+func (n naming) targetName(date time.Time, sum []byte, ext string, tags ...string) string {
+	parts := []string{n.formatDate(date), n.hashPrefix(sum)}
+	for _, t := range tags {
+		if t != "" {
+			parts = append(parts, t)
+		}
+	}
+	if n.lowerExt {
+		ext = strings.ToLower(ext)
+	}
+	return strings.Join(parts, "_") + ext
+}
+
+// hashPrefix returns the part of the checksum used in the archive file names.
+func (n naming) hashPrefix(sum []byte) string {
+	return hex.EncodeToString(sum)[0:n.hashLength]
+}
+
+// hashFromName returns the checksum prefix encoded in an archive file name.
+func (n naming) hashFromName(name string) (string, error) {
+	start := n.dateLength() + 1
+	if len(name) < start+n.hashLength || name[start-1] != '_' {
+		return "", fmt.Errorf("file name %s does not contain a checksum", name)
+	}
+	sum := name[start : start+n.hashLength]
+	if strings.Trim(sum, "0123456789abcdefABCDEF") != "" {
+		return "", fmt.Errorf("file name %s does not contain a checksum", name)
+	}
+	return sum, nil
+}
+
+// dateFromName parses the capture date encoded at the start of an archive file name.
+func (n naming) dateFromName(name string) (time.Time, error) {
+	length := n.dateLength()
+	if len(name) < length {
+		return time.Time{}, fmt.Errorf("file name %s is too short to contain a date", name)
+	}
+	date, err := n.parseDate(name[:length])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("file name %s does not start with a date: %w", name, err)
+	}
+	return date, nil
+}
+
+// calendarPath returns the path of the calendar file for the given archive file name.
+func (n naming) calendarPath(archiveRoot string, name string) (string, error) {
+	date, err := n.dateFromName(name)
+	if err != nil {
+		return "", err
+	}
+	year, month := getYearMonth(date)
+	return path.Join(archiveRoot, fmt.Sprintf("%d/%02d", year, month), name), nil
+}
@@ -0,0 +1,120 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortWithNaming(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithTimeFormat("20060102_150405.000"), WithHashPrefixLength(12), WithDateExtractor(func(string) (time.Time, error) {
+		return time.Date(2015, 12, 24, 13, 59, 17, 42000000, time.UTC), nil
+	}))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	target, err := a.Sort(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dst, "2015/12/20151224_135917.042_7c0ed5badc7c.JPG"), target)
+	assert.NoError(t, a.CheckIntegrity(target))
+
+	other := filepath.Join(src, "other")
+	assert.NoError(t, os.MkdirAll(other, os.ModePerm))
+	res, err := a.SortFile(copyFixture(t, "sample1.JPG", other))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+	assert.Equal(t, target, res.Target)
+}
+
+func TestValidTimeFormat(t *testing.T) {
+	for format, valid := range map[string]bool{
+		"20060102_150405":     true,
+		"20060102_150405.000": true,
+		"2006-01-02T150405":   true,
+		"20060102_150405.999": false,
+		"January_2_2006":      false,
+		"2006/01/02":          false,
+	} {
+		assert.Equal(t, valid, ValidTimeFormat(format) == nil, format)
+	}
+}
+
+func TestSortWithLowerCaseExtensions(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"verbatim":  nil,
+		"lowercase": {WithLowerCaseExtensions()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			a := NewAlgorithm(src, dst, append(opts, WithDateExtractor(func(string) (time.Time, error) {
+				return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+			}))...)
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			first, err := a.SortFile(copyFixture(t, "sample1.JPG", src))
+			assert.NoError(t, err)
+			expected := "20151224_135917_7c0ed5ba.JPG"
+			if opts != nil {
+				expected = "20151224_135917_7c0ed5ba.jpg"
+			}
+			assert.Equal(t, filepath.Join(dst, "2015/12", expected), first.Target)
+
+			// the same content with a lowercase extension is already archived
+			other := filepath.Join(src, "other")
+			assert.NoError(t, os.MkdirAll(other, os.ModePerm))
+			lower := filepath.Join(other, "sample1.jpg")
+			assert.NoError(t, os.Rename(copyFixture(t, "sample1.JPG", other), lower))
+			res, err := a.SortFile(lower)
+			assert.NoError(t, err)
+			assert.Equal(t, ActionSkipped, res.Action)
+			assert.Equal(t, first.Target, res.Target)
+		})
+	}
+}
+
+func TestSortWithSubSecondNames(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	dates := map[string]time.Time{
+		"sample1.JPG": time.Date(2015, 12, 24, 13, 59, 17, 420000000, time.UTC),
+		"sample2.mp4": time.Date(2015, 12, 24, 13, 59, 17, 70000000, time.UTC),
+	}
+	a := NewAlgorithm(src, dst, WithSubSecondNames(), WithDateExtractor(func(fname string) (time.Time, error) {
+		return dates[filepath.Base(fname)], nil
+	}))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	late, err := a.Sort(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dst, "2015/12/20151224_135917_42_7c0ed5ba.JPG"), late)
+	early, err := a.Sort(copyFixture(t, "sample2.mp4", src))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dst, "2015/12/20151224_135917_07_6bd02fe8.mp4"), early)
+	assert.NoError(t, a.CheckIntegrity(late))
+
+	res, err := a.SortFile(filepath.Join(src, "sample1.JPG"))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+	assert.Equal(t, late, res.Target)
+}
+
+func TestSubSecondNaming(t *testing.T) {
+	n := naming{timeFormat: targetTimeFormat, hashLength: hashPrefixLength, subSec: true}
+	date := time.Date(2015, 12, 24, 13, 59, 17, 70000000, time.UTC)
+	name := n.targetName(date, make([]byte, 28), ".JPG")
+	assert.Equal(t, "20151224_135917_07_00000000.JPG", name)
+	parsed, err := n.dateFromName(name)
+	assert.NoError(t, err)
+	assert.Equal(t, date, parsed)
+	prefix, err := n.hashFromName(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "00000000", prefix)
+
+	_, err = n.dateFromName("20151224_135917_7c0ed5ba.JPG")
+	assert.Error(t, err, "names without the fraction of a second aren't parsed")
+}
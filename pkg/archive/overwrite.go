@@ -0,0 +1,131 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// OverwritePolicy decides what happens if the name of a file in the archive is already taken by a file with different
+// content, e.g. on a collision of the checksum prefix.
+type OverwritePolicy int
+
+const (
+	// OverwriteRename appends a counter to the name of the incoming file, e.g. _1, and keeps both files. The incoming
+	// file is moved to the free name with FileSystem.Rename. This is the default.
+	OverwriteRename OverwritePolicy = iota
+	// OverwriteSkip keeps the existing file and drops the incoming one. Only its temporary copy is removed with
+	// FileSystem.EnsureAbsent.
+	OverwriteSkip
+	// OverwriteAlways replaces the existing file with FileSystem.Rename without comparing their content
+	OverwriteAlways
+	// OverwriteKeepLarger keeps the larger file like OverwriteAlways or OverwriteSkip
+	OverwriteKeepLarger
+	// OverwriteKeepNewer keeps the file with the later modification time like OverwriteAlways or OverwriteSkip
+	OverwriteKeepNewer
+)
+
+// ParseOverwritePolicy returns the OverwritePolicy with the given name.
+func ParseOverwritePolicy(name string) (OverwritePolicy, error) {
+	switch name {
+	case "rename":
+		return OverwriteRename, nil
+	case "skip":
+		return OverwriteSkip, nil
+	case "overwrite":
+		return OverwriteAlways, nil
+	case "keep-larger":
+		return OverwriteKeepLarger, nil
+	case "keep-newer":
+		return OverwriteKeepNewer, nil
+	}
+	return OverwriteRename, fmt.Errorf("unknown overwrite policy '%s'", name)
+}
+
+// WithOverwritePolicy decides what happens if a calendar file already exists with different content. An existing file
+// with the same content is kept, unless WithForce is given. A dropped incoming file is reported as ActionKept. With
+// WithSourceHardLinks the incoming file is written with FileSystem.CreateLinks, which renames a link created next to
+// the replaced file into place, instead of FileSystem.Rename. The policy only reads the archive to decide, so a dry
+// run logs the same operations as a real run. The undated quarantine never replaces files regardless of the policy.
+func WithOverwritePolicy(p OverwritePolicy) Option {
+	return func(a *Algorithm) {
+		a.overwrite = p
+	}
+}
+
+// resolveTarget returns the name to write the incoming file with the given checksum to. The action is ActionSkipped if
+// the target already has the same content, ActionKept if a different existing file is kept and empty if the file is
+// written. The size and modification time of the source file are compared by OverwriteKeepLarger and
+// OverwriteKeepNewer.
+func (a *Algorithm) resolveTarget(fname string, target string, sum []byte) (string, Action, error) {
+	existing, err := os.Lstat(target)
+	if os.IsNotExist(err) || a.overwrite == OverwriteAlways {
+		return target, "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("could not stat existing file: %w", err)
+	}
+	same, err := a.sameContent(target, sum)
+	if err != nil {
+		return "", "", fmt.Errorf("could not compare existing file: %w", err)
+	}
+	switch {
+	case same && a.force:
+		return target, "", nil
+	case same:
+		return target, ActionSkipped, nil
+	case a.overwrite == OverwriteRename:
+		return a.freeName(target, sum)
+	case a.overwrite == OverwriteSkip:
+		return target, ActionKept, nil
+	}
+	info, err := os.Stat(fname)
+	if err != nil {
+		return "", "", fmt.Errorf("could not stat source file: %w", err)
+	}
+	if a.overwrite == OverwriteKeepLarger && info.Size() > existing.Size() ||
+		a.overwrite == OverwriteKeepNewer && info.ModTime().After(existing.ModTime()) {
+		return target, "", nil
+	}
+	return target, ActionKept, nil
+}
+
+// keepExisting completes the result of a file which isn't written because the existing target is kept. Only an
+// existing file with the same content is linked into origin.
+func (a *Algorithm) keepExisting(fname string, res SortResult, action Action, target string) (SortResult, error) {
+	res.Action = action
+	if action == ActionKept {
+		return res, nil
+	}
+	res.Target = target
+	var err error
+	res.OriginLink, err = a.linkOrigin(fname, target)
+	if err != nil {
+		return res, err
+	}
+	return res, a.backlink(fname, res)
+}
+
+// freeName returns the first name of the form <name>_<n><ext> which doesn't exist yet. A renamed copy of an earlier
+// run with the given checksum is returned with ActionSkipped instead.
+func (a *Algorithm) freeName(fname string, sum []byte) (string, Action, error) {
+	ext := path.Ext(fname)
+	base := strings.TrimSuffix(fname, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		same, err := a.sameContent(candidate, sum)
+		if os.IsNotExist(err) {
+			return candidate, "", nil
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("could not compare existing file: %w", err)
+		}
+		if same && a.force {
+			return candidate, "", nil
+		}
+		if same {
+			return candidate, ActionSkipped, nil
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortWithOverwritePolicy(t *testing.T) {
+	const target = "2015/12/20151224_135917_7c0ed5ba.JPG"
+	fixture := fixtureContent(t, "sample1.JPG")
+	small, large := []byte("other content"), make([]byte, len(fixture)+1)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	tests := []struct {
+		name         string
+		policy       OverwritePolicy
+		existing     []byte
+		existingTime time.Time
+		wantAction   Action
+		wantTarget   string
+		// wantExisting is the content of the existing file after sorting
+		wantExisting []byte
+	}{
+		{name: "rename", policy: OverwriteRename, existing: small, existingTime: past, wantAction: ActionCopied, wantTarget: "2015/12/20151224_135917_7c0ed5ba_1.JPG", wantExisting: small},
+		{name: "skip", policy: OverwriteSkip, existing: small, existingTime: past, wantAction: ActionKept, wantExisting: small},
+		{name: "overwrite", policy: OverwriteAlways, existing: small, existingTime: future, wantAction: ActionCopied, wantTarget: target, wantExisting: fixture},
+		{name: "keep larger incoming", policy: OverwriteKeepLarger, existing: small, existingTime: past, wantAction: ActionCopied, wantTarget: target, wantExisting: fixture},
+		{name: "keep larger existing", policy: OverwriteKeepLarger, existing: large, existingTime: past, wantAction: ActionKept, wantExisting: large},
+		{name: "keep newer incoming", policy: OverwriteKeepNewer, existing: large, existingTime: past, wantAction: ActionCopied, wantTarget: target, wantExisting: fixture},
+		{name: "keep newer existing", policy: OverwriteKeepNewer, existing: small, existingTime: future, wantAction: ActionKept, wantExisting: small},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			existing := filepath.Join(dst, target)
+			assert.NoError(t, os.MkdirAll(filepath.Dir(existing), 0755))
+			assert.NoError(t, os.WriteFile(existing, tt.existing, 0644))
+			assert.NoError(t, os.Chtimes(existing, tt.existingTime, tt.existingTime))
+			a := NewAlgorithm(src, dst, WithOverwritePolicy(tt.policy))
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			res, err := a.SortFile(copyFixture(t, "sample1.JPG", src))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAction, res.Action)
+			if tt.wantTarget == "" {
+				assert.Empty(t, res.Target)
+				assert.Empty(t, res.OriginLink)
+			} else {
+				assert.Equal(t, filepath.Join(dst, tt.wantTarget), res.Target)
+				content, err := os.ReadFile(res.Target)
+				assert.NoError(t, err)
+				assert.Equal(t, fixture, content)
+			}
+			content, err := os.ReadFile(existing)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantExisting, content)
+			assert.NoFileExists(t, filepath.Join(dst, "2015/12", tmpFileName))
+		})
+	}
+}
+
+func TestSortWithOverwritePolicyRenameTwice(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	existing := filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba.JPG")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(existing), 0755))
+	assert.NoError(t, os.WriteFile(existing, []byte("other content"), 0644))
+	a := NewAlgorithm(src, dst)
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	fname := copyFixture(t, "sample1.JPG", src)
+	res, err := a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	renamed := res.Target
+
+	res, err = a.SortFile(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action, "the renamed copy is found again")
+	assert.Equal(t, renamed, res.Target)
+	assert.NoFileExists(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba_2.JPG"))
+}
+
+func TestParseOverwritePolicy(t *testing.T) {
+	for name, want := range map[string]OverwritePolicy{
+		"rename":      OverwriteRename,
+		"skip":        OverwriteSkip,
+		"overwrite":   OverwriteAlways,
+		"keep-larger": OverwriteKeepLarger,
+		"keep-newer":  OverwriteKeepNewer,
+	} {
+		got, err := ParseOverwritePolicy(name)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, name)
+	}
+	_, err := ParseOverwritePolicy("replace")
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// tmpFileName is the file in the calendar directories a file is copied to before it is renamed to its archive name
+	tmpFileName = "exifsorter.tmp"
+	// linkCheckPrefix is the prefix of the temporary files of WithLinkCheck
+	linkCheckPrefix = ".exifsorter-linkcheck-"
+	// linkSuffix is appended to the paths of FileSystem.CreateLinks for the links before they are renamed into place
+	linkSuffix = ".exifsorter-link"
+)
+
+// ownFiles matches the temporary and lock files written by the Algorithm, so they aren't sorted if the archive or a
+// backlinked source file is inside the watched source directory.
+type ownFiles struct{}
+
+// Match returns true if the base name of name is the name of a temporary or lock file of the Algorithm.
+func (ownFiles) Match(name string) bool {
+	base := filepath.Base(name)
+	return base == tmpFileName || base == lockFileName || strings.HasSuffix(base, backlinkSuffix) ||
+		strings.HasSuffix(base, linkSuffix) || strings.HasPrefix(base, linkCheckPrefix)
+}
+
+// backlinkedFiles are the source files replaced by links to their calendar files, see WithBacklinks.
+type backlinkedFiles struct {
+	mtx     sync.Mutex
+	targets map[string]string
+}
+
+// add records that fname was replaced by a link to target.
+func (b *backlinkedFiles) add(fname, target string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.targets[fname] = target
+}
+
+// linked returns true if fname is still the link to its calendar file created by the Algorithm. Files which were
+// replaced since are forgotten.
+func (b *backlinkedFiles) linked(fname string) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	target, found := b.targets[fname]
+	if !found {
+		return false
+	}
+	sourceInfo, err := os.Stat(fname)
+	if err == nil {
+		targetInfo, err := os.Stat(target)
+		if err == nil && os.SameFile(sourceInfo, targetInfo) {
+			return true
+		}
+	}
+	delete(b.targets, fname)
+	return false
+}
+
+// ownWrite returns true if the Algorithm wrote the source file itself, i.e. it is a backlink of WithBacklinks. Events
+// of the watcher for such files are ignored.
+func (a *Algorithm) ownWrite(fname string) bool {
+	return a.backlinked != nil && a.backlinked.linked(fname)
+}
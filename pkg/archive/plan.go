@@ -0,0 +1,244 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Plan is the archive tree a sort run would create, see Algorithm.Plan.
+type Plan struct {
+	archiveDir string
+	// Files are the results of all planned media files. Their targets and origin links don't exist yet.
+	Files []SortResult
+	// Collisions are the files of the archive which would be written more than once with different content
+	Collisions []Collision
+	// Failed maps the files which couldn't be planned to their error
+	Failed map[string]error
+}
+
+// Collision is a file of the archive which several sources with different content would be written to, or which
+// already exists with different content.
+type Collision struct {
+	Path    string
+	Sources []string
+	// Existing is true if the file exists with different content and would be replaced
+	Existing bool
+}
+
+// Plan runs the sort logic for the given files without writing anything. The files are hashed instead of copied, so
+// the targets are named like in a real run. Files with the same content as a planned or archived file are planned as
+// skipped. Hard links of WithSourceHardLinks are planned as copies.
+func (a *Algorithm) Plan(ctx context.Context, files []string) (Plan, error) {
+	p := a.planner()
+	if p.index != nil {
+		if _, err := os.Stat(p.archiveDir); err == nil {
+			if err := p.index.scan(p.archiveDir, p.naming); err != nil {
+				return Plan{}, err
+			}
+		}
+	}
+	plan := Plan{archiveDir: a.archiveDir, Failed: make(map[string]error)}
+	targets := make(map[string]SortResult)
+	byHash := make(map[string]string)
+	links := make(map[string]string)
+	collisions := make(map[string]*Collision)
+	collide := func(name string, existing bool, sources ...string) {
+		c, found := collisions[name]
+		if !found {
+			c = &Collision{Path: name, Existing: existing}
+			collisions[name] = c
+		}
+		for _, s := range sources {
+			if !slices.Contains(c.Sources, s) {
+				c.Sources = append(c.Sources, s)
+			}
+		}
+	}
+	for _, fname := range files {
+		if err := ctx.Err(); err != nil {
+			return plan, err
+		}
+		res, err := p.archiveFile(ctx, fname)
+		if errors.Is(err, ErrNotMediaFile) {
+			continue
+		}
+		if err != nil {
+			plan.Failed[fname] = err
+			continue
+		}
+		if prev, found := byHash[string(res.Hash)]; found && p.index != nil && res.Action != ActionQuarantined && res.Target != prev {
+			// the index finds the copy of an earlier file regardless of its capture date
+			res.Action, res.Target = ActionSkipped, prev
+			if res.OriginLink != "" {
+				if res.OriginLink, err = p.originArchiveFileName(fname, prev); err != nil {
+					plan.Failed[fname] = err
+					continue
+				}
+			}
+		}
+		if res.Action == ActionCopied || res.Action == ActionLinked || res.Action == ActionQuarantined {
+			if prev, found := targets[res.Target]; found {
+				if bytes.Equal(prev.Hash, res.Hash) {
+					res.Action = ActionSkipped
+				} else {
+					collide(res.Target, false, prev.Source, fname)
+				}
+			} else if same, err := p.sameContent(res.Target, res.Hash); err == nil && !same {
+				collide(res.Target, true, fname)
+			}
+		}
+		if _, found := targets[res.Target]; !found && res.Target != "" {
+			targets[res.Target] = res
+			if res.Action != ActionQuarantined {
+				byHash[string(res.Hash)] = res.Target
+			}
+		}
+		if res.OriginLink != "" {
+			if prev, found := links[res.OriginLink]; found && prev != res.Target {
+				collide(res.OriginLink, false, targets[prev].Source, fname)
+			}
+			links[res.OriginLink] = res.Target
+		}
+		plan.Files = append(plan.Files, res)
+	}
+	for _, c := range collisions {
+		plan.Collisions = append(plan.Collisions, *c)
+	}
+	sort.Slice(plan.Collisions, func(i, j int) bool {
+		return plan.Collisions[i].Path < plan.Collisions[j].Path
+	})
+	return plan, nil
+}
+
+// planner returns a copy of the Algorithm which hashes instead of copying and doesn't change the file system.
+func (a *Algorithm) planner() *Algorithm {
+	p := *a
+	p.fileSystem = a.fileSystem.planned()
+	p.copier = func(_ context.Context, src, _ string, hFunc hash.Hash) ([]byte, error) {
+		return a.hasher(src, hFunc)
+	}
+	p.verifyCopies, p.captureMTime, p.linkSource, p.backlinks = false, false, false, false
+	p.journal, p.state, p.freeSpace, p.reporter, p.hook, p.archiveLock = nil, nil, nil, nil, nil, nil
+	if a.index != nil {
+		p.index = &hashIndex{bySize: make(map[int64][]indexEntry)}
+	}
+	return &p
+}
+
+// planned returns a copy of the FileSystem which doesn't remove, link, rename or create anything.
+func (fs FileSystem) planned() FileSystem {
+	fs.fd = func(string) error { return nil }
+	fs.linker = func(string, string) error { return nil }
+	fs.renamer = func(string, string) error { return nil }
+	fs.mkdir = func(string, os.FileMode) error { return nil }
+	return fs
+}
+
+// sameContent returns true if the existing file has the given checksum. It returns an error if it doesn't exist.
+func (a *Algorithm) sameContent(fname string, sum []byte) (bool, error) {
+	if _, err := os.Lstat(fname); err != nil {
+		return false, err
+	}
+	existing, err := a.hasher(fname, sha256.New224())
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(existing, sum), nil
+}
+
+// planEntry is a file of the planned archive tree.
+type planEntry struct {
+	label   string
+	sources []string
+}
+
+// Write prints the planned archive tree grouped by directory, followed by the collisions and failures. Every file of
+// the archive is listed once with all of its sources.
+func (p Plan) Write(w io.Writer) error {
+	rel := func(name string) string {
+		if inArchive, err := pathInArchive(p.archiveDir, name); err == nil {
+			return filepath.ToSlash(inArchive)
+		}
+		return name
+	}
+	dirs := make(map[string]map[string]*planEntry)
+	add := func(name, label, source string) {
+		dir, base := path.Split(rel(name))
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string]*planEntry)
+		}
+		e, found := dirs[dir][base]
+		if !found {
+			e = &planEntry{label: label}
+			dirs[dir][base] = e
+		}
+		if label == "new" {
+			e.label = label
+		}
+		e.sources = append(e.sources, source)
+	}
+	var created, skipped int
+	for _, res := range p.Files {
+		if res.Target == "" {
+			continue
+		}
+		if res.Action == ActionSkipped {
+			skipped++
+			add(res.Target, "exists", res.Source)
+		} else {
+			created++
+			add(res.Target, "new", res.Source)
+		}
+		if res.OriginLink != "" {
+			add(res.OriginLink, "link -> "+rel(res.Target), res.Source)
+		}
+	}
+
+	var lines []string
+	dirNames := sortedKeys(dirs)
+	for _, dir := range dirNames {
+		lines = append(lines, dir)
+		for _, base := range sortedKeys(dirs[dir]) {
+			e := dirs[dir][base]
+			lines = append(lines, fmt.Sprintf("  %s  %s  <- %s", base, e.label, strings.Join(e.sources, ", ")))
+		}
+	}
+	for _, c := range p.Collisions {
+		if c.Existing {
+			lines = append(lines, fmt.Sprintf("collision: %s exists with different content and would be replaced by %s", rel(c.Path), strings.Join(c.Sources, ", ")))
+		} else {
+			lines = append(lines, fmt.Sprintf("collision: %s would be written by %s", rel(c.Path), strings.Join(c.Sources, ", ")))
+		}
+	}
+	for _, fname := range sortedKeys(p.Failed) {
+		lines = append(lines, fmt.Sprintf("failed: %s: %v", fname, p.Failed[fname]))
+	}
+	lines = append(lines, fmt.Sprintf("%d new files, %d already archived, %d directories, %d collisions, %d failed", created, skipped, len(dirNames), len(p.Collisions), len(p.Failed)))
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	var sources []string
+	for _, dir := range []string{"a", "b"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(src, dir), os.ModePerm))
+		sources = append(sources, copyFixture(t, "sample1.JPG", filepath.Join(src, dir)))
+	}
+	sources = append(sources, copyFixture(t, "sample2.mp4", src), copyFixture(t, "sample3.txt", src))
+	// a different file of the same name is already archived and replaced
+	writeArchiveFile(t, dst, "2019/04/20190417_133044_6bd02fe8.mp4", "other content")
+
+	a := NewAlgorithm(src, dst, WithOverwritePolicy(OverwriteAlways), WithDateExtractor(func(string) (time.Time, error) {
+		return time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC), nil
+	}))
+	plan, err := a.Plan(context.Background(), sources)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{filepath.Join(dst, "2019/04/20190417_133044_6bd02fe8.mp4")}, archiveFiles(t, dst), "nothing is written")
+	if assert.Len(t, plan.Files, 4) {
+		assert.Equal(t, ActionCopied, plan.Files[0].Action)
+		assert.Equal(t, ActionSkipped, plan.Files[1].Action, "the second copy is planned as duplicate")
+		assert.Equal(t, plan.Files[0].Target, plan.Files[1].Target)
+		assert.Equal(t, ActionTooSmall, plan.Files[3].Action, "the short text file is too small to detect its type")
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, plan.Write(&out))
+	expected := strings.ReplaceAll(`2019/04/
+  20190417_133044_6bd02fe8.mp4  new  <- SRC/sample2.mp4
+  20190417_133044_7c0ed5ba.JPG  new  <- SRC/a/sample1.JPG, SRC/b/sample1.JPG
+origin/
+  20190417_133044_6bd02fe8.mp4  link -> 2019/04/20190417_133044_6bd02fe8.mp4  <- SRC/sample2.mp4
+origin/a/
+  20190417_133044_7c0ed5ba.JPG  link -> 2019/04/20190417_133044_7c0ed5ba.JPG  <- SRC/a/sample1.JPG
+origin/b/
+  20190417_133044_7c0ed5ba.JPG  link -> 2019/04/20190417_133044_7c0ed5ba.JPG  <- SRC/b/sample1.JPG
+collision: 2019/04/20190417_133044_6bd02fe8.mp4 exists with different content and would be replaced by SRC/sample2.mp4
+2 new files, 1 already archived, 4 directories, 1 collisions, 0 failed
+`, "SRC", src)
+	assert.Equal(t, expected, out.String())
+}
+
+func TestPlanWithHashIndex(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	first, second := copyFixture(t, "sample1.JPG", src), filepath.Join(src, "copy.JPG")
+	content, _ := os.ReadFile(first)
+	assert.NoError(t, os.WriteFile(second, content, 0644))
+	dates := map[string]time.Time{
+		first:  time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC),
+		second: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	a := NewAlgorithm(src, dst, WithHashIndex(), WithDateExtractor(func(fname string) (time.Time, error) {
+		return dates[fname], nil
+	}))
+	plan, err := a.Plan(context.Background(), []string{first, second})
+	if !assert.NoError(t, err) || !assert.Len(t, plan.Files, 2) {
+		return
+	}
+	assert.Equal(t, ActionSkipped, plan.Files[1].Action, "the index finds copies regardless of the capture date")
+	assert.Equal(t, plan.Files[0].Target, plan.Files[1].Target)
+	assert.Equal(t, filepath.Join(dst, "origin/20190417_133044_7c0ed5ba.JPG"), plan.Files[1].OriginLink)
+	assert.Empty(t, plan.Collisions)
+}
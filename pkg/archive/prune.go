@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PruneEmptyDirs removes all directories below root which contain no files, deepest first. root itself and the
+// origin and quarantine directories of an archive are kept even if they are empty. The directories are removed by fs,
+// so with a logging FileSystem they are only reported. It returns the number of removed directories.
+func PruneEmptyDirs(root string, fs FileSystem) (int, error) {
+	p := pruner{root: root, fs: fs}
+	_, err := p.prune(root)
+	return p.removed, err
+}
+
+// pruner removes the empty directories of a tree.
+type pruner struct {
+	root    string
+	fs      FileSystem
+	removed int
+}
+
+// prune removes the empty directories below dir and dir itself if it is empty afterwards. It returns true if dir was
+// removed.
+func (p *pruner) prune(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read directory: %w", err)
+	}
+	empty := true
+	for _, e := range entries {
+		if !e.IsDir() {
+			empty = false
+			continue
+		}
+		removed, err := p.prune(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return false, err
+		}
+		empty = empty && removed
+	}
+	if !empty || p.protected(dir) {
+		return false, nil
+	}
+	// the FileSystem removes empty directories like files
+	err = p.fs.EnsureAbsent(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove empty directory %s: %w", dir, err)
+	}
+	p.removed++
+	return true, nil
+}
+
+// protected returns true if dir must be kept even if it is empty.
+func (p *pruner) protected(dir string) bool {
+	inArchive, err := pathInArchive(p.root, dir)
+	if err != nil || inArchive == "." {
+		return true
+	}
+	parts := strings.Split(filepath.ToSlash(inArchive), "/")
+	switch {
+	case len(parts) == 1:
+		return parts[0] == originDirName || parts[0] == quarantineDirName
+	case len(parts) == 2:
+		// origin directories of archives split by media type
+		return parts[1] == originDirName
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneEmptyDirs(t *testing.T) {
+	tests := []struct {
+		name         string
+		fs           FileSystem
+		expectedDirs []string
+	}{
+		{
+			name:         "os",
+			fs:           NewOSFileSystem(),
+			expectedDirs: []string{".", "2019", "2019/05", "origin", "photos", "photos/origin", "quarantine"},
+		},
+		{
+			name:         "dry-run",
+			fs:           NewLoggingFileSystem(),
+			expectedDirs: []string{".", "2019", "2019/04", "2019/05", "origin", "origin/a", "origin/a/b", "photos", "photos/origin", "quarantine"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, dir := range []string{"2019/04", "2019/05", "origin/a/b", "photos/origin", "quarantine"} {
+				assert.NoError(t, os.MkdirAll(filepath.Join(root, dir), os.ModePerm))
+			}
+			assert.NoError(t, os.WriteFile(filepath.Join(root, "2019/05/file.jpg"), []byte("content"), 0644))
+
+			removed, err := PruneEmptyDirs(root, test.fs)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, removed)
+
+			var dirs []string
+			assert.NoError(t, filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+				if d.IsDir() {
+					rel, _ := filepath.Rel(root, p)
+					dirs = append(dirs, filepath.ToSlash(rel))
+				}
+				return err
+			}))
+			assert.Equal(t, test.expectedDirs, dirs)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
+)
+
+// ActionRenamed means the file was renamed in place by RenameInPlace
+const ActionRenamed Action = "renamed"
+
+// WithFileSystem replaces the file system of the archive, e.g. by NewLoggingFileSystem for a dry run of
+// RenameInPlace.
+func WithFileSystem(fs FileSystem) Option {
+	return func(a *Algorithm) {
+		a.fileSystem = fs
+	}
+}
+
+// RenameInPlace renames the given media file in its directory to the name it would get in the archive, without
+// creating calendar directories or origin links. A file of the same name with the same content is kept and the file
+// is skipped, a file of the same name with different content is never replaced.
+func (a *Algorithm) RenameInPlace(fname string) (SortResult, error) {
+	res := SortResult{Source: fname}
+	isMedia, err := a.isMedia(fname)
+	if errors.Is(err, extraction.ErrTruncatedHeader) {
+		res.Action = ActionTooSmall
+		return res, nil
+	}
+	if err != nil {
+		return res, errors.Wrap(err, "could not determine media type")
+	}
+	if !isMedia {
+		res.Action = ActionIgnored
+		return res, ErrNotMediaFile
+	}
+	date, err := a.extractor(fname)
+	if err != nil {
+		return res, errors.Wrap(err, "could not determine creation date of media file")
+	}
+	res.CaptureDate = date
+	sum, err := a.hasher(fname, sha256.New224())
+	if err != nil {
+		return res, errors.Wrap(err, "could not compute checksum")
+	}
+	res.Hash = sum
+	target := filepath.Join(filepath.Dir(fname), a.naming.targetName(date, sum, filepath.Ext(fname), a.tags(fname)...))
+	if target == fname {
+		res.Action, res.Target = ActionSkipped, target
+		return res, nil
+	}
+	if _, err := os.Lstat(target); err == nil {
+		existingSum, err := a.hasher(target, sha256.New224())
+		if err != nil {
+			return res, errors.Wrap(err, "could not compute checksum of existing file")
+		}
+		if !bytes.Equal(sum, existingSum) {
+			return res, fmt.Errorf("%s already exists with different content", target)
+		}
+		res.Action, res.Target = ActionSkipped, target
+		return res, nil
+	}
+	err = a.fileSystem.Rename(fname, target)
+	if err != nil {
+		return res, errors.Wrap(err, "could not rename file")
+	}
+	res.Action, res.Target = ActionRenamed, target
+	return res, nil
+}
@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameInPlace(t *testing.T) {
+	dir := t.TempDir()
+	a := NewAlgorithm("", "", WithDateExtractor(func(string) (time.Time, error) {
+		return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+	}))
+	expected := filepath.Join(dir, "20151224_135917_7c0ed5ba.JPG")
+
+	res, err := a.RenameInPlace(copyFixture(t, "sample1.JPG", dir))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionRenamed, res.Action)
+	assert.Equal(t, expected, res.Target)
+	assert.FileExists(t, expected)
+	assert.NoFileExists(t, filepath.Join(dir, "sample1.JPG"))
+
+	// renaming is idempotent
+	res, err = a.RenameInPlace(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+
+	// a copy is kept next to the existing file
+	copied := copyFixture(t, "sample1.JPG", dir)
+	res, err = a.RenameInPlace(copied)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+	assert.Equal(t, expected, res.Target)
+	assert.FileExists(t, copied)
+
+	// files of the same name but other content are never replaced
+	assert.NoError(t, os.Remove(copied))
+	assert.NoError(t, os.WriteFile(expected, []byte("other content"), 0644))
+	_, err = a.RenameInPlace(copyFixture(t, "sample1.JPG", dir))
+	assert.ErrorContains(t, err, "already exists with different content")
+
+	// a dry run only logs the rename
+	video := copyFixture(t, "sample2.mp4", dir)
+	res, err = NewAlgorithm("", "", WithFileSystem(NewLoggingFileSystem())).RenameInPlace(video)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionRenamed, res.Action)
+	assert.FileExists(t, video)
+}
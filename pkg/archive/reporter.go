@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"context"
+	"errors"
+)
+
+// Reporter is notified about the progress of sorting files, e.g. to show it to the user.
+type Reporter interface {
+	// OnFileStart is called before a file is sorted.
+	OnFileStart(path string)
+	// OnFileDone is called after a file was sorted. target is empty if the file wasn't archived, e.g. because it is
+	// not a media file.
+	OnFileDone(path, target string, action Action)
+	// OnError is called if a file could not be sorted.
+	OnError(path string, err error)
+}
+
+// WithReporter reports the progress of all sorted files to r.
+func WithReporter(r Reporter) Option {
+	return func(a *Algorithm) {
+		a.reporter = r
+	}
+}
+
+// sortFile archives the given file and reports the progress. Copying the file is aborted if the context is cancelled.
+func (a *Algorithm) sortFile(ctx context.Context, fname string) (SortResult, error) {
+	a.reportStart(fname)
+	res, err := a.archiveUnsorted(ctx, fname)
+	a.reportDone(ctx, res, err)
+	return res, err
+}
+
+// reportStart reports that the given file is sorted next.
+func (a *Algorithm) reportStart(fname string) {
+	if a.reporter != nil {
+		a.reporter.OnFileStart(fname)
+	}
+}
+
+// reportDone reports the result of sorting a file and runs the hook of WithExecHook with the given context. Files
+// aborted by cancelling the context aren't reported.
+func (a *Algorithm) reportDone(ctx context.Context, res SortResult, err error) {
+	if a.hook != nil {
+		a.hook.Run(ctx, res, err)
+	}
+	switch {
+	case a.reporter == nil || (err != nil && ctx.Err() != nil):
+	case err == nil || errors.Is(err, ErrNotMediaFile):
+		a.reporter.OnFileDone(res.Source, res.Target, res.Action)
+	default:
+		a.reporter.OnError(res.Source, err)
+	}
+}
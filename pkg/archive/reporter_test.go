@@ -0,0 +1,47 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingReporter records all progress reports as strings.
+type recordingReporter []string
+
+func (r *recordingReporter) OnFileStart(path string) {
+	*r = append(*r, "start "+filepath.Base(path))
+}
+
+func (r *recordingReporter) OnFileDone(path, target string, action Action) {
+	*r = append(*r, fmt.Sprintf("done %s %s %t", filepath.Base(path), action, target != ""))
+}
+
+func (r *recordingReporter) OnError(path string, err error) {
+	*r = append(*r, "error "+filepath.Base(path))
+}
+
+func TestWithReporter(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	r := &recordingReporter{}
+	a := NewAlgorithm(src, dst, WithReporter(r))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	text := filepath.Join(src, "notes.txt")
+	assert.NoError(t, os.WriteFile(text, bytes.Repeat([]byte("no media\n"), 32), 0644))
+	files := []string{copyFixture(t, "sample1.JPG", src), text, filepath.Join(src, "absent.jpg")}
+
+	_, err := a.SortAll(context.Background(), files, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &recordingReporter{
+		"start sample1.JPG", "done sample1.JPG copied true",
+		"start notes.txt", "done notes.txt ignored false",
+		"start absent.jpg", "error absent.jpg",
+	}, r)
+}
@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures how often failed file system operations are retried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts of an operation, including the first one
+	Attempts int
+	// Backoff is the wait after the first failed attempt. It doubles after every further failed attempt.
+	Backoff time.Duration
+}
+
+// WithRetry retries file system operations of the archive which fail with a transient error according to the policy.
+// It also applies to a FileSystem given by WithFileSystem.
+func WithRetry(policy RetryPolicy) Option {
+	return func(a *Algorithm) {
+		a.retry = &policy
+	}
+}
+
+// WithRetry returns a copy of the FileSystem which retries removing, linking, renaming and creating directories if
+// they fail with a transient error like EINTR or ETIMEDOUT, e.g. on network mounts. Other errors like missing files or
+// permissions are returned immediately. The backoff is waited with the clock of the FileSystem, see WithClock.
+func (fs FileSystem) WithRetry(policy RetryPolicy) FileSystem {
+	fd, linker, renamer, mkdir, clock := fs.fd, fs.linker, fs.renamer, fs.mkdir, fs.clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	fs.fd = func(file string) error {
+		return policy.do(clock, func() error { return fd(file) })
+	}
+	fs.linker = func(oldName, newName string) error {
+		return policy.do(clock, func() error { return linker(oldName, newName) })
+	}
+	fs.renamer = func(oldName, newName string) error {
+		return policy.do(clock, func() error { return renamer(oldName, newName) })
+	}
+	fs.mkdir = func(dirPath string, perm os.FileMode) error {
+		return policy.do(clock, func() error { return mkdir(dirPath, perm) })
+	}
+	return fs
+}
+
+// do runs op until it succeeds, fails with an error which isn't transient or the attempts are exhausted.
+func (p RetryPolicy) do(clock Clock, op func() error) error {
+	backoff := p.Backoff
+	err := op()
+	for attempt := 1; attempt < p.Attempts && isTransient(err); attempt++ {
+		clock.Sleep(backoff)
+		backoff *= 2
+		err = op()
+	}
+	return err
+}
+
+// isTransient returns true if the error may disappear if the operation is repeated.
+func isTransient(err error) bool {
+	if err == nil || os.IsNotExist(err) || os.IsPermission(err) {
+		return false
+	}
+	for _, transient := range []error{syscall.EINTR, syscall.EAGAIN, syscall.ETIMEDOUT, syscall.EBUSY} {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
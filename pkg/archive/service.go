@@ -0,0 +1,143 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/hikhvar/exifsorter/pkg/exploration"
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+// Service sorts the source directory of an Algorithm into its archive. It combines the Algorithm with the exploration
+// of the source directory and is meant for embedding exifsorter into other programs.
+type Service struct {
+	algorithm   *Algorithm
+	ignores     []exploration.Matcher
+	walkOpts    []exploration.InitialFilesOption
+	watcherOpts []exploration.WatcherOption
+	// shutdownTimeout is the time a file sorted during the cancellation may take to finish
+	shutdownTimeout time.Duration
+	// walkedDirs are the directories found by the last SortTree, Watch watches them instead of walking again
+	walkedDirs []string
+}
+
+// ServiceOption configures optional behaviour of the Service
+type ServiceOption func(s *Service)
+
+// WithIgnores skips all files in the source directory matched by one of the given matchers.
+func WithIgnores(ignores ...exploration.Matcher) ServiceOption {
+	return func(s *Service) {
+		s.ignores = append(s.ignores, ignores...)
+	}
+}
+
+// WithWalkOptions configures the walk of the source directory, e.g. to follow symlinks.
+func WithWalkOptions(opts ...exploration.InitialFilesOption) ServiceOption {
+	return func(s *Service) {
+		s.walkOpts = append(s.walkOpts, opts...)
+	}
+}
+
+// WithWatcherOptions configures the watcher of the source directory, e.g. to debounce events.
+func WithWatcherOptions(opts ...exploration.WatcherOption) ServiceOption {
+	return func(s *Service) {
+		s.watcherOpts = append(s.watcherOpts, opts...)
+	}
+}
+
+// NewService returns a new Service sorting with the given Algorithm. If the archive is inside the source directory,
+// the archive is ignored to not sort the archived files again. Temporary and lock files of the Algorithm are always
+// ignored.
+func NewService(a *Algorithm, opts ...ServiceOption) *Service {
+	s := &Service{algorithm: a, ignores: []exploration.Matcher{ownFiles{}}}
+	if a.sourceDir != "" && exploration.NewPathMatcher(a.sourceDir).Match(a.archiveDir) {
+		s.ignores = append(s.ignores, exploration.NewPathMatcher(a.archiveDir))
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SortFile archives the given file. Copying the file is aborted if the context is cancelled.
+func (s *Service) SortFile(ctx context.Context, fname string) (SortResult, error) {
+	return s.algorithm.sortFile(ctx, fname)
+}
+
+// SortTree archives all files in the source directory which aren't ignored. The result of every file is passed to
+// report, if it isn't nil. The directories found are kept for a following Watch.
+func (s *Service) SortTree(ctx context.Context, report func(SortResult, error)) (SortSummary, error) {
+	dirs, sources, err := exploration.InitialFiles(s.algorithm.sourceDir, s.ignores, s.walkOpts...)
+	if err != nil {
+		return SortSummary{}, fmt.Errorf("failed to walk source directory: %w", err)
+	}
+	s.walkedDirs = dirs
+	sortCtx, cancel := s.sortContext(ctx)
+	defer cancel()
+	return s.algorithm.sortAll(ctx, sortCtx, sources, report)
+}
+
+// PlanTree plans the archiving of all files in the source directory which aren't ignored, see Algorithm.Plan.
+func (s *Service) PlanTree(ctx context.Context) (Plan, error) {
+	_, sources, err := exploration.InitialFiles(s.algorithm.sourceDir, s.ignores, s.walkOpts...)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to walk source directory: %w", err)
+	}
+	return s.algorithm.Plan(ctx, sources)
+}
+
+// Watch archives every file created or written in the source directory until the context is cancelled or the archive
+// is low on disk space. The result of every file is passed to report, errors of the watcher are passed to watchErr.
+// Both may be nil. Events of source files replaced by WithBacklinks are ignored as long as they are the links. The
+// directories found by a preceding SortTree are watched, the source directory is only walked if there was none.
+func (s *Service) Watch(ctx context.Context, report func(SortResult, error), watchErr func(error)) error {
+	dirs := s.walkedDirs
+	if dirs == nil {
+		var err error
+		dirs, _, err = exploration.InitialFiles(s.algorithm.sourceDir, s.ignores, s.walkOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to walk source directory: %w", err)
+		}
+	}
+	opts := append([]exploration.WatcherOption{exploration.WithOps(fsnotify.Create | fsnotify.Write)}, s.watcherOpts...)
+	watcher, err := exploration.NewRecursiveWatcher(ctx, s.ignores, dirs, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to watch source directory: %w", err)
+	}
+	if report == nil {
+		report = func(SortResult, error) {}
+	}
+	if watchErr == nil {
+		watchErr = func(error) {}
+	}
+	sortCtx, cancel := s.sortContext(ctx)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watcher.Errors:
+			watchErr(err)
+		case e := <-watcher.Events:
+			if ctx.Err() != nil {
+				return nil
+			}
+			normalFile, err := files.IsNormalFile(e.Name)
+			if err != nil {
+				watchErr(fmt.Errorf("could not stat file: %w", err))
+				continue
+			}
+			if normalFile && !s.algorithm.ownWrite(e.Name) {
+				res, err := s.SortFile(sortCtx, e.Name)
+				report(res, err)
+				if errors.Is(err, ErrLowDiskSpace) {
+					return err
+				}
+			}
+		}
+	}
+}
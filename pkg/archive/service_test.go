@@ -0,0 +1,165 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/exploration"
+)
+
+func TestServiceSortTree(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst)
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	ignored := filepath.Join(src, "ignored")
+	assert.NoError(t, os.MkdirAll(ignored, os.ModePerm))
+	copyFixture(t, "sample1.JPG", src)
+	copyFixture(t, "sample2.mp4", ignored)
+	ignores, err := exploration.GobwasMatcherFromPatterns([]string{"**/ignored/**"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s, err := NewService(a, WithIgnores(ignores...)).SortTree(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.Scanned)
+	assert.Equal(t, 1, s.Sorted)
+}
+
+func TestServiceSortTreeIgnoresNestedArchive(t *testing.T) {
+	src := t.TempDir()
+	a := NewAlgorithm(src, filepath.Join(src, "archive"))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	copyFixture(t, "sample1.JPG", src)
+	s, err := NewService(a).SortTree(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.Sorted)
+
+	// the second run doesn't see the archived file and its link
+	s, err = NewService(a).SortTree(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.Scanned)
+	assert.Equal(t, 1, s.AlreadyArchived)
+}
+
+func TestServiceWatch(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst)
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	results := make(chan SortResult, 1)
+	done := make(chan error)
+	go func() {
+		done <- NewService(a, WithWatcherOptions(exploration.WithDebounce(100*time.Millisecond))).Watch(ctx, func(res SortResult, err error) {
+			assert.NoError(t, err)
+			results <- res
+		}, nil)
+	}()
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+	copyFixture(t, "sample1.JPG", src)
+
+	select {
+	case res := <-results:
+		assert.Equal(t, ActionCopied, res.Action)
+	case <-ctx.Done():
+		t.Fatal("created file not sorted")
+	}
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestServiceWatchAfterSortTree(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	sub := filepath.Join(src, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0755))
+	copyFixture(t, "sample1.JPG", sub)
+	a := NewAlgorithm(src, dst)
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	s := NewService(a, WithWatcherOptions(exploration.WithDebounce(100*time.Millisecond)))
+	summary, err := s.SortTree(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.Sorted)
+	assert.ElementsMatch(t, []string{src, sub}, s.walkedDirs, "the directories are kept for Watch")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	results := make(chan SortResult, 1)
+	done := make(chan error)
+	go func() {
+		done <- s.Watch(ctx, func(res SortResult, err error) {
+			assert.NoError(t, err)
+			results <- res
+		}, nil)
+	}()
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+	copyFixture(t, "sample5.dng", sub)
+
+	select {
+	case res := <-results:
+		assert.Equal(t, ActionCopied, res.Action)
+	case <-ctx.Done():
+		t.Fatal("file created in a directory of the initial walk not sorted")
+	}
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestServiceWatchIgnoresOwnWrites(t *testing.T) {
+	src := t.TempDir()
+	a := NewAlgorithm(src, filepath.Join(src, "archive"), WithBacklinks())
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	results := make(chan SortResult, 10)
+	done := make(chan error)
+	go func() {
+		done <- NewService(a, WithWatcherOptions(exploration.WithDebounce(100*time.Millisecond))).Watch(ctx, func(res SortResult, err error) {
+			assert.NoError(t, err)
+			results <- res
+		}, nil)
+	}()
+	// give the watcher time to start
+	time.Sleep(100 * time.Millisecond)
+	copyFixture(t, "sample1.JPG", src)
+
+	select {
+	case res := <-results:
+		assert.Equal(t, ActionCopied, res.Action)
+	case <-ctx.Done():
+		t.Fatal("created file not sorted")
+	}
+	select {
+	case res := <-results:
+		t.Fatalf("own write of %s sorted again", res.Source)
+	case <-time.After(500 * time.Millisecond):
+	}
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestOwnFiles(t *testing.T) {
+	assert.True(t, ownFiles{}.Match("/archive/2019/04/exifsorter.tmp"))
+	assert.True(t, ownFiles{}.Match("/archive/.exifsorter.lock"))
+	assert.True(t, ownFiles{}.Match("/src/IMG_0001.JPG.exifsorter-backlink"))
+	assert.True(t, ownFiles{}.Match("/archive/.exifsorter-linkcheck-123"))
+	assert.True(t, ownFiles{}.Match("/archive/origin/IMG_0001.JPG.exifsorter-link"))
+	assert.False(t, ownFiles{}.Match("/src/IMG_0001.JPG"))
+}
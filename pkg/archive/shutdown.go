@@ -0,0 +1,32 @@
+package archive
+
+import (
+	"context"
+	"time"
+)
+
+// WithShutdownTimeout lets the file which is sorted when the context of SortTree or Watch is cancelled finish for up
+// to the given duration. Its copy is aborted and the temporary file removed only after the timeout. No further files
+// are sorted after the cancellation. Without this option the copy is aborted immediately.
+func WithShutdownTimeout(timeout time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// sortContext returns the context to sort files with. It is cancelled after the shutdown timeout passed on the clock of
+// the Algorithm since ctx was cancelled, or when the returned function is called.
+func (s *Service) sortContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.shutdownTimeout <= 0 {
+		return ctx, func() {}
+	}
+	sortCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		s.algorithm.clock.Sleep(s.shutdownTimeout)
+		abort()
+	})
+	return sortCtx, func() {
+		stop()
+		abort()
+	}
+}
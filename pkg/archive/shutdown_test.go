@@ -0,0 +1,87 @@
+package archive
+
+import (
+	"context"
+	"hash"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+func TestSortTreeShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ServiceOption
+		finished bool
+	}{
+		{name: "without timeout the copy is aborted"},
+		{name: "with timeout the copy is finished", opts: []ServiceOption{WithShutdownTimeout(time.Minute)}, finished: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			copyFixture(t, "sample1.JPG", src)
+			copyFixture(t, "sample2.mp4", src)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			a := NewAlgorithm(src, dst)
+			a.copier = func(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+				// the signal arrives while the first file is copied
+				cancel()
+				return files.CopyContext(ctx, src, dst, hFunc)
+			}
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			summary, err := NewService(a, test.opts...).SortTree(ctx, nil)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.NoFileExists(t, filepath.Join(dst, "2015/12/exifsorter.tmp"))
+			if test.finished {
+				assert.Equal(t, 1, summary.Sorted)
+				assert.FileExists(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba.JPG"))
+			} else {
+				assert.Equal(t, 0, summary.Sorted)
+				assert.NoFileExists(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba.JPG"))
+			}
+		})
+	}
+}
+
+func TestSortTreeShutdownTimeoutClock(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	copyFixture(t, "sample1.JPG", src)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := &signalingClock{slept: make(chan time.Duration, 1)}
+	a := NewAlgorithm(src, dst, WithClock(clock))
+	a.copier = func(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+		cancel()
+		// the copy hangs until it is aborted
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	summary, err := NewService(a, WithShutdownTimeout(time.Hour)).SortTree(ctx, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, summary.Sorted)
+	assert.Equal(t, time.Hour, <-clock.slept, "the shutdown timeout passes on the clock of the algorithm")
+}
+
+// signalingClock passes every sleep to slept and returns immediately.
+type signalingClock struct {
+	slept chan time.Duration
+}
+
+func (c *signalingClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *signalingClock) Sleep(d time.Duration) {
+	c.slept <- d
+}
@@ -0,0 +1,131 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// StateEntry is a source file which was sorted successfully.
+type StateEntry struct {
+	Source  string    `json:"source"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// Target is the archived file
+	Target string `json:"target"`
+}
+
+// State records the sorted source files as newline delimited JSON to resume an interrupted import. It is safe for
+// concurrent use.
+type State struct {
+	mtx    sync.Mutex
+	f      *os.File
+	enc    *json.Encoder
+	sorted map[string]StateEntry
+}
+
+// OpenState reads the sorted source files of previous runs from the given state file and opens it for appending. The
+// file is created if it doesn't exist. With fresh the previous runs are discarded. A truncated last entry of an
+// interrupted run is ignored.
+func OpenState(fname string, fresh bool) (*State, error) {
+	flags := os.O_RDWR | os.O_CREATE
+	if fresh {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(fname, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state: %w", err)
+	}
+	s := &State{f: f, sorted: make(map[string]StateEntry)}
+	dec := json.NewDecoder(f)
+	var end int64
+	for {
+		var e StateEntry
+		err := dec.Decode(&e)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read state entry %d: %w", len(s.sorted)+1, err)
+		}
+		s.sorted[e.Source] = e
+		end = dec.InputOffset()
+	}
+	// a truncated entry is removed
+	if err := f.Truncate(end); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open state: %w", err)
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open state: %w", err)
+	}
+	// the offset is behind the last entry, but before its newline
+	if end > 0 {
+		if _, err := f.WriteString("\n"); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to open state: %w", err)
+		}
+	}
+	s.enc = json.NewEncoder(f)
+	return s, nil
+}
+
+// Close closes the state file.
+func (s *State) Close() error {
+	return s.f.Close()
+}
+
+// lookup returns the entry of the given source file if it wasn't modified since it was sorted.
+func (s *State) lookup(fname string, info os.FileInfo) (StateEntry, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	e, found := s.sorted[fname]
+	if !found || e.Size != info.Size() || !e.ModTime.Equal(info.ModTime()) {
+		return StateEntry{}, false
+	}
+	return e, true
+}
+
+// add appends the entry to the state file.
+func (s *State) add(e StateEntry) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if err := s.enc.Encode(e); err != nil {
+		return fmt.Errorf("failed to write state entry: %w", err)
+	}
+	s.sorted[e.Source] = e
+	return nil
+}
+
+// WithState skips the source files recorded in the given state as sorted without checking the archive, unless they
+// were modified since. Every file archived successfully is added to the state.
+func WithState(s *State) Option {
+	return func(a *Algorithm) {
+		a.state = s
+	}
+}
+
+// archiveUnsorted archives the given file unless the state knows it as sorted.
+func (a *Algorithm) archiveUnsorted(ctx context.Context, fname string) (SortResult, error) {
+	if a.state == nil {
+		return a.archiveFile(ctx, fname)
+	}
+	info, err := os.Stat(fname)
+	if err != nil {
+		return SortResult{Source: fname}, fmt.Errorf("could not stat source file: %w", err)
+	}
+	if e, found := a.state.lookup(fname, info); found {
+		return SortResult{Source: fname, Action: ActionSkipped, Target: e.Target}, nil
+	}
+	res, err := a.archiveFile(ctx, fname)
+	if err != nil || res.Target == "" {
+		return res, err
+	}
+	return res, a.state.add(StateEntry{Source: fname, Size: info.Size(), ModTime: info.ModTime(), Target: res.Target})
+}
@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortWithState(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	fname := copyFixture(t, "sample1.JPG", src)
+
+	sortWithState := func(fresh bool) (SortResult, int) {
+		state, err := OpenState(stateFile, fresh)
+		if !assert.NoError(t, err) {
+			return SortResult{}, 0
+		}
+		defer state.Close()
+		a := NewAlgorithm(src, dst, WithState(state))
+		checked := 0
+		a.isMedia = func(string) (bool, error) {
+			checked++
+			return true, nil
+		}
+		res, err := a.SortFile(fname)
+		assert.NoError(t, err)
+		return res, checked
+	}
+
+	first, checked := sortWithState(false)
+	assert.Equal(t, ActionCopied, first.Action)
+	assert.Equal(t, 1, checked)
+
+	resumed, checked := sortWithState(false)
+	assert.Equal(t, ActionSkipped, resumed.Action)
+	assert.Equal(t, first.Target, resumed.Target)
+	assert.Equal(t, 0, checked, "sorted files aren't checked again")
+
+	fresh, checked := sortWithState(true)
+	assert.Equal(t, ActionSkipped, fresh.Action)
+	assert.Equal(t, 1, checked)
+
+	modTime := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(fname, modTime, modTime))
+	_, checked = sortWithState(false)
+	assert.Equal(t, 1, checked, "modified files are sorted again")
+}
+
+func TestOpenStateTruncated(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{"source":"a.jpg","size":1,"modTime":"2019-04-17T13:30:44Z","target":"t.jpg"}
+{"source":"b.jpg","size":1,"modTime":"2019-04-17T13:3`), 0644))
+	state, err := OpenState(stateFile, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, state.sorted, 1)
+	assert.NoError(t, state.add(StateEntry{Source: "c.jpg", Target: "c.jpg"}))
+	assert.NoError(t, state.Close())
+
+	state, err = OpenState(stateFile, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer state.Close()
+	assert.Len(t, state.sorted, 2, "the truncated entry is removed")
+	content, err := os.ReadFile(stateFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "\n"))
+}
@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SortSummary counts the outcomes of a sort run.
+type SortSummary struct {
+	// Scanned is the number of files given to the run
+	Scanned int
+	// Sorted is the number of media files copied or linked into the archive
+	Sorted int
+	// NotMedia is the number of skipped files which aren't media files
+	NotMedia int
+	// AlreadyArchived is the number of skipped files already present in the archive
+	AlreadyArchived int
+	// Filtered is the number of skipped files captured outside the range of the date filter
+	Filtered int
+	// TooSmall is the number of skipped files smaller than the minimum size or too short to detect their file type
+	TooSmall int
+	// Quarantined is the number of media files without a capture date copied into the quarantine directory
+	Quarantined int
+	// Kept is the number of skipped files whose name in the archive is taken by a different file, see
+	// WithOverwritePolicy
+	Kept int
+	// Failed is the number of files which couldn't be sorted
+	Failed int
+	// SortedByYear is the number of sorted files per capture year
+	SortedByYear map[int]int
+}
+
+// Add counts the result of sorting a single file.
+func (s *SortSummary) Add(res SortResult, err error) {
+	s.Scanned++
+	switch {
+	case res.Action == ActionIgnored:
+		s.NotMedia++
+	case err != nil:
+		s.Failed++
+	case res.Action == ActionSkipped:
+		s.AlreadyArchived++
+	case res.Action == ActionFiltered:
+		s.Filtered++
+	case res.Action == ActionTooSmall:
+		s.TooSmall++
+	case res.Action == ActionQuarantined:
+		s.Quarantined++
+	case res.Action == ActionKept:
+		s.Kept++
+	default:
+		s.Sorted++
+		if s.SortedByYear == nil {
+			s.SortedByYear = make(map[int]int)
+		}
+		s.SortedByYear[res.CaptureDate.Year()]++
+	}
+}
+
+// Write prints the summary to the given writer.
+func (s SortSummary) Write(w io.Writer) error {
+	years := make([]int, 0, len(s.SortedByYear))
+	for y := range s.SortedByYear {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+	lines := make([]string, 0, len(years)+1)
+	for _, y := range years {
+		lines = append(lines, fmt.Sprintf("  %d: %d sorted", y, s.SortedByYear[y]))
+	}
+	lines = append(lines, fmt.Sprintf("%d scanned, %d sorted, %d already archived, %d filtered, %d too small, %d quarantined, %d kept existing, %d not media, %d failed", s.Scanned, s.Sorted, s.AlreadyArchived, s.Filtered, s.TooSmall, s.Quarantined, s.Kept, s.NotMedia, s.Failed))
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SortAll sorts all given files and returns the summary of the run. The result of every file is passed to report, if
+// it isn't nil. If the context is cancelled, the file currently copied is aborted and the remaining files are skipped.
+// The summary then only covers the processed files and the context error is returned. The run also stops at the first
+// ErrLowDiskSpace, which is returned. With WithContainers every entry of an archive is counted like a file.
+func (a *Algorithm) SortAll(ctx context.Context, files []string, report func(SortResult, error)) (SortSummary, error) {
+	return a.sortAll(ctx, ctx, files, report)
+}
+
+// sortAll sorts all given files like SortAll. The files are sorted with sortCtx, so a file may still be finished after
+// ctx is cancelled.
+func (a *Algorithm) sortAll(ctx context.Context, sortCtx context.Context, files []string, report func(SortResult, error)) (SortSummary, error) {
+	var s SortSummary
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return s, err
+		}
+		if a.containers && containerFormat(f) != "" {
+			if err := a.sortContainerEntries(ctx, sortCtx, f, &s, report); err != nil {
+				return s, err
+			}
+			continue
+		}
+		res, err := a.sortFile(sortCtx, f)
+		if ctxErr := ctx.Err(); ctxErr != nil && err != nil {
+			return s, ctxErr
+		}
+		s.Add(res, err)
+		if report != nil {
+			report(res, err)
+		}
+		if errors.Is(err, ErrLowDiskSpace) {
+			return s, err
+		}
+	}
+	return s, nil
+}
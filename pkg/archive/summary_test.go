@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"hash"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/files"
+)
+
+func TestSortAll(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst)
+	a.extractor = func(fname string) (time.Time, error) {
+		return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	other := filepath.Join(src, "other")
+	assert.NoError(t, os.MkdirAll(other, os.ModePerm))
+	text := filepath.Join(src, "notes.txt")
+	assert.NoError(t, os.WriteFile(text, bytes.Repeat([]byte("no media\n"), 32), 0644))
+	files := []string{
+		copyFixture(t, "sample1.JPG", src),
+		copyFixture(t, "sample1.JPG", other),
+		text,
+		filepath.Join(src, "absent.jpg"),
+	}
+
+	reported := 0
+	s, err := a.SortAll(context.Background(), files, func(res SortResult, err error) {
+		reported++
+		if res.Source == text {
+			assert.ErrorIs(t, err, ErrNotMediaFile)
+		}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, len(files), reported)
+	assert.Equal(t, SortSummary{
+		Scanned:         4,
+		Sorted:          1,
+		NotMedia:        1,
+		AlreadyArchived: 1,
+		Failed:          1,
+		SortedByYear:    map[int]int{2015: 1},
+	}, s)
+
+	var buf bytes.Buffer
+	assert.NoError(t, s.Write(&buf))
+	assert.Equal(t, "  2015: 1 sorted\n4 scanned, 1 sorted, 1 already archived, 0 filtered, 0 too small, 0 quarantined, 0 kept existing, 1 not media, 1 failed\n", buf.String())
+}
+
+func TestSortAllCancelled(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst)
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	other := filepath.Join(src, "other")
+	assert.NoError(t, os.MkdirAll(other, os.ModePerm))
+	sources := []string{copyFixture(t, "sample1.JPG", src), copyFixture(t, "sample1.JPG", other)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	copies := 0
+	a.copier = func(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+		copies++
+		// cancelled while the file is copied
+		cancel()
+		return files.CopyContext(ctx, src, dst, hFunc)
+	}
+	s, err := a.SortAll(ctx, sources, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, SortSummary{}, s)
+	assert.Equal(t, 1, copies)
+	assert.Empty(t, archiveFiles(t, dst), "no temporary file must be left")
+}
@@ -0,0 +1,65 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// undatedDirName is the directory below the quarantine directory holding the media files without a capture date
+const undatedDirName = "undated"
+
+// WithUndatedQuarantine copies media files without a capture date in their meta data below quarantine/undated of the
+// archive instead of sorting them by their modification time. The files keep their path within the source directory
+// to review them manually.
+func WithUndatedQuarantine() Option {
+	return func(a *Algorithm) {
+		a.quarantineUndated = true
+	}
+}
+
+// copyUndated copies the file below the undated quarantine directory. A file of the same name with the same content
+// is kept and the file is skipped, a file of the same name with different content is never replaced.
+func (a *Algorithm) copyUndated(ctx context.Context, fname string, res SortResult) (SortResult, error) {
+	pathInSrc, err := a.pathInSource(fname)
+	if err != nil {
+		pathInSrc = filepath.Base(fname)
+	}
+	target := path.Join(a.archiveDir, quarantineDirName, undatedDirName, pathInSrc)
+	targetDir := path.Dir(target)
+	err = a.fileSystem.EnsureDirectory(targetDir)
+	if err != nil {
+		return res, errors.Wrapf(err, "could not create quarantine dir '%s'", targetDir)
+	}
+	tmpFile := path.Join(targetDir, tmpFileName)
+	sum, err := a.copyToTemp(ctx, fname, tmpFile)
+	if err != nil {
+		return res, errors.Wrap(err, "could not copy file and compute checksum")
+	}
+	res.Hash = sum
+	if _, err := os.Lstat(target); err == nil {
+		_ = a.fileSystem.EnsureAbsent(tmpFile)
+		existingSum, err := a.hasher(target, sha256.New224())
+		if err != nil {
+			return res, errors.Wrap(err, "could not compute checksum of existing file")
+		}
+		if !bytes.Equal(sum, existingSum) {
+			return res, fmt.Errorf("%s already exists with different content", target)
+		}
+		res.Action, res.Target = ActionSkipped, target
+		return res, nil
+	}
+	err = a.fileSystem.Rename(tmpFile, target)
+	if err != nil {
+		_ = a.fileSystem.EnsureAbsent(tmpFile)
+		return res, errors.Wrap(err, "could not mv temporary file to quarantine")
+	}
+	res.Action, res.Target = ActionQuarantined, target
+	return res, a.record(JournalCreated, target, "")
+}
@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hikhvar/exifsorter/pkg/extraction"
+)
+
+func TestSortWithUndatedQuarantine(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithUndatedQuarantine(), WithDateExtractor(func(string) (time.Time, error) {
+		return time.Date(2015, 12, 24, 13, 59, 17, 0, time.UTC), nil
+	}))
+	a.hasCaptureDate = func(fname string) (bool, error) {
+		return filepath.Base(filepath.Dir(fname)) != "undated", nil
+	}
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	undatedDir := filepath.Join(src, "undated")
+	assert.NoError(t, os.MkdirAll(undatedDir, os.ModePerm))
+	undated := copyFixture(t, "sample1.JPG", undatedDir)
+
+	res, err := a.SortFile(undated)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionQuarantined, res.Action)
+	assert.Equal(t, filepath.Join(dst, "quarantine/undated/undated/sample1.JPG"), res.Target)
+	assert.Empty(t, res.OriginLink)
+	assert.FileExists(t, res.Target)
+	assert.NoDirExists(t, filepath.Join(dst, "2015"))
+
+	res, err = a.SortFile(undated)
+	assert.NoError(t, err)
+	assert.Equal(t, ActionSkipped, res.Action)
+
+	// a different file of the same name isn't replaced
+	assert.NoError(t, os.WriteFile(undated, []byte("other content"), 0644))
+	a.isMedia = func(string) (bool, error) { return true, nil }
+	_, err = a.SortFile(undated)
+	assert.ErrorContains(t, err, "already exists with different content")
+
+	res, err = a.SortFile(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+	assert.Equal(t, ActionCopied, res.Action)
+	assert.Equal(t, filepath.Join(dst, "2015/12/20151224_135917_7c0ed5ba.JPG"), res.Target)
+}
+
+func TestSortWithUndatedQuarantineRegistry(t *testing.T) {
+	// the registry knows no date of JPEG files, unlike the default registry
+	reg := extraction.NewRegistry(func(extraction.ReadSeekerAt) (time.Time, error) {
+		return time.Time{}, errors.New("no date")
+	})
+	for name, opts := range map[string][]Option{
+		"registry":                {WithRegistry(reg)},
+		"sidecar before registry": {WithSidecarDates(), WithRegistry(reg)},
+		"sidecar after registry":  {WithRegistry(reg), WithSidecarDates()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src, dst := t.TempDir(), t.TempDir()
+			a := NewAlgorithm(src, dst, append(opts, WithUndatedQuarantine())...)
+			if !assert.NoError(t, a.Init()) {
+				return
+			}
+			res, err := a.SortFile(copyFixture(t, "sample1.JPG", src))
+			assert.NoError(t, err)
+			assert.Equal(t, ActionQuarantined, res.Action)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const originDirName = "origin"
+
+// VerifyReport lists all integrity problems found in an archive.
+type VerifyReport struct {
+	// Orphaned are links below origin without a corresponding calendar file
+	Orphaned []string
+	// Detached maps links below origin to their calendar file if they aren't the same file anymore
+	Detached map[string]string
+	// Unlinked are calendar files without any link below origin. Archives without an origin directory, e.g. sorted
+	// with LinkNone, have no unlinked files.
+	Unlinked []string
+}
+
+// Ok returns true if no problems were found.
+func (r VerifyReport) Ok() bool {
+	return len(r.Orphaned) == 0 && len(r.Detached) == 0 && len(r.Unlinked) == 0
+}
+
+// Verify walks the archiveRoot and checks that every file below origin is a hard link to its calendar file and that
+// every calendar file is linked at least once. If the archive has no origin directory, e.g. because it was sorted with
+// LinkNone, the calendar files aren't expected to be linked.
+func Verify(archiveRoot string) (VerifyReport, error) {
+	ret := VerifyReport{Detached: make(map[string]string)}
+	linked := make(map[string]struct{})
+	var calendarFiles, originFiles []string
+	// the calendar files by name, the calendar directories may be below different media type directories
+	calendarByName := make(map[string]string)
+	hasOrigin := false
+	err := filepath.WalkDir(archiveRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		inArchive, err := pathInArchive(archiveRoot, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			hasOrigin = hasOrigin || isOriginStoredFile(inArchive+"/")
+			return nil
+		}
+		if isCalendarStoredFile(inArchive) {
+			calendarFiles = append(calendarFiles, p)
+			calendarByName[filepath.Base(p)] = p
+		} else if isOriginStoredFile(inArchive) {
+			originFiles = append(originFiles, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return VerifyReport{}, fmt.Errorf("failed to walk archive: %w", err)
+	}
+	for _, p := range originFiles {
+		calendarFile, found := calendarByName[filepath.Base(p)]
+		if !found {
+			ret.Orphaned = append(ret.Orphaned, p)
+			continue
+		}
+		same, err := sameFile(p, calendarFile)
+		if err != nil {
+			return VerifyReport{}, err
+		}
+		linked[calendarFile] = struct{}{}
+		if !same {
+			ret.Detached[p] = calendarFile
+		}
+	}
+	for _, c := range calendarFiles {
+		if _, found := linked[c]; !found && hasOrigin {
+			ret.Unlinked = append(ret.Unlinked, c)
+		}
+	}
+	return ret, nil
+}
+
+// Fix re-creates all detached links. Orphaned links and unlinked calendar files can't be fixed automatically.
+func (r VerifyReport) Fix(fs FileSystem) error {
+	links := make([]string, 0, len(r.Detached))
+	for l := range r.Detached {
+		links = append(links, l)
+	}
+	sort.Strings(links)
+	for _, l := range links {
+		err := fs.CreateLinks([]string{l}, r.Detached[l])
+		if err != nil {
+			return fmt.Errorf("failed to re-create link %s: %w", l, err)
+		}
+	}
+	return nil
+}
+
+// Write prints the report to the given writer.
+func (r VerifyReport) Write(w io.Writer) error {
+	var lines []string
+	for _, o := range r.Orphaned {
+		lines = append(lines, fmt.Sprintf("orphaned  %s", o))
+	}
+	detached := make([]string, 0, len(r.Detached))
+	for l := range r.Detached {
+		detached = append(detached, l)
+	}
+	sort.Strings(detached)
+	for _, l := range detached {
+		lines = append(lines, fmt.Sprintf("detached  %s (calendar file %s)", l, r.Detached[l]))
+	}
+	for _, u := range r.Unlinked {
+		lines = append(lines, fmt.Sprintf("unlinked  %s", u))
+	}
+	lines = append(lines, fmt.Sprintf("%d orphaned, %d detached, %d unlinked", len(r.Orphaned), len(r.Detached), len(r.Unlinked)))
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isOriginStoredFile returns true if the file is stored below an origin directory. The origin directory may be below a
+// media type directory, e.g. photos/origin. The filename must be a relative path within the archive.
+func isOriginStoredFile(filename string) bool {
+	filename = filepath.ToSlash(filename)
+	if strings.HasPrefix(filename, originDirName+"/") {
+		return true
+	}
+	prefix, rest, found := strings.Cut(filename, "/")
+	return found && prefix != quarantineDirName && strings.HasPrefix(rest, originDirName+"/")
+}
+
+func sameFile(a, b string) (bool, error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(aInfo, bInfo), nil
+}
@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	root := t.TempDir()
+	writeArchiveFile(t, root, "2019/04/20190417_133044_537842c8.jpg", "linked")
+	linkArchiveFile(t, root, "2019/04/20190417_133044_537842c8.jpg", "origin/foo/20190417_133044_537842c8.jpg")
+	writeArchiveFile(t, root, "2019/04/20190417_151708_537842c8.jpg", "detached")
+	writeArchiveFile(t, root, "origin/bar/20190417_151708_537842c8.jpg", "detached")
+	writeArchiveFile(t, root, "origin/bar/20200101_000000_aaaaaaaa.jpg", "orphaned")
+	writeArchiveFile(t, root, "origin/bar/notes.txt", "orphaned")
+	writeArchiveFile(t, root, "2019/05/20190501_000000_bbbbbbbb.jpg", "unlinked")
+
+	report, err := Verify(root)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, report.Ok())
+	assert.Equal(t, []string{
+		filepath.Join(root, "origin/bar/20200101_000000_aaaaaaaa.jpg"),
+		filepath.Join(root, "origin/bar/notes.txt"),
+	}, report.Orphaned)
+	assert.Equal(t, map[string]string{
+		filepath.Join(root, "origin/bar/20190417_151708_537842c8.jpg"): filepath.Join(root, "2019/04/20190417_151708_537842c8.jpg"),
+	}, report.Detached)
+	assert.Equal(t, []string{filepath.Join(root, "2019/05/20190501_000000_bbbbbbbb.jpg")}, report.Unlinked)
+
+	assert.NoError(t, report.Fix(NewOSFileSystem()))
+	fixed, err := Verify(root)
+	assert.NoError(t, err)
+	assert.Empty(t, fixed.Detached)
+	assert.Len(t, fixed.Orphaned, 2)
+
+	same, err := sameFile(filepath.Join(root, "origin/bar/20190417_151708_537842c8.jpg"), filepath.Join(root, "2019/04/20190417_151708_537842c8.jpg"))
+	assert.NoError(t, err)
+	assert.True(t, same)
+	_, err = os.Stat(filepath.Join(root, "origin/foo/20190417_133044_537842c8.jpg"))
+	assert.NoError(t, err)
+}
+
+func TestVerifyWithoutOrigin(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	a := NewAlgorithm(src, dst, WithLinkStrategy(LinkNone))
+	if !assert.NoError(t, a.Init()) {
+		return
+	}
+	_, err := a.Sort(copyFixture(t, "sample1.JPG", src))
+	assert.NoError(t, err)
+
+	report, err := Verify(dst)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Unlinked, "calendar files aren't linked without an origin directory")
+	assert.True(t, report.Ok())
+}
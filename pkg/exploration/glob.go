@@ -1,6 +1,9 @@
 package exploration
 
 import (
+	"path/filepath"
+	"strings"
+
 	"github.com/gobwas/glob"
 	"github.com/pkg/errors"
 )
@@ -24,6 +27,39 @@ func GobwasMatcherFromPatterns(patterns []string) ([]Matcher, error) {
 	return ret, nil
 }
 
+// RelativeGobwasMatcherFromPatterns returns the matchers of GobwasMatcherFromPatterns, which also match the paths
+// relative to root, e.g. tmp/** matches all files below <root>/tmp wherever root is mounted. The relative paths use
+// forward slashes on all platforms. The full path is still matched, so patterns written for it keep working.
+func RelativeGobwasMatcherFromPatterns(root string, patterns []string) ([]Matcher, error) {
+	matchers, err := GobwasMatcherFromPatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range matchers {
+		matchers[i] = relativeMatcher{root: root, matcher: m}
+	}
+	return matchers, nil
+}
+
+// relativeMatcher matches the full path and the path relative to root.
+type relativeMatcher struct {
+	root    string
+	matcher Matcher
+}
+
+// Match returns true if the full path or the path relative to the root matches. Paths outside of the root and the root
+// itself are only matched by their full path.
+func (m relativeMatcher) Match(name string) bool {
+	if m.matcher.Match(name) {
+		return true
+	}
+	rel, err := filepath.Rel(m.root, name)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return m.matcher.Match(filepath.ToSlash(rel))
+}
+
 func isIgnored(ignores []Matcher, dir string) bool {
 	for _, g := range ignores {
 		if g.Match(dir) {
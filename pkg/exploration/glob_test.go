@@ -38,3 +38,24 @@ func TestGobwasMatcherFromPatterns(t *testing.T) {
 		})
 	}
 }
+
+func TestRelativeGobwasMatcherFromPatterns(t *testing.T) {
+	matchers, err := RelativeGobwasMatcherFromPatterns("/mnt/photos", []string{"tmp/**", "/mnt/photos/old/**"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	for name, ignored := range map[string]bool{
+		"/mnt/photos/tmp/a.jpg":        true,
+		"/mnt/photos/tmp/sub/b.jpg":    true,
+		"/mnt/photos/old/c.jpg":        true,
+		"/mnt/photos/2019/tmp/d.jpg":   false,
+		"/mnt/photos/e.jpg":            false,
+		"/mnt/other/tmp/f.jpg":         false,
+		"/mnt/photos/tmp-backup/g.jpg": false,
+	} {
+		assert.Equal(t, ignored, isIgnored(matchers, name), name)
+	}
+
+	_, err = RelativeGobwasMatcherFromPatterns("/mnt/photos", []string{"[\\&"})
+	assert.Error(t, err)
+}
@@ -17,30 +17,149 @@ package exploration
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// InitialFilesOption configures optional behaviour of InitialFiles
+type InitialFilesOption func(w *walker)
+
+// FollowSymlinks descends into symlinked directories. Directories reachable by several paths are walked only once.
+func FollowSymlinks() InitialFilesOption {
+	return func(w *walker) {
+		w.followSymlinks = true
+		w.visited = make(map[string]struct{})
+	}
+}
+
+// OnWalkError calls onError for every file or directory which can't be read, e.g. because of missing permissions.
+// Such entries are skipped. Without this option they are skipped silently.
+func OnWalkError(onError func(path string, err error)) InitialFilesOption {
+	return func(w *walker) {
+		w.onError = onError
+	}
+}
+
+// MaxDepth only collects files up to the given depth below the root directory, e.g. 1 for the files directly in the
+// root directory. Directories whose files would be deeper are skipped. A depth of 0 or less is unlimited.
+func MaxDepth(depth int) InitialFilesOption {
+	return func(w *walker) {
+		w.maxDepth = depth
+	}
+}
+
+// walker collects the files and directories of a tree.
+type walker struct {
+	ignores        []Matcher
+	rootDir        string
+	maxDepth       int
+	followSymlinks bool
+	onError        func(path string, err error)
+	// visited are the real paths of all walked directories
+	visited     map[string]struct{}
+	directories []string
+	files       []string
+}
+
 // InitialFiles return all files and directories in the tree below rootDir and the rootDir itself.
-// ignores is a list of relativ subdirs to ignore
-func InitialFiles(rootDir string, ignores []Matcher) (directories []string, files []string, err error) {
-	walkFunc := func(path string, info os.FileInfo, err error) error {
-		if err != nil && info == nil {
+// ignores is a list of relativ subdirs to ignore. An error is only returned if rootDir can't be read, unreadable
+// entries below are reported by OnWalkError.
+func InitialFiles(rootDir string, ignores []Matcher, opts ...InitialFilesOption) (directories []string, files []string, err error) {
+	w := &walker{ignores: ignores, rootDir: rootDir}
+	for _, opt := range opts {
+		opt(w)
+	}
+	err = w.walk(rootDir, rootDir)
+	return w.directories, w.files, err
+}
+
+// walk walks the tree below realRoot and reports all paths below root instead.
+func (w *walker) walk(root string, realRoot string) error {
+	walkFunc := func(realPath string, info os.FileInfo, err error) error {
+		path := realPath
+		if root != realRoot {
+			rel, err := filepath.Rel(realRoot, realPath)
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(root, rel)
+		}
+		if err != nil {
+			if info == nil && realPath == realRoot && root == realRoot {
+				return err
+			}
+			if w.onError != nil {
+				w.onError(path, err)
+			}
+			// the entry itself was reported before its contents couldn't be read
 			return nil
 		}
-		if isIgnored(ignores, path) {
+		if isIgnored(w.ignores, path) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			} else {
 				return nil
 			}
 		}
+		if tooDeep(w.rootDir, path, w.maxDepth, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 && w.followSymlinks {
+			target, err := filepath.EvalSymlinks(realPath)
+			if err != nil {
+				return nil
+			}
+			targetInfo, err := os.Stat(target)
+			if err == nil && targetInfo.IsDir() {
+				return w.walk(path, target)
+			}
+		}
 		if info.IsDir() {
-			directories = append(directories, path)
+			if w.followSymlinks && !w.firstVisit(realPath) {
+				return filepath.SkipDir
+			}
+			w.directories = append(w.directories, path)
 		} else {
-			files = append(files, path)
+			w.files = append(w.files, path)
 		}
 
 		return nil
 	}
-	err = filepath.Walk(rootDir, walkFunc)
-	return directories, files, err
+	return filepath.Walk(realRoot, walkFunc)
+}
+
+// firstVisit records the real path of the directory and returns true if it wasn't visited before.
+func (w *walker) firstVisit(dir string) bool {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return true
+	}
+	real, err = filepath.Abs(real)
+	if err != nil {
+		return true
+	}
+	if _, found := w.visited[real]; found {
+		return false
+	}
+	w.visited[real] = struct{}{}
+	return true
+}
+
+// tooDeep returns true if name is below root and its depth exceeds maxDepth. Directories exceed it if their files
+// would. A maxDepth of 0 or less is unlimited.
+func tooDeep(root string, name string, maxDepth int, isDir bool) bool {
+	if maxDepth <= 0 {
+		return false
+	}
+	rel, err := filepath.Rel(root, name)
+	if err != nil || rel == "." {
+		return false
+	}
+	depth := strings.Count(rel, string(filepath.Separator)) + 1
+	if isDir {
+		depth++
+	}
+	return depth > maxDepth
 }
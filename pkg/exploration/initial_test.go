@@ -16,6 +16,7 @@ package exploration
 
 import (
 	"os"
+	"syscall"
 	"testing"
 
 	"path"
@@ -31,6 +32,7 @@ func TestInitialFiles(t *testing.T) {
 		cleanDir            bool
 		filesToTouch        []touchFile
 		ignores             []Matcher
+		opts                []InitialFilesOption
 		expectedFiles       []string
 		expectedDirectories []string
 		expectedError       error
@@ -42,9 +44,10 @@ func TestInitialFiles(t *testing.T) {
 			expectedDirectories: []string{""},
 		},
 		{
-			name:     "not existing dir",
-			dir:      "/tmp/foo-bar",
-			cleanDir: false,
+			name:          "not existing dir",
+			dir:           "/tmp/foo-bar",
+			cleanDir:      false,
+			expectedError: &os.PathError{Op: "lstat", Path: "/tmp/foo-bar", Err: syscall.ENOENT},
 		},
 		{
 			name:     "dir with file and subdir",
@@ -109,6 +112,36 @@ func TestInitialFiles(t *testing.T) {
 			expectedDirectories: []string{"", "foo"},
 			expectedFiles:       []string{"baz", "foo/bar"},
 		},
+		{
+			name:     "max depth",
+			dir:      createTempDir(t),
+			cleanDir: true,
+			filesToTouch: []touchFile{
+				{
+					name:  "2019",
+					isDir: true,
+				},
+				{
+					name:  "2019/foo",
+					isDir: false,
+				},
+				{
+					name:  "2019/junk",
+					isDir: true,
+				},
+				{
+					name:  "2019/junk/bar",
+					isDir: false,
+				},
+				{
+					name:  "baz",
+					isDir: false,
+				},
+			},
+			opts:                []InitialFilesOption{MaxDepth(2)},
+			expectedDirectories: []string{"", "2019"},
+			expectedFiles:       []string{"2019/foo", "baz"},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -116,7 +149,7 @@ func TestInitialFiles(t *testing.T) {
 				defer os.RemoveAll(test.dir)
 			}
 			touchFiles(t, test.dir, test.filesToTouch)
-			dirs, files, err := InitialFiles(test.dir, test.ignores)
+			dirs, files, err := InitialFiles(test.dir, test.ignores, test.opts...)
 			joinPathsWithTempFile(test.dir, test.expectedFiles)
 			joinPathsWithTempFile(test.dir, test.expectedDirectories)
 			assert.Equal(t, test.expectedFiles, files)
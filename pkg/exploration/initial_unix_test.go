@@ -0,0 +1,57 @@
+//go:build unix
+
+package exploration
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitialFilesFollowSymlinks(t *testing.T) {
+	root, external := t.TempDir(), t.TempDir()
+	touchFiles(t, root, []touchFile{{name: "real", isDir: true}, {name: "real/a.jpg"}})
+	touchFiles(t, external, []touchFile{{name: "b.jpg"}})
+	assert.NoError(t, os.Symlink(external, path.Join(root, "link")))
+	assert.NoError(t, os.Symlink(external, path.Join(root, "real", "same")))
+	assert.NoError(t, os.Symlink(root, path.Join(root, "real", "loop")))
+
+	dirs, files, err := InitialFiles(root, nil, FollowSymlinks())
+	assert.NoError(t, err)
+	expectedDirectories := []string{"", "link", "real"}
+	expectedFiles := []string{"link/b.jpg", "real/a.jpg"}
+	joinPathsWithTempFile(root, expectedDirectories)
+	joinPathsWithTempFile(root, expectedFiles)
+	assert.Equal(t, expectedDirectories, dirs)
+	assert.Equal(t, expectedFiles, files)
+
+	dirs, files, err = InitialFiles(root, nil)
+	assert.NoError(t, err)
+	expectedFiles = []string{"link", "real/a.jpg", "real/loop", "real/same"}
+	joinPathsWithTempFile(root, expectedFiles)
+	assert.Equal(t, []string{root, path.Join(root, "real")}, dirs)
+	assert.Equal(t, expectedFiles, files)
+}
+
+func TestInitialFilesOnWalkError(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can read every directory")
+	}
+	root := t.TempDir()
+	touchFiles(t, root, []touchFile{{name: "secret", isDir: true}, {name: "secret/a.jpg"}, {name: "b.jpg"}})
+	secret := path.Join(root, "secret")
+	assert.NoError(t, os.Chmod(secret, 0))
+	defer os.Chmod(secret, 0755)
+
+	var unreadable []string
+	dirs, files, err := InitialFiles(root, nil, OnWalkError(func(p string, err error) {
+		assert.ErrorIs(t, err, os.ErrPermission)
+		unreadable = append(unreadable, p)
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{root, secret}, dirs)
+	assert.Equal(t, []string{path.Join(root, "b.jpg")}, files)
+	assert.Equal(t, []string{secret}, unreadable)
+}
@@ -0,0 +1,171 @@
+package exploration
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// sizeUnits are the supported suffixes of size predicates
+var sizeUnits = map[byte]int64{
+	'k': 1 << 10,
+	'm': 1 << 20,
+	'g': 1 << 30,
+	't': 1 << 40,
+}
+
+// SizeMatcher matches regular files smaller or larger than a size.
+type SizeMatcher struct {
+	size    int64
+	smaller bool
+}
+
+// SizeMatcherFromPredicate returns the matcher for predicates like "<50k" or ">2G". The units k, M, G and T are
+// powers of 1024.
+func SizeMatcherFromPredicate(predicate string) (SizeMatcher, error) {
+	p := strings.TrimSpace(predicate)
+	if len(p) < 2 || (p[0] != '<' && p[0] != '>') {
+		return SizeMatcher{}, errors.Errorf("size predicate '%s' must start with < or >", predicate)
+	}
+	size, err := ParseSize(p[1:])
+	if err != nil {
+		return SizeMatcher{}, errors.Errorf("invalid size in predicate '%s'", predicate)
+	}
+	return SizeMatcher{size: size, smaller: p[0] == '<'}, nil
+}
+
+// ParseSize returns the number of bytes of sizes like "50k" or "2G". The units k, M, G and T are powers of 1024.
+func ParseSize(size string) (int64, error) {
+	num := strings.TrimSpace(size)
+	if num == "" {
+		return 0, errors.New("empty size")
+	}
+	factor := int64(1)
+	if f, found := sizeUnits[strings.ToLower(num)[len(num)-1]]; found {
+		factor = f
+		num = num[:len(num)-1]
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid size '%s'", size)
+	}
+	return n * factor, nil
+}
+
+// Match returns true if name is a regular file and its size matches. Files which can't be stat'ed never match.
+func (m SizeMatcher) Match(name string) bool {
+	info, err := os.Stat(name)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if m.smaller {
+		return info.Size() < m.size
+	}
+	return info.Size() > m.size
+}
+
+// ExtensionMatcher matches files by their extension regardless of their location. The comparison is case-insensitive.
+type ExtensionMatcher map[string]struct{}
+
+// NewExtensionMatcher returns a matcher for the given extensions. The leading dot is optional.
+func NewExtensionMatcher(extensions []string) ExtensionMatcher {
+	m := make(ExtensionMatcher, len(extensions))
+	for _, e := range extensions {
+		m["."+strings.ToLower(strings.TrimPrefix(e, "."))] = struct{}{}
+	}
+	return m
+}
+
+// Match returns true if the extension of name is one of the matchers extensions.
+func (m ExtensionMatcher) Match(name string) bool {
+	_, found := m[strings.ToLower(filepath.Ext(name))]
+	return found
+}
+
+// PathMatcher matches the given directories and everything below them regardless of the form of the matched path.
+type PathMatcher []string
+
+// NewPathMatcher returns a matcher for the given files or directories. Relative paths are resolved against the
+// working directory.
+func NewPathMatcher(paths ...string) PathMatcher {
+	m := make(PathMatcher, 0, len(paths))
+	for _, p := range paths {
+		m = append(m, absPath(p))
+	}
+	return m
+}
+
+// Match returns true if name is one of the matchers paths or below one of them.
+func (m PathMatcher) Match(name string) bool {
+	name = absPath(name)
+	for _, p := range m {
+		if isBelow(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBelow returns true if name is dir or a path below dir. Both paths must be either absolute or relative.
+func isBelow(dir, name string) bool {
+	rel, err := filepath.Rel(dir, name)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// absPath returns the cleaned absolute form of p, or the cleaned p if the working directory is unknown.
+func absPath(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return filepath.Clean(p)
+	}
+	return abs
+}
+
+// HiddenMatcher matches dotfiles and dot directories, e.g. .DS_Store or .thumbnails. Only the last element of the path
+// is considered. The root directories given to NewHiddenMatcher are never matched, even if they are hidden.
+type HiddenMatcher struct {
+	roots []string
+}
+
+// NewHiddenMatcher returns a matcher for hidden files below the given root directories.
+func NewHiddenMatcher(roots ...string) HiddenMatcher {
+	m := HiddenMatcher{roots: make([]string, 0, len(roots))}
+	for _, r := range roots {
+		m.roots = append(m.roots, absPath(r))
+	}
+	return m
+}
+
+// Match returns true if the base name of name starts with a dot and name isn't one of the root directories.
+func (m HiddenMatcher) Match(name string) bool {
+	base := filepath.Base(name)
+	if !strings.HasPrefix(base, ".") || base == "." || base == ".." {
+		return false
+	}
+	abs := absPath(name)
+	for _, r := range m.roots {
+		if abs == r {
+			return false
+		}
+	}
+	return true
+}
+
+// AppleDoubleMatcher matches the AppleDouble resource forks named ._<file> which macOS writes next to files on file
+// systems without extended attributes.
+type AppleDoubleMatcher struct{}
+
+// Match returns true if the base name of name starts with ._ and isn't a directory.
+func (AppleDoubleMatcher) Match(name string) bool {
+	if !strings.HasPrefix(filepath.Base(name), "._") {
+		return false
+	}
+	info, err := os.Stat(name)
+	return err != nil || !info.IsDir()
+}
@@ -0,0 +1,90 @@
+package exploration
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeMatcherFromPredicate(t *testing.T) {
+	tests := []struct {
+		predicate     string
+		expected      SizeMatcher
+		expectedError string
+	}{
+		{predicate: "<50k", expected: SizeMatcher{size: 50 * 1024, smaller: true}},
+		{predicate: ">2G", expected: SizeMatcher{size: 2 << 30}},
+		{predicate: " >100 ", expected: SizeMatcher{size: 100}},
+		{predicate: "50k", expectedError: "size predicate '50k' must start with < or >"},
+		{predicate: "<", expectedError: "size predicate '<' must start with < or >"},
+		{predicate: "<5x", expectedError: "invalid size in predicate '<5x'"},
+	}
+	for _, test := range tests {
+		t.Run(test.predicate, func(t *testing.T) {
+			m, err := SizeMatcherFromPredicate(test.predicate)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, m)
+		})
+	}
+}
+
+func TestSizeMatcher(t *testing.T) {
+	dir := t.TempDir()
+	small, large := path.Join(dir, "small"), path.Join(dir, "large")
+	assert.NoError(t, os.WriteFile(small, make([]byte, 10), 0644))
+	assert.NoError(t, os.WriteFile(large, make([]byte, 2048), 0644))
+	smaller := SizeMatcher{size: 1024, smaller: true}
+	larger := SizeMatcher{size: 1024}
+
+	assert.True(t, smaller.Match(small))
+	assert.False(t, smaller.Match(large))
+	assert.False(t, larger.Match(small))
+	assert.True(t, larger.Match(large))
+	assert.False(t, smaller.Match(dir), "directories never match")
+	assert.False(t, smaller.Match(path.Join(dir, "absent")), "missing files never match")
+}
+
+func TestExtensionMatcher(t *testing.T) {
+	m := NewExtensionMatcher([]string{"aae", ".THM"})
+	assert.True(t, m.Match("/foo/IMG_0001.AAE"))
+	assert.True(t, m.Match("/foo/bar.thm"))
+	assert.False(t, m.Match("/foo/IMG_0001.JPG"))
+	assert.False(t, m.Match("/foo/aae"))
+}
+
+func TestPathMatcher(t *testing.T) {
+	m := NewPathMatcher("/src/archive", "/src/tmp/")
+	assert.True(t, m.Match("/src/archive"))
+	assert.True(t, m.Match("/src/archive/2018/01/foo.jpg"))
+	assert.True(t, m.Match("/src/./tmp/foo.jpg"))
+	assert.False(t, m.Match("/src/archive2/foo.jpg"))
+	assert.False(t, m.Match("/src/foo.jpg"))
+	assert.False(t, m.Match("/src"))
+}
+
+func TestHiddenMatcher(t *testing.T) {
+	m := NewHiddenMatcher("/src/.photos")
+	assert.True(t, m.Match("/src/.photos/.DS_Store"))
+	assert.True(t, m.Match("/src/.photos/._IMG_0001.JPG"))
+	assert.True(t, m.Match("/src/.photos/.thumbnails"))
+	assert.False(t, m.Match("/src/.photos"), "the root directory is never hidden")
+	assert.False(t, m.Match("/src/.photos/IMG_0001.JPG"))
+	assert.False(t, m.Match("/src/.photos/2018.06/IMG_0001.JPG"))
+	assert.False(t, m.Match("."))
+}
+
+func TestAppleDoubleMatcher(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(path.Join(dir, "._dir"), 0755))
+	m := AppleDoubleMatcher{}
+	assert.True(t, m.Match(path.Join(dir, "._IMG_0001.JPG")))
+	assert.False(t, m.Match(path.Join(dir, "._dir")), "directories never match")
+	assert.False(t, m.Match(path.Join(dir, ".DS_Store")))
+	assert.False(t, m.Match(path.Join(dir, "IMG_0001.JPG")))
+}
@@ -17,6 +17,9 @@ package exploration
 import (
 	"context"
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"os"
 
@@ -24,16 +27,81 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	allOps = fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename | fsnotify.Chmod
+
+	defaultEventBuffer = 10
+	defaultErrorBuffer = 10
+)
+
 type RecursiveWatcher struct {
-	watcher *fsnotify.Watcher
-	ignores []Matcher
-	Events  chan fsnotify.Event
-	Errors  chan error
+	watcher       *fsnotify.Watcher
+	ignores       []Matcher
+	ops           fsnotify.Op
+	root          string
+	maxDepth      int
+	debounce      time.Duration
+	pending       map[string]*pendingEvent
+	mtx           sync.Mutex
+	eventBuffer   int
+	errorBuffer   int
+	droppedErrors atomic.Uint64
+	Events        chan fsnotify.Event
+	Errors        chan error
+}
+
+// pendingEvent is an event waiting for its file to become quiescent
+type pendingEvent struct {
+	event fsnotify.Event
+	timer *time.Timer
+}
+
+// WatcherOption configures optional behaviour of the RecursiveWatcher
+type WatcherOption func(r *RecursiveWatcher)
+
+// WithOps limits the forwarded events to the given operations. By default all operations are forwarded.
+func WithOps(ops fsnotify.Op) WatcherOption {
+	return func(r *RecursiveWatcher) {
+		r.ops = ops
+	}
+}
+
+// WithDebounce delays the events of a file until no further event for the file occurred for the given duration. The
+// operations of all delayed events of a file are combined into a single event.
+func WithDebounce(d time.Duration) WatcherOption {
+	return func(r *RecursiveWatcher) {
+		r.debounce = d
+	}
+}
+
+// WithMaxDepth only watches the directories up to the given depth below root like the MaxDepth option of
+// InitialFiles. Created directories whose files would be deeper are not watched.
+func WithMaxDepth(root string, depth int) WatcherOption {
+	return func(r *RecursiveWatcher) {
+		r.root = root
+		r.maxDepth = depth
+	}
+}
+
+// WithEventBuffer sets the capacity of the Events channel. The default is 10.
+func WithEventBuffer(size int) WatcherOption {
+	return func(r *RecursiveWatcher) {
+		r.eventBuffer = size
+	}
+}
+
+// WithErrorBuffer sets the capacity of the Errors channel. The default is 10. Errors are dropped if the channel is
+// full, see DroppedErrors.
+func WithErrorBuffer(size int) WatcherOption {
+	return func(r *RecursiveWatcher) {
+		r.errorBuffer = size
+	}
 }
 
 // NewRecursiveWatcher creates a new recursive file watcher. You can listen for errors and events via the channels
-// Events and Errors
-func NewRecursiveWatcher(ctx context.Context, ignores []Matcher, initialDirs ...string) (*RecursiveWatcher, error) {
+// Events and Errors. Errors never block the delivery of events, errors which don't fit into the Errors channel are
+// dropped.
+func NewRecursiveWatcher(ctx context.Context, ignores []Matcher, initialDirs []string, opts ...WatcherOption) (*RecursiveWatcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create watcher")
@@ -47,11 +115,18 @@ func NewRecursiveWatcher(ctx context.Context, ignores []Matcher, initialDirs ...
 	}
 
 	r := &RecursiveWatcher{
-		watcher: watcher,
-		ignores: ignores,
-		Events:  make(chan fsnotify.Event, 10),
-		Errors:  make(chan error),
+		watcher:     watcher,
+		ignores:     ignores,
+		ops:         allOps,
+		pending:     make(map[string]*pendingEvent),
+		eventBuffer: defaultEventBuffer,
+		errorBuffer: defaultErrorBuffer,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.Events = make(chan fsnotify.Event, r.eventBuffer)
+	r.Errors = make(chan error, r.errorBuffer)
 	go r.run(ctx)
 	return r, nil
 }
@@ -60,22 +135,88 @@ func (r *RecursiveWatcher) run(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			r.flush()
 			err := r.watcher.Close()
 			if err != nil {
-				r.Errors <- err
+				r.sendError(err)
 			}
 			return
 		case e := <-r.watcher.Errors:
-			r.Errors <- e
+			r.sendError(e)
 		case e := <-r.watcher.Events:
 			if !isIgnored(r.ignores, e.Name) {
 				r.processEvent(e)
-				r.Events <- e
+				if e.Op&r.ops == 0 {
+					break
+				}
+				if r.debounce > 0 {
+					r.delay(ctx, e)
+				} else {
+					r.Events <- e
+				}
 			}
 		}
 	}
 }
 
+// delay (re)starts the quiescence timer of the events file.
+func (r *RecursiveWatcher) delay(ctx context.Context, e fsnotify.Event) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if p, found := r.pending[e.Name]; found {
+		if p.timer.Stop() {
+			p.event.Op |= e.Op
+			p.timer.Reset(r.debounce)
+			return
+		}
+		// the timer already fired and is about to send the event
+	}
+	p := &pendingEvent{event: e}
+	p.timer = time.AfterFunc(r.debounce, func() {
+		r.mtx.Lock()
+		if r.pending[e.Name] == p {
+			delete(r.pending, e.Name)
+		}
+		event := p.event
+		r.mtx.Unlock()
+		select {
+		case r.Events <- event:
+		case <-ctx.Done():
+		}
+	})
+	r.pending[e.Name] = p
+}
+
+// flush forwards all delayed events immediately as far as the Events channel has capacity.
+func (r *RecursiveWatcher) flush() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for name, p := range r.pending {
+		if !p.timer.Stop() {
+			continue
+		}
+		delete(r.pending, name)
+		select {
+		case r.Events <- p.event:
+		default:
+		}
+	}
+}
+
+// DroppedErrors returns the number of errors dropped because the Errors channel was full.
+func (r *RecursiveWatcher) DroppedErrors() uint64 {
+	return r.droppedErrors.Load()
+}
+
+// sendError forwards the error without waiting for a consumer.
+func (r *RecursiveWatcher) sendError(err error) {
+	select {
+	case r.Errors <- err:
+	default:
+		r.droppedErrors.Add(1)
+	}
+}
+
 func (r *RecursiveWatcher) processEvent(e fsnotify.Event) {
 	switch e.Op {
 	case fsnotify.Create:
@@ -83,7 +224,7 @@ func (r *RecursiveWatcher) processEvent(e fsnotify.Event) {
 		if err != nil {
 			return
 		}
-		if finfo.IsDir() {
+		if finfo.IsDir() && !tooDeep(r.root, e.Name, r.maxDepth, true) {
 			err := r.watcher.Add(e.Name)
 			if err != nil {
 				log.Printf("failed to add directory (%s) to inotify watcher: %s", e.Name, err.Error())
@@ -154,7 +154,7 @@ func TestNewRecursiveWatcher(t *testing.T) {
 			}
 			ctx, cancelFunc := context.WithTimeout(context.Background(), 1*time.Second)
 			defer cancelFunc()
-			w, err := NewRecursiveWatcher(ctx, test.ignores, test.dir)
+			w, err := NewRecursiveWatcher(ctx, test.ignores, []string{test.dir})
 			if test.expectedError != nil {
 				if !assert.NotNil(t, err) {
 					return
@@ -182,6 +182,145 @@ func TestNewRecursiveWatcher(t *testing.T) {
 	}
 }
 
+func TestNewRecursiveWatcherWithOps(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancelFunc()
+	w, err := NewRecursiveWatcher(ctx, nil, []string{dir}, WithOps(fsnotify.Create))
+	if !assert.NoError(t, err) {
+		return
+	}
+	touchFiles(t, dir, []touchFile{{name: "foo", isDir: true}, {name: "foo/bar"}})
+	name := path.Join(dir, "foo/bar")
+	assert.NoError(t, os.WriteFile(name, []byte("baz"), 0644))
+	assert.NoError(t, os.Chmod(name, 0600))
+	assert.NoError(t, os.Remove(name))
+
+	receivedEvents := make([]fsnotify.Event, 0)
+	for {
+		select {
+		case <-ctx.Done():
+			expectedEvents := []fsnotify.Event{{Op: fsnotify.Create, Name: "foo"}, {Op: fsnotify.Create, Name: "foo/bar"}}
+			joinExpectedEventsWithDir(dir, expectedEvents)
+			assert.ElementsMatch(t, expectedEvents, receivedEvents)
+			return
+		case e := <-w.Events:
+			receivedEvents = append(receivedEvents, e)
+		}
+	}
+}
+
+func TestNewRecursiveWatcherWithMaxDepth(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancelFunc()
+	w, err := NewRecursiveWatcher(ctx, nil, []string{dir}, WithOps(fsnotify.Create), WithMaxDepth(dir, 1))
+	if !assert.NoError(t, err) {
+		return
+	}
+	touchFiles(t, dir, []touchFile{{name: "foo", isDir: true}})
+	// give the watcher time to process the new directory
+	time.Sleep(100 * time.Millisecond)
+	touchFiles(t, dir, []touchFile{{name: "foo/bar"}})
+
+	receivedEvents := make([]fsnotify.Event, 0)
+	for {
+		select {
+		case <-ctx.Done():
+			expectedEvents := []fsnotify.Event{{Op: fsnotify.Create, Name: "foo"}}
+			joinExpectedEventsWithDir(dir, expectedEvents)
+			assert.ElementsMatch(t, expectedEvents, receivedEvents)
+			return
+		case e := <-w.Events:
+			receivedEvents = append(receivedEvents, e)
+		}
+	}
+}
+
+func TestNewRecursiveWatcherWithDebounce(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFunc()
+	w, err := NewRecursiveWatcher(ctx, nil, []string{dir}, WithOps(fsnotify.Create|fsnotify.Write), WithDebounce(300*time.Millisecond))
+	if !assert.NoError(t, err) {
+		return
+	}
+	name := path.Join(dir, "foo")
+	f, err := os.Create(name)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 0; i < 5; i++ {
+		_, err = f.Write([]byte("chunk"))
+		assert.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.NoError(t, f.Close())
+
+	select {
+	case e := <-w.Events:
+		assert.Equal(t, fsnotify.Event{Op: fsnotify.Create | fsnotify.Write, Name: name}, e)
+	case <-ctx.Done():
+		t.Fatal("no debounced event received")
+	}
+	select {
+	case e := <-w.Events:
+		t.Fatalf("unexpected second event %v", e)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestNewRecursiveWatcherDebounceFlushesOnCancel(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	w, err := NewRecursiveWatcher(ctx, nil, []string{dir}, WithDebounce(time.Hour))
+	if !assert.NoError(t, err) {
+		cancelFunc()
+		return
+	}
+	name := path.Join(dir, "foo")
+	assert.NoError(t, os.WriteFile(name, []byte("bar"), 0644))
+	time.Sleep(100 * time.Millisecond)
+	cancelFunc()
+
+	select {
+	case e := <-w.Events:
+		assert.Equal(t, name, e.Name)
+	case <-time.After(time.Second):
+		t.Fatal("pending event not flushed on cancel")
+	}
+}
+
+func TestNewRecursiveWatcherDropsErrors(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFunc()
+	w, err := NewRecursiveWatcher(ctx, nil, []string{dir}, WithOps(fsnotify.Create), WithErrorBuffer(1))
+	if !assert.NoError(t, err) {
+		return
+	}
+	// nobody reads the errors
+	for i := 0; i < 3; i++ {
+		w.watcher.Errors <- errors.New("watch error")
+	}
+	name := path.Join(dir, "foo")
+	assert.NoError(t, os.WriteFile(name, []byte("bar"), 0644))
+
+	select {
+	case e := <-w.Events:
+		assert.Equal(t, fsnotify.Event{Op: fsnotify.Create, Name: name}, e)
+	case <-ctx.Done():
+		t.Fatal("events blocked by unread errors")
+	}
+	assert.Equal(t, uint64(2), w.DroppedErrors())
+	assert.Len(t, w.Errors, 1)
+}
+
 func joinExpectedEventsWithDir(testDir string, expectedEvents []fsnotify.Event) {
 	for i := range expectedEvents {
 		expectedEvents[i].Name = path.Join(testDir, expectedEvents[i].Name)
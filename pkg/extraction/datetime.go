@@ -0,0 +1,76 @@
+package extraction
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xor-gate/goexif2/exif"
+	"github.com/xor-gate/goexif2/tiff"
+)
+
+// exifTimeLayouts are the accepted layouts of the EXIF date fields: the standard one and the dash separated one some
+// cameras write.
+var exifTimeLayouts = []string{"2006:01:02 15:04:05", "2006-01-02 15:04:05"}
+
+// exifDateTime returns the date of the DateTimeOriginal or, if missing, the DateTime field like exif.DateTime. Unlike
+// exif.DateTime it accepts all exifTimeLayouts and adds the fractional seconds of the corresponding SubSecTime field.
+// Dates without a time zone are in loc.
+func exifDateTime(x *exif.Exif, loc *time.Location) (time.Time, DateSource, error) {
+	var dateField, subSecField exif.FieldName = exif.DateTimeOriginal, exif.SubSecTimeOriginal
+	source := SourceExifDateTimeOriginal
+	tag, err := x.Get(dateField)
+	if err != nil {
+		dateField, subSecField, source = exif.DateTime, exif.SubSecTime, SourceExifDateTime
+		tag, err = x.Get(dateField)
+		if err != nil {
+			return time.Time{}, SourceNone, err
+		}
+	}
+	if tag.Format() != tiff.StringVal {
+		return time.Time{}, SourceNone, errors.Errorf("%s not in string format", dateField)
+	}
+	if tz, _ := makerNoteTimeZone(x); tz != nil {
+		loc = tz
+	}
+	tm, err := parseExifTime(string(tag.Val), loc)
+	if err != nil {
+		return time.Time{}, SourceNone, err
+	}
+	if subSec, err := x.Get(subSecField); err == nil && subSec.Format() == tiff.StringVal {
+		tm = tm.Add(parseSubSec(string(subSec.Val)))
+	}
+	return tm, source, nil
+}
+
+// parseExifTime parses the value of an EXIF date field in the first matching layout of exifTimeLayouts.
+func parseExifTime(val string, loc *time.Location) (time.Time, error) {
+	val = strings.TrimSpace(strings.TrimRight(val, "\x00"))
+	var err error
+	for _, layout := range exifTimeLayouts {
+		var tm time.Time
+		tm, err = time.ParseInLocation(layout, val, loc)
+		if err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, errors.Wrapf(err, "invalid exif date '%s'", val)
+}
+
+// parseSubSec returns the fraction of a second given by the digits of a SubSecTime field, e.g. 500ms for "5" or
+// "500". Invalid values are ignored.
+func parseSubSec(val string) time.Duration {
+	val = strings.TrimSpace(strings.TrimRight(val, "\x00"))
+	var nanos time.Duration
+	for i := 0; i < 9; i++ {
+		nanos *= 10
+		if i >= len(val) {
+			continue
+		}
+		if val[i] < '0' || val[i] > '9' {
+			return 0
+		}
+		nanos += time.Duration(val[i] - '0')
+	}
+	return nanos
+}
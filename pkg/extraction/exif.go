@@ -15,11 +15,9 @@
 package extraction
 
 import (
-	"fmt"
+	"io"
 	"time"
 
-	"os"
-
 	"github.com/pkg/errors"
 
 	"github.com/xor-gate/goexif2/exif"
@@ -38,37 +36,66 @@ func init() {
 	exif.RegisterParsers(mknote.All...)
 }
 
-// CaptureDate returns the point in time the capturing device created the media file
-func CaptureDate(fname string) (retTime time.Time, retErr error) {
-	defer func() {
-		r := recover()
-		if r != nil {
-			retTime = time.Time{}
-			retErr = fmt.Errorf("catched panic while processing file (%s): %v", fname, r)
-		}
-	}()
-	fInfo, fInfoErr := os.Stat(fname)
-	f, err := os.Open(fname)
+// ReadSeekerAt is the access to media data the extraction functions need, e.g. an *os.File or a *bytes.Reader.
+type ReadSeekerAt interface {
+	io.ReadSeeker
+	io.ReaderAt
+}
+
+// CaptureDate returns the point in time the capturing device created the media file. If the file contains no capture
+// date, the modification time of the file is returned. The extractor is chosen by the DefaultRegistry.
+func CaptureDate(fname string) (time.Time, error) {
+	return DefaultRegistry.CaptureDate(fname)
+}
+
+// CaptureDateWithSidecar is CaptureDate, but files without an embedded capture date use the date of their XMP sidecar
+// file, if any, before falling back to the modification time.
+func CaptureDateWithSidecar(fname string) (time.Time, error) {
+	return DefaultRegistry.CaptureDateWithSidecar(fname)
+}
+
+// CaptureDateWithSource is CaptureDate, but also returns where the date was read from. Dates of files without a
+// capture date in their meta data are from SourceModTime.
+func CaptureDateWithSource(fname string) (time.Time, DateSource, error) {
+	return DefaultRegistry.CaptureDateWithSource(fname)
+}
+
+// CaptureDateWithSidecarAndSource is CaptureDateWithSidecar, but also returns where the date was read from.
+func CaptureDateWithSidecarAndSource(fname string) (time.Time, DateSource, error) {
+	return DefaultRegistry.CaptureDateWithSidecarAndSource(fname)
+}
+
+// CaptureDateFromReader returns the point in time the capturing device created the media read from r. The extractor
+// is chosen by the DefaultRegistry. There is no fallback if the media contains no capture date.
+func CaptureDateFromReader(r ReadSeekerAt) (time.Time, error) {
+	return DefaultRegistry.CaptureDateFromReader(r)
+}
+
+// exifOrXMPDate returns the capture date from the EXIF data and falls back to the XMP data.
+func exifOrXMPDate(r ReadSeekerAt, loc *time.Location) (time.Time, DateSource, error) {
+	tm, source, err := exifDate(r, loc)
 	if err != nil {
-		if fInfoErr == nil {
-			return fInfo.ModTime(), nil
+		if xmpTm, xmpErr := xmpDate(r, loc); xmpErr == nil {
+			return xmpTm, SourceXMP, nil
 		}
-		return time.Time{}, errors.Wrap(err, "failed to open or fstat file.")
+		return time.Time{}, SourceNone, err
 	}
-	x, err := exif.Decode(f)
-	//data, err := exif.Read(fname)
+	return tm, source, nil
+}
+
+// exifDate returns the capture date from the EXIF data of the given JPEG or TIFF file. Dates without a time zone are
+// in loc.
+func exifDate(r io.ReaderAt, loc *time.Location) (time.Time, DateSource, error) {
+	x, err := decodeExif(r)
 	if err != nil {
-		if fInfoErr == nil {
-			return fInfo.ModTime(), nil
-		}
-		return time.Time{}, errors.Wrap(err, noInfoFoundError)
+		return time.Time{}, SourceNone, errors.Wrap(err, "could not decode exif meta data")
 	}
-	tm, err := x.DateTime()
+	tm, source, err := exifDateTime(x, loc)
 	if err != nil {
-		if fInfoErr == nil {
-			return fInfo.ModTime(), nil
-		}
-		return time.Time{}, errors.Wrap(err, noInfoFoundError)
+		return time.Time{}, SourceNone, errors.Wrap(err, "no date in exif meta data")
+	}
+	if loc, err := captureLocation(r, x); err == nil {
+		tm = inLocation(tm, loc)
 	}
-	return tm, nil
+	return tm, source, nil
 }
@@ -15,13 +15,17 @@
 package extraction
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"os"
+	"path"
 	"testing"
 
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/xor-gate/goexif2/exif"
 )
 
 func TestCaptureDate(t *testing.T) {
@@ -43,9 +47,9 @@ func TestCaptureDate(t *testing.T) {
 			timeStamp:    parseTimeString(t, "2016-04-02 09:23:56 +0000 UTC"),
 		},
 		{
-			name:         "sample3.txt",
-			setTimestamp: true,
-			timeStamp:    parseTimeString(t, "2018-06-15 15:24:26.263360885 +0000 UTC"),
+			name:          "sample3.txt",
+			setTimestamp:  false,
+			expectedError: "%s is only 7 bytes: truncated file header",
 		},
 		{
 			name:          "sample-not-exist",
@@ -77,3 +81,376 @@ func parseTimeString(t *testing.T, ts string) time.Time {
 	}
 	return ti.UTC().Local()
 }
+
+func TestCaptureDateOffsetTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		exifTags  []tiffEntry
+		expected  string
+		expectUTC bool
+	}{
+		{
+			name: "offset time original",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				asciiEntry(offsetTimeOriginalTag, "+02:00"),
+			},
+			expected: "2019-04-17T13:30:44+02:00",
+		},
+		{
+			name: "negative offset time original",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				asciiEntry(offsetTimeTag, "+01:00"),
+				asciiEntry(offsetTimeOriginalTag, "-05:30"),
+			},
+			expected: "2019-04-17T13:30:44-05:30",
+		},
+		{
+			name: "offset time belongs to date time",
+			exifTags: []tiffEntry{
+				asciiEntry(offsetTimeTag, "+09:00"),
+			},
+			expected: "2019-04-17T13:30:44+09:00",
+		},
+		{
+			name: "nikon world time",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				nikonMakerNote(binary.LittleEndian, 540, false),
+			},
+			expected: "2019-04-17T13:30:44+09:00",
+		},
+		{
+			name: "nikon world time with daylight saving time",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				nikonMakerNote(binary.BigEndian, -300, true),
+			},
+			expected: "2019-04-17T13:30:44-04:00",
+		},
+		{
+			name: "offset time before maker note",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				asciiEntry(offsetTimeOriginalTag, "+02:00"),
+				nikonMakerNote(binary.LittleEndian, 540, false),
+			},
+			expected: "2019-04-17T13:30:44+02:00",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileUnderTest := writeJPEG(t, []tiffEntry{asciiEntry(tagDateTime, "2019:04:17 13:30:44")}, test.exifTags)
+			ts, err := CaptureDate(fileUnderTest)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ts.Format(time.RFC3339))
+		})
+	}
+}
+
+// nikonMakerNote returns a Nikon maker note containing only the WorldTime tag.
+func nikonMakerNote(order binary.AppendByteOrder, offsetMinutes int16, daylightSaving bool) tiffEntry {
+	worldTime := order.AppendUint16(nil, uint16(offsetMinutes))
+	if daylightSaving {
+		worldTime = append(worldTime, 1, 0)
+	} else {
+		worldTime = append(worldTime, 0, 0)
+	}
+	note := []byte("Nikon\x00\x02\x10\x00\x00")
+	if order == binary.LittleEndian {
+		note = append(note, "II*\x00"...)
+	} else {
+		note = append(note, "MM\x00*"...)
+	}
+	note = order.AppendUint32(note, 8)
+	note = order.AppendUint16(note, 1)
+	note = order.AppendUint16(note, 0x0024)
+	note = order.AppendUint16(note, tiffTypeUndefined)
+	note = order.AppendUint32(note, uint32(len(worldTime)))
+	note = append(note, worldTime...)
+	note = order.AppendUint32(note, 0)
+	return tiffEntry{id: tagMakerNote, typ: tiffTypeUndefined, count: uint32(len(note)), data: note}
+}
+
+func TestCaptureDateWithoutOffsetTime(t *testing.T) {
+	fileUnderTest := writeJPEG(t, nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")})
+	ts, err := CaptureDate(fileUnderTest)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, ts.Location())
+	assert.Equal(t, "20190417_133044", ts.Format("20060102_150405"))
+}
+
+func TestCaptureDateRegistryLocation(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	reg := NewDefaultRegistry()
+	reg.SetLocation(tokyo)
+	naive := writeJPEG(t, nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:30 23:30:44")})
+	ts, err := reg.CaptureDate(naive)
+	assert.NoError(t, err)
+	assert.Equal(t, "2019-04-30T23:30:44+09:00", ts.Format(time.RFC3339))
+
+	ts, err = CaptureDate(naive)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, ts.Location(), "the location of other registries is unchanged")
+
+	withOffset := writeJPEG(t, nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:30 23:30:44"), asciiEntry(offsetTimeOriginalTag, "+02:00")})
+	ts, err = reg.CaptureDate(withOffset)
+	assert.NoError(t, err)
+	assert.Equal(t, "2019-04-30T23:30:44+02:00", ts.Format(time.RFC3339), "the offset of the file takes precedence")
+
+	tm, found := reg.fileNameDate("IMG-20190417-WA0001.jpg")
+	assert.True(t, found)
+	assert.Equal(t, tokyo, tm.Location())
+}
+
+func TestCaptureDateLayouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifd0     []tiffEntry
+		exifTags []tiffEntry
+		expected string
+	}{
+		{
+			name:     "standard",
+			exifTags: []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")},
+			expected: "20190417_133044.000",
+		},
+		{
+			name:     "dashes",
+			exifTags: []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019-04-17 13:30:44")},
+			expected: "20190417_133044.000",
+		},
+		{
+			name:     "dashes in date time",
+			ifd0:     []tiffEntry{asciiEntry(tagDateTime, "2019-04-17 13:30:44")},
+			expected: "20190417_133044.000",
+		},
+		{
+			name: "sub seconds",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				asciiEntry(tagSubSecOriginal, "123"),
+			},
+			expected: "20190417_133044.123",
+		},
+		{
+			name: "short sub seconds",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019-04-17 13:30:44"),
+				asciiEntry(tagSubSecOriginal, "5 "),
+			},
+			expected: "20190417_133044.500",
+		},
+		{
+			name: "sub seconds of other field",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				asciiEntry(tagSubSecTime, "123"),
+			},
+			expected: "20190417_133044.000",
+		},
+		{
+			name: "sub seconds of date time",
+			ifd0: []tiffEntry{asciiEntry(tagDateTime, "2019:04:17 13:30:44")},
+			exifTags: []tiffEntry{
+				asciiEntry(tagSubSecTime, "42"),
+			},
+			expected: "20190417_133044.420",
+		},
+		{
+			name: "invalid sub seconds",
+			exifTags: []tiffEntry{
+				asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44"),
+				asciiEntry(tagSubSecOriginal, "1a"),
+			},
+			expected: "20190417_133044.000",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileUnderTest := writeJPEG(t, test.ifd0, test.exifTags)
+			ts, err := CaptureDate(fileUnderTest)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ts.Format("20060102_150405.000"))
+		})
+	}
+}
+
+func TestCaptureDateInvalidLayout(t *testing.T) {
+	x, err := decodeExif(bytes.NewReader(buildJPEG(buildTiff(nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "17.04.2019 13:30:44")}))))
+	assert.NoError(t, err)
+	_, _, err = exifDateTime(x, time.Local)
+	assert.ErrorContains(t, err, "invalid exif date '17.04.2019 13:30:44'")
+}
+
+func TestCaptureDateCorruptExifIFD(t *testing.T) {
+	tiff := buildTiff([]tiffEntry{asciiEntry(tagDateTime, "2019:04:17 13:30:44")}, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2018:01:01 00:00:00")})
+	// the value of the Exif IFD pointer, the second entry of IFD0, points beyond the end of the data
+	binary.LittleEndian.PutUint32(tiff[8+2+12+8:], 0xffff)
+	_, err := exif.Decode(bytes.NewReader(tiff))
+	if !assert.Error(t, err, "broken test setup") || !assert.False(t, exif.IsCriticalError(err), "broken test setup") {
+		return
+	}
+
+	tm, source, err := CaptureDateWithSource(writeTempFile(t, "sample.jpg", buildJPEG(tiff)))
+	assert.NoError(t, err)
+	assert.Equal(t, SourceExifDateTime, source)
+	assert.Equal(t, "2019-04-17T13:30:44", tm.Format("2006-01-02T15:04:05"))
+}
+
+func TestCaptureDateMatroska(t *testing.T) {
+	ts, err := CaptureDate(fixturePath("sample4.webm"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2019-04-17T13:30:44Z", ts.Format(time.RFC3339))
+
+	withoutDate, err := os.ReadFile(fixturePath("sample4.webm"))
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	// rename the DateUTC element to an unknown element
+	withoutDate = bytes.Replace(withoutDate, []byte{0x44, 0x61, 0x88}, []byte{0x44, 0x62, 0x88}, 1)
+	fileUnderTest := writeTempFile(t, "sample.webm", withoutDate)
+	modTime := parseTimeString(t, "2020-01-02 03:04:05 +0000 UTC")
+	assert.Nil(t, os.Chtimes(fileUnderTest, modTime, modTime))
+	ts, err = CaptureDate(fileUnderTest)
+	assert.NoError(t, err)
+	assert.True(t, modTime.Equal(ts), "expected: %v, got: %v", modTime, ts)
+}
+
+func TestCaptureDateExifAfterOtherAPP1(t *testing.T) {
+	jfif := jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	xmp := jpegSegment(jpegAPP1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta xmlns:x=\"adobe:ns:meta/\"></x:xmpmeta>"))
+	content := buildJPEG(buildTiff(nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")}), jfif, xmp)
+	fileUnderTest := writeTempFile(t, "sample.jpg", content)
+	modTime := parseTimeString(t, "2020-01-02 03:04:05 +0000 UTC")
+	assert.Nil(t, os.Chtimes(fileUnderTest, modTime, modTime))
+	ts, err := CaptureDate(fileUnderTest)
+	assert.NoError(t, err)
+	assert.Equal(t, "20190417_133044", ts.Format("20060102_150405"))
+}
+
+func TestCaptureDateFromReader(t *testing.T) {
+	content := buildJPEG(buildTiff(nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")}))
+	ts, err := CaptureDateFromReader(bytes.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, "20190417_133044", ts.Format("20060102_150405"))
+
+	webm, err := os.ReadFile(fixturePath("sample4.webm"))
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	ts, err = CaptureDateFromReader(bytes.NewReader(webm))
+	assert.NoError(t, err)
+	assert.Equal(t, "2019-04-17T13:30:44Z", ts.Format(time.RFC3339))
+
+	_, err = CaptureDateFromReader(bytes.NewReader([]byte("no media")))
+	assert.Error(t, err)
+}
+
+const (
+	tagDateTime         = 0x0132
+	tagExifIFDPointer   = 0x8769
+	tagDateTimeOriginal = 0x9003
+	tagSubSecTime       = 0x9290
+	tagSubSecOriginal   = 0x9291
+	tagMakerNote        = 0x927c
+	tiffTypeASCII       = 2
+	tiffTypeLong        = 4
+	tiffTypeUndefined   = 7
+)
+
+type tiffEntry struct {
+	id    uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func asciiEntry(id uint16, val string) tiffEntry {
+	data := append([]byte(val), 0)
+	return tiffEntry{id: id, typ: tiffTypeASCII, count: uint32(len(data)), data: data}
+}
+
+// buildTiff returns a little endian TIFF structure with the given IFD0 and exif sub IFD entries.
+func buildTiff(ifd0 []tiffEntry, exifIFD []tiffEntry) []byte {
+	ifd0 = append(append([]tiffEntry{}, ifd0...), tiffEntry{id: tagExifIFDPointer, typ: tiffTypeLong, count: 1})
+	ifd0Size := 2 + 12*len(ifd0) + 4
+	exifOffset := 8 + ifd0Size
+	exifSize := 2 + 12*len(exifIFD) + 4
+	dataOffset := exifOffset + exifSize
+
+	var buf, data bytes.Buffer
+	le := binary.LittleEndian
+	buf.WriteString("II*\x00")
+	_ = binary.Write(&buf, le, uint32(8))
+	writeIFD := func(entries []tiffEntry) {
+		_ = binary.Write(&buf, le, uint16(len(entries)))
+		for _, e := range entries {
+			if e.id == tagExifIFDPointer {
+				e.data = le.AppendUint32(nil, uint32(exifOffset))
+			}
+			_ = binary.Write(&buf, le, e.id)
+			_ = binary.Write(&buf, le, e.typ)
+			_ = binary.Write(&buf, le, e.count)
+			if len(e.data) > 4 {
+				_ = binary.Write(&buf, le, uint32(dataOffset+data.Len()))
+				data.Write(e.data)
+			} else {
+				buf.Write(append(e.data, make([]byte, 4-len(e.data))...))
+			}
+		}
+		_ = binary.Write(&buf, le, uint32(0))
+	}
+	writeIFD(ifd0)
+	writeIFD(exifIFD)
+	buf.Write(data.Bytes())
+	return buf.Bytes()
+}
+
+// jpegSegment returns a JPEG segment with the given marker and payload.
+func jpegSegment(marker byte, payload []byte) []byte {
+	ret := []byte{jpegMarker, marker}
+	ret = binary.BigEndian.AppendUint16(ret, uint16(len(payload)+2))
+	return append(ret, payload...)
+}
+
+// buildJPEG returns a minimal JPEG containing the given segments followed by the EXIF APP1 segment.
+func buildJPEG(tiffData []byte, leadingSegments ...[]byte) []byte {
+	ret := []byte{jpegMarker, jpegSOI}
+	for _, s := range leadingSegments {
+		ret = append(ret, s...)
+	}
+	ret = append(ret, jpegSegment(jpegAPP1, append(append([]byte{}, exifHeader...), tiffData...))...)
+	return append(ret, jpegMarker, jpegEOI)
+}
+
+func writeJPEG(t *testing.T, ifd0 []tiffEntry, exifIFD []tiffEntry) string {
+	return writeTempFile(t, "sample.jpg", buildJPEG(buildTiff(ifd0, exifIFD)))
+}
+
+func writeTempFile(t *testing.T, name string, content []byte) string {
+	fname := path.Join(t.TempDir(), name)
+	if err := os.WriteFile(fname, content, 0644); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	return fname
+}
+
+func TestCaptureDateDNG(t *testing.T) {
+	fileUnderTest := fixturePath("sample5.dng")
+	isImage, err := IsImage(fileUnderTest)
+	assert.NoError(t, err)
+	assert.True(t, isImage)
+	f, err := os.Open(fileUnderTest)
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	defer f.Close()
+	ts, err := CaptureDateFromReader(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "20190417_133044", ts.Format("20060102_150405"))
+	model, err := Tag(fileUnderTest, "Model")
+	assert.NoError(t, err)
+	assert.Equal(t, "Canon-EOS-5D", model)
+}
@@ -0,0 +1,111 @@
+package extraction
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultFileNamePatterns are the file name patterns of DefaultRegistry. They match the names of common Android and
+// WhatsApp files, e.g. IMG-20190417-WA0001.jpg, IMG_20190417_133044.jpg and Screenshot_20190417-133044.png.
+var DefaultFileNamePatterns = []string{
+	`^(?:IMG|VID|AUD|PTT|DOC|STK)-(?P<year>\d{4})(?P<month>\d{2})(?P<day>\d{2})-WA\d+`,
+	`^(?:IMG|VID|PXL|MVIMG|PANO|Screenshot|Screenrecorder)_(?P<year>\d{4})(?P<month>\d{2})(?P<day>\d{2})[_-](?P<hour>\d{2})(?P<minute>\d{2})(?P<second>\d{2})`,
+	`^Screenshot_(?P<year>\d{4})-(?P<month>\d{2})-(?P<day>\d{2})-(?P<hour>\d{2})-(?P<minute>\d{2})-(?P<second>\d{2})`,
+}
+
+// fileNameGroups are the named groups of a file name pattern. The first three are required.
+var fileNameGroups = []string{"year", "month", "day", "hour", "minute", "second"}
+
+// FileNamePattern reads a date from file names.
+type FileNamePattern struct {
+	re *regexp.Regexp
+	// groups are the indices of the submatches in the order of fileNameGroups, -1 if missing
+	groups []int
+}
+
+// NewFileNamePattern compiles the regular expression expr. It is matched against the base name of files and must have
+// the named groups year, month and day. The named groups hour, minute and second are optional, e.g.
+// `^IMG-(?P<year>\d{4})(?P<month>\d{2})(?P<day>\d{2})-WA\d+`.
+func NewFileNamePattern(expr string) (*FileNamePattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name pattern: %w", err)
+	}
+	p := &FileNamePattern{re: re}
+	for i, name := range fileNameGroups {
+		idx := re.SubexpIndex(name)
+		if idx < 0 && i < 3 {
+			return nil, fmt.Errorf("file name pattern '%s' has no group named %s", expr, name)
+		}
+		p.groups = append(p.groups, idx)
+	}
+	return p, nil
+}
+
+// Date returns the date in the base name of fname in the local time zone. It returns false if the name doesn't match or
+// isn't a valid date. Registries read it in their location, see Registry.SetLocation.
+func (p *FileNamePattern) Date(fname string) (time.Time, bool) {
+	return p.dateIn(fname, time.Local)
+}
+
+// dateIn is Date in the given location.
+func (p *FileNamePattern) dateIn(fname string, loc *time.Location) (time.Time, bool) {
+	match := p.re.FindStringSubmatch(filepath.Base(fname))
+	if match == nil {
+		return time.Time{}, false
+	}
+	values := make([]int, len(p.groups))
+	for i, idx := range p.groups {
+		if idx < 0 || match[idx] == "" {
+			continue
+		}
+		v, err := strconv.Atoi(match[idx])
+		if err != nil {
+			return time.Time{}, false
+		}
+		values[i] = v
+	}
+	tm := time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, loc)
+	// time.Date normalizes out of range values, e.g. the 13th month
+	if tm.Year() != values[0] || int(tm.Month()) != values[1] || tm.Day() != values[2] || tm.Hour() != values[3] ||
+		tm.Minute() != values[4] || tm.Second() != values[5] {
+		return time.Time{}, false
+	}
+	return tm, true
+}
+
+// AddFileNamePatterns adds patterns to read the capture date from the file name. They are tried in order if there is
+// no date in the meta data of a file, before falling back to the modification time.
+func (reg *Registry) AddFileNamePatterns(patterns ...*FileNamePattern) {
+	reg.mtx.Lock()
+	defer reg.mtx.Unlock()
+	reg.fileNamePatterns = append(reg.fileNamePatterns, patterns...)
+}
+
+// fileNameDate returns the date of the first file name pattern matching fname.
+func (reg *Registry) fileNameDate(fname string) (time.Time, bool) {
+	reg.mtx.RLock()
+	defer reg.mtx.RUnlock()
+	for _, p := range reg.fileNamePatterns {
+		if tm, ok := p.dateIn(fname, reg.location); ok {
+			return tm, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// mustFileNamePatterns compiles the given patterns and panics if one is invalid.
+func mustFileNamePatterns(exprs ...string) []*FileNamePattern {
+	ret := make([]*FileNamePattern, 0, len(exprs))
+	for _, expr := range exprs {
+		p, err := NewFileNamePattern(expr)
+		if err != nil {
+			panic(err)
+		}
+		ret = append(ret, p)
+	}
+	return ret
+}
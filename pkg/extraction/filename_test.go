@@ -0,0 +1,50 @@
+package extraction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultFileNamePatterns(t *testing.T) {
+	tests := []struct {
+		fname    string
+		expected time.Time
+		found    bool
+	}{
+		{fname: "/sdcard/WhatsApp/Media/IMG-20190417-WA0001.jpg", expected: time.Date(2019, 4, 17, 0, 0, 0, 0, time.Local), found: true},
+		{fname: "VID-20190417-WA0012.mp4", expected: time.Date(2019, 4, 17, 0, 0, 0, 0, time.Local), found: true},
+		{fname: "IMG_20190417_133044.jpg", expected: time.Date(2019, 4, 17, 13, 30, 44, 0, time.Local), found: true},
+		{fname: "PXL_20190417_133044123.jpg", expected: time.Date(2019, 4, 17, 13, 30, 44, 0, time.Local), found: true},
+		{fname: "Screenshot_20190417-133044.png", expected: time.Date(2019, 4, 17, 13, 30, 44, 0, time.Local), found: true},
+		{fname: "Screenshot_2019-04-17-13-30-44.png", expected: time.Date(2019, 4, 17, 13, 30, 44, 0, time.Local), found: true},
+		{fname: "IMG-20191317-WA0001.jpg"},
+		{fname: "IMG_20190417_253044.jpg"},
+		{fname: "IMG_0001.jpg"},
+		{fname: "IMG-20190417-WA0001/sample.jpg"},
+	}
+	reg := NewRegistry(nil)
+	reg.AddFileNamePatterns(mustFileNamePatterns(DefaultFileNamePatterns...)...)
+	for _, test := range tests {
+		t.Run(test.fname, func(t *testing.T) {
+			tm, found := reg.fileNameDate(test.fname)
+			assert.Equal(t, test.found, found)
+			assert.True(t, test.expected.Equal(tm), "expected %v, got %v", test.expected, tm)
+		})
+	}
+}
+
+func TestNewFileNamePattern(t *testing.T) {
+	p, err := NewFileNamePattern(`^(?P<day>\d{2})\.(?P<month>\d{2})\.(?P<year>\d{4})`)
+	if assert.NoError(t, err) {
+		tm, found := p.Date("17.04.2019 Urlaub.jpg")
+		assert.True(t, found)
+		assert.True(t, time.Date(2019, 4, 17, 0, 0, 0, 0, time.Local).Equal(tm))
+	}
+
+	_, err = NewFileNamePattern(`^(?P<year>\d{4})(?P<month>\d{2})`)
+	assert.ErrorContains(t, err, "no group named day")
+	_, err = NewFileNamePattern(`^(?P<year>\d{4}`)
+	assert.Error(t, err)
+}
@@ -15,26 +15,121 @@
 package extraction
 
 import (
+	"io"
 	"os"
 
 	"github.com/h2non/filetype"
 	"github.com/pkg/errors"
 )
 
-// IsVideoOrImage return true if the given file is a video or an image
-func IsVideoOrImage(fname string) (bool, error) {
-	// Open a file descriptor
+// headerSize is the number of bytes needed to detect the file type
+const headerSize = 261
+
+// ErrTruncatedHeader is returned if a file is too short to detect its file type, e.g. an empty placeholder file.
+var ErrTruncatedHeader = errors.New("truncated file header")
+
+// MediaKind is the kind of media detected from the header of a file.
+type MediaKind int
+
+const (
+	// KindOther is any file which is neither an image nor a video
+	KindOther MediaKind = iota
+	// KindImage is an image
+	KindImage
+	// KindVideo is a video
+	KindVideo
+)
+
+// FileClass describes the file type detected from the header of a file.
+type FileClass struct {
+	Kind MediaKind
+	// MIME is the MIME type of the file, e.g. image/jpeg. It is empty if the file type is unknown.
+	MIME string
+	// Extension is the file type as used by Registry.Register, e.g. jpg. It is empty if the file type is unknown.
+	Extension string
+}
+
+// IsMedia returns true if the file is an image or a video.
+func (c FileClass) IsMedia() bool {
+	return c.Kind == KindImage || c.Kind == KindVideo
+}
+
+// Classify detects the file type of the given file. The file is only opened once.
+func Classify(fname string) (FileClass, error) {
 	file, err := os.Open(fname)
 	if err != nil {
-		return false, errors.Wrap(err, "could not open file to determine file type")
+		return FileClass{}, errors.Wrap(err, "could not open file to determine file type")
 	}
 	defer file.Close()
+	return ClassifyReader(file)
+}
 
-	// We only have to pass the file header = first 261 bytes
-	head := make([]byte, 261)
-	_, err = file.Read(head)
+// ClassifyReader detects the file type of the media read from r.
+func ClassifyReader(r io.Reader) (FileClass, error) {
+	head, err := readFileHeader(r)
 	if err != nil {
-		return false, errors.Wrap(err, "could not read file header to determine file type")
+		return FileClass{}, err
+	}
+	kind, err := filetype.Match(head)
+	if err != nil || kind == filetype.Unknown {
+		return FileClass{}, nil
+	}
+	c := FileClass{MIME: kind.MIME.Value, Extension: kind.Extension}
+	switch {
+	case filetype.IsImage(head) || kind == avifType:
+		c.Kind = KindImage
+	case filetype.IsVideo(head):
+		c.Kind = KindVideo
+	}
+	return c, nil
+}
+
+// IsVideoOrImage return true if the given file is a video or an image
+func IsVideoOrImage(fname string) (bool, error) {
+	c, err := Classify(fname)
+	return c.IsMedia(), err
+}
+
+// IsVideoOrImageFromReader return true if the media read from r is a video or an image
+func IsVideoOrImageFromReader(r io.Reader) (bool, error) {
+	c, err := ClassifyReader(r)
+	return c.IsMedia(), err
+}
+
+// IsImage return true if the given file is an image
+func IsImage(fname string) (bool, error) {
+	c, err := Classify(fname)
+	return c.Kind == KindImage, err
+}
+
+// readFileHeader returns the header of the media read from r. A header shorter than headerSize is only returned if its
+// file type is known, otherwise ErrTruncatedHeader is returned.
+func readFileHeader(r io.Reader) ([]byte, error) {
+	head := make([]byte, headerSize)
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if kind, _ := filetype.Match(head[:n]); kind == filetype.Unknown {
+			return nil, errors.Wrapf(ErrTruncatedHeader, "read %d of %d bytes", n, headerSize)
+		}
+		return head[:n], nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read file header to determine file type")
+	}
+	return head, nil
+}
+
+// fileType returns the extension of the file type detected from the header of r or an empty string if the type is
+// unknown. The read position of r is not changed.
+func fileType(r io.ReaderAt) string {
+	head := make([]byte, headerSize)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	kind, err := filetype.Match(head[:n])
+	if err != nil || kind == filetype.Unknown {
+		return ""
 	}
-	return filetype.IsImage(head) || filetype.IsVideo(head), nil
+	return kind.Extension
 }
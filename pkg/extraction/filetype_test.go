@@ -15,6 +15,7 @@
 package extraction
 
 import (
+	"bytes"
 	"fmt"
 	"path"
 	"runtime"
@@ -43,6 +44,10 @@ func TestIsVideoOrImage(t *testing.T) {
 			name:        "sample3.txt",
 			fileOrVideo: false,
 		},
+		{
+			name:        "sample4.webm",
+			fileOrVideo: true,
+		},
 		{
 			name:          "sample-not-exist",
 			fileOrVideo:   false,
@@ -61,6 +66,48 @@ func TestIsVideoOrImage(t *testing.T) {
 	}
 }
 
+func TestIsVideoOrImageFromReader(t *testing.T) {
+	for name, expected := range map[string]bool{"sample1.JPG": true, "sample4.webm": true} {
+		content, err := os.ReadFile(fixturePath(name))
+		if err != nil {
+			t.Fatalf("broken test setup: %s", err.Error())
+		}
+		is, err := IsVideoOrImageFromReader(bytes.NewReader(content))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, is, name)
+	}
+	is, err := IsVideoOrImageFromReader(bytes.NewReader(bytes.Repeat([]byte("no media\n"), 32)))
+	assert.NoError(t, err)
+	assert.False(t, is)
+	for _, truncated := range []string{"", "short text"} {
+		_, err := IsVideoOrImageFromReader(bytes.NewReader([]byte(truncated)))
+		assert.ErrorIs(t, err, ErrTruncatedHeader)
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	for name, expected := range map[string]bool{"sample1.JPG": true, "sample2.mp4": false, "sample4.webm": false} {
+		is, err := IsImage(fixturePath(name))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, is, name)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for name, expected := range map[string]FileClass{
+		"sample1.JPG":  {Kind: KindImage, MIME: "image/jpeg", Extension: "jpg"},
+		"sample2.mp4":  {Kind: KindVideo, MIME: "video/mp4", Extension: "mp4"},
+		"sample4.webm": {Kind: KindVideo, MIME: "video/webm", Extension: "webm"},
+	} {
+		c, err := Classify(fixturePath(name))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, c, name)
+	}
+	c, err := ClassifyReader(bytes.NewReader(bytes.Repeat([]byte("no media\n"), 32)))
+	assert.NoError(t, err)
+	assert.Equal(t, FileClass{Kind: KindOther}, c)
+}
+
 func errorMessageNotFoundByOS() string {
 	switch runtime.GOOS {
 	case "linux":
@@ -0,0 +1,262 @@
+package extraction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/h2non/filetype"
+	"github.com/pkg/errors"
+)
+
+// heifMaxBoxLength limits the boxes read into memory
+const heifMaxBoxLength = 16 << 20
+
+// avifType is the AVIF image format, which github.com/h2non/filetype doesn't know
+var avifType = filetype.NewType("avif", "image/avif")
+
+func init() {
+	filetype.AddMatcher(avifType, isAVIF)
+}
+
+// isAVIF returns true if the header is the ftyp box of an AVIF image.
+func isAVIF(head []byte) bool {
+	if len(head) < 16 || string(head[4:8]) != "ftyp" {
+		return false
+	}
+	length := min(int(binary.BigEndian.Uint32(head[:4])), len(head))
+	if string(head[8:12]) == "avif" || string(head[8:12]) == "avis" {
+		return true
+	}
+	// compatible brands
+	for i := 16; i+4 <= length; i += 4 {
+		if string(head[i:i+4]) == "avif" {
+			return true
+		}
+	}
+	return false
+}
+
+// heifDate returns the capture date from the Exif item of the given HEIF or AVIF file.
+func heifDate(r ReadSeekerAt, loc *time.Location) (time.Time, DateSource, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return time.Time{}, SourceNone, errors.Wrap(err, "could not determine file size")
+	}
+	exifData, err := heifExif(r, size)
+	if err != nil {
+		return time.Time{}, SourceNone, err
+	}
+	return exifDate(bytes.NewReader(exifData), loc)
+}
+
+// isoBox is a box of the ISO base media file format.
+type isoBox struct {
+	typ string
+	// offset is the offset of the content after the header
+	offset int64
+	length int64
+}
+
+// readISOBoxes returns the boxes in the given range of r.
+func readISOBoxes(r io.ReaderAt, offset, end int64) ([]isoBox, error) {
+	var ret []isoBox
+	header := make([]byte, 16)
+	for offset+8 <= end {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, errors.Wrap(err, "could not read box header")
+		}
+		length, headerLength := int64(binary.BigEndian.Uint32(header[:4])), int64(8)
+		switch length {
+		case 0:
+			// the box extends to the end
+			length = end - offset
+		case 1:
+			if _, err := r.ReadAt(header[8:], offset+8); err != nil {
+				return nil, errors.Wrap(err, "could not read box size")
+			}
+			length, headerLength = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if length < headerLength || offset+length > end {
+			return nil, errors.Errorf("invalid size of box %q", header[4:8])
+		}
+		ret = append(ret, isoBox{typ: string(header[4:8]), offset: offset + headerLength, length: length - headerLength})
+		offset += length
+	}
+	return ret, nil
+}
+
+// heifExif returns the TIFF encoded EXIF data of the Exif item of the given HEIF or AVIF file of the given size.
+func heifExif(r io.ReaderAt, size int64) ([]byte, error) {
+	boxes, err := readISOBoxes(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+	var meta []byte
+	for _, b := range boxes {
+		if b.typ == "meta" && b.length <= heifMaxBoxLength {
+			meta = make([]byte, b.length)
+			if _, err := r.ReadAt(meta, b.offset); err != nil {
+				return nil, errors.Wrap(err, "could not read meta box")
+			}
+		}
+	}
+	if len(meta) < 4 {
+		return nil, errors.New("no meta box found")
+	}
+	// the meta box is a full box with version and flags
+	metaReader := bytes.NewReader(meta)
+	children, err := readISOBoxes(metaReader, 4, int64(len(meta)))
+	if err != nil {
+		return nil, err
+	}
+	var itemID uint32
+	var locations map[uint32][]heifExtent
+	for _, c := range children {
+		content := meta[c.offset : c.offset+c.length]
+		switch c.typ {
+		case "iinf":
+			itemID, err = heifExifItem(content)
+		case "iloc":
+			locations, err = heifItemLocations(content)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	extents, found := locations[itemID]
+	if itemID == 0 || !found {
+		return nil, errors.New("no exif item found")
+	}
+	var data []byte
+	for _, e := range extents {
+		if e.length > heifMaxBoxLength || int64(len(data))+e.length > heifMaxBoxLength {
+			return nil, errors.New("exif item too large")
+		}
+		extent := make([]byte, e.length)
+		if _, err := r.ReadAt(extent, e.offset); err != nil {
+			return nil, errors.Wrap(err, "could not read exif item")
+		}
+		data = append(data, extent...)
+	}
+	// the item starts with the offset of the TIFF header
+	if len(data) < 4 {
+		return nil, errors.New("exif item too short")
+	}
+	start := 4 + int64(binary.BigEndian.Uint32(data[:4]))
+	if start > int64(len(data)) {
+		return nil, errors.New("invalid tiff header offset in exif item")
+	}
+	return data[start:], nil
+}
+
+// heifExifItem returns the ID of the Exif item listed in the content of an iinf box. It is 0 if there is no Exif
+// item.
+func heifExifItem(iinf []byte) (uint32, error) {
+	if len(iinf) < 6 {
+		return 0, errors.New("iinf box too short")
+	}
+	offset := int64(6)
+	if iinf[0] > 0 {
+		offset = 8
+	}
+	entries, err := readISOBoxes(bytes.NewReader(iinf), offset, int64(len(iinf)))
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		infe := iinf[e.offset : e.offset+e.length]
+		// item types are only present since version 2
+		if e.typ != "infe" || len(infe) < 4 || infe[0] < 2 {
+			continue
+		}
+		var id uint32
+		var typ []byte
+		if infe[0] == 2 && len(infe) >= 12 {
+			id, typ = uint32(binary.BigEndian.Uint16(infe[4:6])), infe[8:12]
+		} else if infe[0] == 3 && len(infe) >= 14 {
+			id, typ = binary.BigEndian.Uint32(infe[4:8]), infe[10:14]
+		}
+		if string(typ) == "Exif" {
+			return id, nil
+		}
+	}
+	return 0, nil
+}
+
+// heifExtent is a range of the file holding item data.
+type heifExtent struct {
+	offset int64
+	length int64
+}
+
+// heifItemLocations returns the file ranges of the items listed in the content of an iloc box. Items stored in other
+// ways than by file offset are left out.
+func heifItemLocations(iloc []byte) (map[uint32][]heifExtent, error) {
+	r := &byteReader{data: iloc}
+	version := r.uint(1)
+	r.uint(3)
+	sizes := r.uint(1)
+	offsetSize, lengthSize := int(sizes>>4), int(sizes&0x0F)
+	sizes = r.uint(1)
+	baseOffsetSize, indexSize := int(sizes>>4), int(sizes&0x0F)
+	if version != 1 && version != 2 {
+		indexSize = 0
+	}
+	itemCount := r.uint(2)
+	if version == 2 {
+		itemCount = r.uint(4)
+	}
+	ret := make(map[uint32][]heifExtent)
+	for i := uint64(0); i < itemCount && r.err == nil; i++ {
+		var id, constructionMethod uint64
+		if version < 2 {
+			id = r.uint(2)
+		} else {
+			id = r.uint(4)
+		}
+		if version == 1 || version == 2 {
+			constructionMethod = r.uint(2) & 0x0F
+		}
+		r.uint(2)
+		baseOffset := r.uint(baseOffsetSize)
+		extentCount := r.uint(2)
+		var extents []heifExtent
+		for j := uint64(0); j < extentCount && r.err == nil; j++ {
+			r.uint(indexSize)
+			offset := r.uint(offsetSize)
+			length := r.uint(lengthSize)
+			extents = append(extents, heifExtent{offset: int64(baseOffset + offset), length: int64(length)})
+		}
+		if constructionMethod == 0 {
+			ret[uint32(id)] = extents
+		}
+	}
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid iloc box")
+	}
+	return ret, nil
+}
+
+// byteReader reads big endian integers of any size. After the first error all reads return 0.
+type byteReader struct {
+	data []byte
+	err  error
+}
+
+func (b *byteReader) uint(size int) uint64 {
+	if b.err != nil {
+		return 0
+	}
+	if size > 8 || len(b.data) < size {
+		b.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	var ret uint64
+	for _, c := range b.data[:size] {
+		ret = ret<<8 | uint64(c)
+	}
+	b.data = b.data[size:]
+	return ret
+}
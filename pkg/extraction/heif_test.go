@@ -0,0 +1,63 @@
+package extraction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureDateHEIF(t *testing.T) {
+	tiffData := buildTiff(nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")})
+	tests := []struct {
+		name          string
+		brands        []string
+		itemType      string
+		expectedType  string
+		expectedError string
+	}{
+		{name: "avif", brands: []string{"avif", "mif1", "avif"}, itemType: "Exif", expectedType: "avif"},
+		{name: "avif compatible brand", brands: []string{"mif1", "mif1", "avif"}, itemType: "Exif", expectedType: "avif"},
+		{name: "heic", brands: []string{"heic", "mif1", "heic"}, itemType: "Exif", expectedType: "heif"},
+		{name: "no exif item", brands: []string{"avif", "mif1", "avif"}, itemType: "mime", expectedType: "avif", expectedError: "no exif item found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bytes.NewReader(buildHEIF(test.brands, test.itemType, tiffData))
+			assert.Equal(t, test.expectedType, fileType(r))
+			c, err := ClassifyReader(r)
+			assert.NoError(t, err)
+			assert.Equal(t, KindImage, c.Kind)
+			tm, source, err := DefaultRegistry.captureDateFromReader(r)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "2019-04-17T13:30:44", tm.Format("2006-01-02T15:04:05"))
+			assert.Equal(t, SourceExifDateTimeOriginal, source)
+		})
+	}
+}
+
+func isoBoxBytes(typ string, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	return append(binary.BigEndian.AppendUint32(nil, uint32(8+len(body))), append([]byte(typ), body...)...)
+}
+
+// buildHEIF returns a HEIF file with a single item of the given type. The item holds the EXIF data.
+func buildHEIF(brands []string, itemType string, tiffData []byte) []byte {
+	// major brand, minor version and compatible brands
+	ftyp := isoBoxBytes("ftyp", []byte(brands[0]), []byte{0, 0, 0, 0}, []byte(brands[1]+brands[2]))
+	infe := isoBoxBytes("infe", []byte{2, 0, 0, 0}, []byte{0, 1, 0, 0}, []byte(itemType), []byte{0})
+	iinf := isoBoxBytes("iinf", []byte{0, 0, 0, 0}, []byte{0, 1}, infe)
+	// the offset is patched below
+	iloc := isoBoxBytes("iloc", []byte{0, 0, 0, 0}, []byte{0x44, 0x00}, []byte{0, 1}, []byte{0, 1}, []byte{0, 0}, []byte{0, 1}, make([]byte, 8))
+	meta := isoBoxBytes("meta", []byte{0, 0, 0, 0}, isoBoxBytes("hdlr", make([]byte, 25)), iinf, iloc)
+	item := append(binary.BigEndian.AppendUint32(nil, 6), append([]byte("Exif\x00\x00"), tiffData...)...)
+	offset := len(ftyp) + len(meta) + 8
+	binary.BigEndian.PutUint32(meta[len(meta)-8:], uint32(offset))
+	binary.BigEndian.PutUint32(meta[len(meta)-4:], uint32(len(item)))
+	return bytes.Join([][]byte{ftyp, meta, isoBoxBytes("mdat", item)}, nil)
+}
@@ -0,0 +1,72 @@
+package extraction
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xor-gate/goexif2/exif"
+	"github.com/xor-gate/goexif2/tiff"
+)
+
+// Field is an EXIF field and its value.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Inspection are all EXIF fields of a file and the values derived from them.
+type Inspection struct {
+	// Fields are all fields sorted by name
+	Fields []Field
+	// Derived are the capture date as used by CaptureDate, the GPS position and the flash mode. Values which can't be
+	// derived are left out.
+	Derived []Field
+}
+
+// Inspect decodes the EXIF data of the given JPEG or TIFF file for debugging.
+func Inspect(fname string) (_ Inspection, retErr error) {
+	defer recoverExif(&retErr)
+	f, err := os.Open(fname)
+	if err != nil {
+		return Inspection{}, errors.Wrap(err, "could not open file")
+	}
+	defer f.Close()
+	x, err := decodeExif(f)
+	if err != nil {
+		return Inspection{}, errors.Wrap(err, "could not decode exif meta data")
+	}
+	var ret Inspection
+	err = x.Walk(exif.WalkerFunc(func(name exif.FieldName, tag *tiff.Tag) error {
+		ret.Fields = append(ret.Fields, Field{Name: string(name), Value: tagValue(tag)})
+		return nil
+	}))
+	if err != nil {
+		return Inspection{}, errors.Wrap(err, "could not walk exif fields")
+	}
+	sort.Slice(ret.Fields, func(i, j int) bool {
+		return ret.Fields[i].Name < ret.Fields[j].Name
+	})
+	if tm, source, err := exifDate(f, DefaultRegistry.Location()); err == nil {
+		ret.Derived = append(ret.Derived, Field{Name: "CaptureDate", Value: fmt.Sprintf("%s (%s)", tm, source)})
+	}
+	if lat, long, err := x.LatLong(); err == nil {
+		ret.Derived = append(ret.Derived, Field{Name: "LatLong", Value: fmt.Sprintf("%f, %f", lat, long)})
+	}
+	if flash, err := x.Flash(); err == nil {
+		ret.Derived = append(ret.Derived, Field{Name: "Flash", Value: flash})
+	}
+	return ret, nil
+}
+
+// tagValue formats the value of the tag. Strings are printed without quotes and trailing NUL characters.
+func tagValue(tag *tiff.Tag) string {
+	if tag.Format() == tiff.StringVal {
+		if val, err := tag.StringVal(); err == nil {
+			return strings.TrimRight(val, "\x00")
+		}
+	}
+	return tag.String()
+}
@@ -0,0 +1,26 @@
+package extraction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	fname := writeJPEG(t, []tiffEntry{asciiEntry(0x0110, "Canon EOS 5D")}, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")})
+	inspection, err := Inspect(fname)
+	assert.NoError(t, err)
+	assert.Equal(t, []Field{
+		{Name: "DateTimeOriginal", Value: "2019:04:17 13:30:44"},
+		{Name: "ExifIFDPointer", Value: "38"},
+		{Name: "Model", Value: "Canon EOS 5D"},
+	}, inspection.Fields)
+	if assert.Len(t, inspection.Derived, 1) {
+		assert.Equal(t, "CaptureDate", inspection.Derived[0].Name)
+		assert.Contains(t, inspection.Derived[0].Value, "2019-04-17 13:30:44")
+		assert.Contains(t, inspection.Derived[0].Value, "(exif-datetime-original)")
+	}
+
+	_, err = Inspect(fixturePath("sample4.webm"))
+	assert.Error(t, err)
+}
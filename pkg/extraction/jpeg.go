@@ -0,0 +1,105 @@
+package extraction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/xor-gate/goexif2/exif"
+)
+
+const (
+	jpegMarker = 0xFF
+	jpegSOI    = 0xD8
+	jpegEOI    = 0xD9
+	jpegSOS    = 0xDA
+	jpegAPP1   = 0xE1
+)
+
+var (
+	exifHeader       = []byte("Exif\x00\x00")
+	tiffLittleEndian = []byte("II*\x00")
+	tiffBigEndian    = []byte("MM\x00*")
+)
+
+// exifSection returns a reader over the TIFF encoded EXIF data of the given JPEG or TIFF file.
+func exifSection(r io.ReaderAt) (*io.SectionReader, error) {
+	header := make([]byte, 4)
+	_, err := r.ReadAt(header, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read file header")
+	}
+	if bytes.Equal(header, tiffLittleEndian) || bytes.Equal(header, tiffBigEndian) {
+		return io.NewSectionReader(r, 0, math.MaxInt64), nil
+	}
+	if header[0] != jpegMarker || header[1] != jpegSOI {
+		return nil, errors.New("neither a jpeg nor a tiff file")
+	}
+	return jpegAPP1Section(r, 2, exifHeader)
+}
+
+// decodeExif decodes the EXIF data of the given JPEG or TIFF file. Unlike exif.Decode, APP1 segments which don't hold
+// EXIF data, e.g. XMP packets, are skipped. Errors in the sub-IFDs aren't critical, the fields decoded so far, e.g. of
+// IFD0, are returned without an error. goexif2 panics on some malformed data, e.g. a sub-IFD pointer without a value,
+// the panic is returned as an error.
+func decodeExif(r io.ReaderAt) (_ *exif.Exif, retErr error) {
+	defer recoverExif(&retErr)
+	section, err := exifSection(r)
+	if err != nil {
+		return nil, err
+	}
+	x, err := exif.Decode(section)
+	if err != nil && x != nil && !exif.IsCriticalError(err) {
+		return x, nil
+	}
+	return x, err
+}
+
+// recoverExif turns a panic of goexif2 into the error pointed to by err. It must be deferred.
+func recoverExif(err *error) {
+	if rec := recover(); rec != nil {
+		*err = errors.Errorf("catched panic while decoding exif meta data: %v", rec)
+	}
+}
+
+// jpegAPP1Section walks the JPEG segments starting at offset until it finds the APP1 segment starting with the given
+// intro. The returned section starts after the intro.
+func jpegAPP1Section(r io.ReaderAt, offset int64, intro []byte) (*io.SectionReader, error) {
+	segment := make([]byte, 4)
+	for {
+		_, err := r.ReadAt(segment, offset)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read jpeg segment")
+		}
+		if segment[0] != jpegMarker {
+			return nil, errors.Errorf("expected jpeg marker at offset %d", offset)
+		}
+		switch marker := segment[1]; {
+		case marker == jpegMarker:
+			// fill byte before the actual marker
+			offset++
+			continue
+		case marker == jpegSOS || marker == jpegEOI:
+			return nil, errors.Errorf("no app1 segment starting with %q found", intro)
+		case marker >= 0xD0 && marker <= 0xD7 || marker == 0x01:
+			// markers without a length
+			offset += 2
+			continue
+		}
+		length := int64(binary.BigEndian.Uint16(segment[2:]))
+		if segment[1] == jpegAPP1 && length >= int64(len(intro))+2 {
+			found := make([]byte, len(intro))
+			_, err = r.ReadAt(found, offset+4)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not read app1 segment")
+			}
+			if bytes.Equal(found, intro) {
+				start := offset + 4 + int64(len(intro))
+				return io.NewSectionReader(r, start, length-2-int64(len(intro))), nil
+			}
+		}
+		offset += 2 + length
+	}
+}
@@ -0,0 +1,138 @@
+package extraction
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Matroska element IDs, see https://www.matroska.org/technical/elements.html
+const (
+	ebmlHeaderID      = 0x1A45DFA3
+	ebmlSegmentID     = 0x18538067
+	ebmlInfoID        = 0x1549A966
+	ebmlDateUTCID     = 0x4461
+	ebmlClusterID     = 0x1F43B675
+	ebmlUnknownLength = -1
+)
+
+// matroskaEpoch is the reference point of the DateUTC element
+var matroskaEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// matroskaDate returns the DateUTC element of the segment info of a Matroska or WebM file.
+func matroskaDate(r ReadSeekerAt) (time.Time, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "could not seek to start")
+	}
+	id, size, err := readEBMLElementHeader(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if id != ebmlHeaderID || size == ebmlUnknownLength {
+		return time.Time{}, errors.New("not an EBML file")
+	}
+	if _, err = r.Seek(size, io.SeekCurrent); err != nil {
+		return time.Time{}, errors.Wrap(err, "could not skip EBML header")
+	}
+	id, _, err = readEBMLElementHeader(r)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if id != ebmlSegmentID {
+		return time.Time{}, errors.New("no matroska segment found")
+	}
+	// Segment children until the first cluster. The segment size is ignored as it may be unknown for live streams.
+	for {
+		id, size, err = readEBMLElementHeader(r)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if id == ebmlClusterID || size == ebmlUnknownLength {
+			return time.Time{}, errors.New("no DateUTC element found")
+		}
+		if id == ebmlInfoID {
+			return matroskaInfoDate(io.LimitReader(r, size))
+		}
+		if _, err = r.Seek(size, io.SeekCurrent); err != nil {
+			return time.Time{}, errors.Wrap(err, "could not skip segment element")
+		}
+	}
+}
+
+// matroskaInfoDate returns the DateUTC element from the children of a segment info element.
+func matroskaInfoDate(r io.Reader) (time.Time, error) {
+	for {
+		id, size, err := readEBMLElementHeader(r)
+		if err == io.EOF {
+			return time.Time{}, errors.New("no DateUTC element found")
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+		if size == ebmlUnknownLength {
+			return time.Time{}, errors.New("unknown size in segment info")
+		}
+		if id == ebmlDateUTCID {
+			if size != 8 {
+				return time.Time{}, errors.Errorf("invalid DateUTC size %d", size)
+			}
+			var nanos int64
+			err = binary.Read(r, binary.BigEndian, &nanos)
+			if err != nil {
+				return time.Time{}, errors.Wrap(err, "could not read DateUTC")
+			}
+			return matroskaEpoch.Add(time.Duration(nanos)), nil
+		}
+		if _, err = io.CopyN(io.Discard, r, size); err != nil {
+			return time.Time{}, errors.Wrap(err, "could not skip segment info element")
+		}
+	}
+}
+
+// readEBMLElementHeader reads the ID and the data size of an EBML element. The ID keeps its length marker bits.
+func readEBMLElementHeader(r io.Reader) (id int64, size int64, err error) {
+	id, _, err = readEBMLVint(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	_, size, err = readEBMLVint(r)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "could not read element size")
+	}
+	return id, size, nil
+}
+
+// readEBMLVint reads a variable length integer and returns it with and without the length marker bits. The value
+// without marker is ebmlUnknownLength if all its bits are set.
+func readEBMLVint(r io.Reader) (raw int64, value int64, err error) {
+	first := make([]byte, 1)
+	if _, err = io.ReadFull(r, first); err != nil {
+		return 0, 0, err
+	}
+	length := 1
+	for mask := byte(0x80); length <= 8 && first[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, 0, errors.New("invalid EBML variable length integer")
+	}
+	rest := make([]byte, length-1)
+	if _, err = io.ReadFull(r, rest); err != nil {
+		return 0, 0, errors.Wrap(err, "truncated EBML variable length integer")
+	}
+	raw = int64(first[0])
+	value = int64(first[0] & (0xFF >> length))
+	allOnes := value == int64(0xFF>>length)
+	for _, b := range rest {
+		raw = raw<<8 | int64(b)
+		value = value<<8 | int64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	if allOnes {
+		value = ebmlUnknownLength
+	}
+	return raw, value, nil
+}
@@ -0,0 +1,112 @@
+package extraction
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xor-gate/goexif2/exif"
+	"github.com/xor-gate/goexif2/mknote"
+	"github.com/xor-gate/goexif2/tiff"
+)
+
+// EXIF 2.31 tags holding the UTC offset of the corresponding date fields. goexif2 doesn't know them,
+// thus they are read from the raw exif sub IFD.
+const (
+	offsetTimeTag         = 0x9010
+	offsetTimeOriginalTag = 0x9011
+	offsetTimeLayout      = "-07:00"
+)
+
+// captureLocation returns the location recorded in the OffsetTimeOriginal or OffsetTime tag. The tag is chosen
+// according to the date field exif.DateTime uses.
+func captureLocation(r io.ReaderAt, x *exif.Exif) (*time.Location, error) {
+	field := uint16(offsetTimeOriginalTag)
+	if _, err := x.Get(exif.DateTimeOriginal); err != nil {
+		field = offsetTimeTag
+	}
+	return offsetForField(r, x, field)
+}
+
+// offsetForField reads the given offset tag from the exif sub IFD and parses it as fixed time zone.
+func offsetForField(r io.ReaderAt, x *exif.Exif, field uint16) (*time.Location, error) {
+	ptr, err := x.Get(exif.ExifIFDPointer)
+	if err != nil {
+		return nil, err
+	}
+	dirOffset, err := ptr.Int64(0)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid exif sub IFD pointer")
+	}
+	section, err := exifSection(r)
+	if err != nil {
+		return nil, err
+	}
+	_, err = section.Seek(dirOffset, io.SeekStart)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not seek to exif sub IFD")
+	}
+	dir, _, err := tiff.DecodeDir(section, x.Tiff.Order)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode exif sub IFD")
+	}
+	for _, tag := range dir.Tags {
+		if tag.Id != field {
+			continue
+		}
+		return parseOffset(tag)
+	}
+	return nil, errors.Errorf("offset tag 0x%04x not present", field)
+}
+
+// parseOffset parses the offset in the format "+02:00"
+func parseOffset(tag *tiff.Tag) (*time.Location, error) {
+	if tag.Format() != tiff.StringVal {
+		return nil, errors.New("offset tag not in string format")
+	}
+	val := strings.TrimSpace(strings.TrimRight(string(tag.Val), "\x00"))
+	t, err := time.Parse(offsetTimeLayout, val)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid offset '%s'", val)
+	}
+	_, offset := t.Zone()
+	return time.FixedZone("", offset), nil
+}
+
+// makerNoteTimeZone returns the time zone the camera was set to according to its maker note. Canon stores it in
+// TimeInfo, Nikon in WorldTime. Sony doesn't store it unencrypted, newer Sony cameras write OffsetTimeOriginal instead.
+func makerNoteTimeZone(x *exif.Exif) (*time.Location, error) {
+	if loc, err := x.TimeZone(); err == nil {
+		return loc, nil
+	}
+	return nikonTimeZone(x)
+}
+
+// nikonTimeZone parses the Nikon WorldTime tag: the UTC offset in minutes as signed 16 bit integer followed by a byte
+// which is 1 if daylight saving time was active.
+func nikonTimeZone(x *exif.Exif) (*time.Location, error) {
+	tag, err := x.Get(mknote.Nikon_WorldTime)
+	if err != nil {
+		return nil, err
+	}
+	if len(tag.Val) < 3 {
+		return nil, errors.New("Nikon.WorldTime too short")
+	}
+	// the byte order of the TIFF header of the maker note, which follows the "Nikon\0" intro and the version
+	var order binary.ByteOrder = binary.BigEndian
+	if note, err := x.Get(exif.MakerNote); err == nil && len(note.Val) >= 12 && string(note.Val[10:12]) == "II" {
+		order = binary.LittleEndian
+	}
+	offsetMinutes := int(int16(order.Uint16(tag.Val)))
+	if tag.Val[2] == 1 {
+		offsetMinutes += 60
+	}
+	return time.FixedZone("", offsetMinutes*60), nil
+}
+
+// inLocation returns the same wall clock time as t in the given location.
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
@@ -0,0 +1,142 @@
+package extraction
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/xor-gate/goexif2/exif"
+)
+
+const (
+	orientationTag = 0x0112
+	tiffTypeShort  = 3
+)
+
+// Transform is the rotation and flip which displays an image stored in an EXIF orientation upright.
+type Transform struct {
+	// Rotate is the clockwise rotation in degrees: 0, 90, 180 or 270
+	Rotate int
+	// Flip mirrors the image horizontally after rotating it
+	Flip bool
+}
+
+// orientationTransforms are the transforms of the orientations 1 to 8
+var orientationTransforms = [...]Transform{
+	{Rotate: 0}, {Rotate: 0, Flip: true}, {Rotate: 180}, {Rotate: 180, Flip: true},
+	{Rotate: 90, Flip: true}, {Rotate: 90}, {Rotate: 270, Flip: true}, {Rotate: 270},
+}
+
+// Orientation returns the EXIF orientation 1 to 8 of the given JPEG or TIFF file. 1 is upright, see
+// OrientationTransform for the others.
+func Orientation(fname string) (int, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not open file to read orientation")
+	}
+	defer f.Close()
+	x, err := decodeExif(f)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not decode exif meta data")
+	}
+	return exifOrientation(x)
+}
+
+// exifOrientation returns the orientation of the decoded EXIF data. goexif2 has no accessor for it.
+func exifOrientation(x *exif.Exif) (int, error) {
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 0, errors.Wrap(err, "orientation tag not present")
+	}
+	orientation, err := tag.Int(0)
+	if err != nil {
+		return 0, errors.Wrap(err, "orientation tag is not an integer")
+	}
+	if orientation < 1 || orientation > 8 {
+		return 0, errors.Errorf("invalid orientation %d", orientation)
+	}
+	return orientation, nil
+}
+
+// OrientationTransform returns the transform which displays an image stored in the given EXIF orientation upright.
+func OrientationTransform(orientation int) (Transform, error) {
+	if orientation < 1 || orientation > 8 {
+		return Transform{}, errors.Errorf("invalid orientation %d", orientation)
+	}
+	return orientationTransforms[orientation-1], nil
+}
+
+// SetOrientation rewrites the EXIF orientation tag of the given JPEG or TIFF file in place without re-encoding the
+// image. The tag must already be present in IFD0. It returns the previous orientation.
+func SetOrientation(fname string, orientation int) (int, error) {
+	if orientation < 1 || orientation > 8 {
+		return 0, errors.Errorf("invalid orientation %d", orientation)
+	}
+	f, err := os.OpenFile(fname, os.O_RDWR, 0)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not open file to set orientation")
+	}
+	defer f.Close()
+	section, err := exifSection(f)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not find exif data")
+	}
+	offset, order, err := orientationOffset(section)
+	if err != nil {
+		return 0, err
+	}
+	_, base, _ := section.Outer()
+	val := make([]byte, 2)
+	_, err = f.ReadAt(val, base+offset)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not read orientation")
+	}
+	previous := int(order.Uint16(val))
+	order.PutUint16(val, uint16(orientation))
+	_, err = f.WriteAt(val, base+offset)
+	if err != nil {
+		return previous, errors.Wrap(err, "could not write orientation")
+	}
+	return previous, f.Sync()
+}
+
+// orientationOffset returns the offset of the orientation value relative to the start of the TIFF structure.
+func orientationOffset(r io.ReaderAt) (int64, binary.ByteOrder, error) {
+	header := make([]byte, 8)
+	_, err := r.ReadAt(header, 0)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not read tiff header")
+	}
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, nil, errors.New("invalid tiff byte order")
+	}
+	ifd := int64(order.Uint32(header[4:]))
+	count := make([]byte, 2)
+	_, err = r.ReadAt(count, ifd)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "could not read IFD0 tag count")
+	}
+	entry := make([]byte, 12)
+	for i := int64(0); i < int64(order.Uint16(count)); i++ {
+		entryOffset := ifd + 2 + 12*i
+		_, err = r.ReadAt(entry, entryOffset)
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "could not read IFD0 entry")
+		}
+		if order.Uint16(entry) != orientationTag {
+			continue
+		}
+		if order.Uint16(entry[2:]) != tiffTypeShort || order.Uint32(entry[4:]) != 1 {
+			return 0, nil, errors.New("orientation tag is not a single short value")
+		}
+		return entryOffset + 8, order, nil
+	}
+	return 0, nil, errors.New("orientation tag not present")
+}
@@ -0,0 +1,97 @@
+package extraction
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xor-gate/goexif2/exif"
+)
+
+func TestSetOrientation(t *testing.T) {
+	orientation := tiffEntry{id: orientationTag, typ: tiffTypeShort, count: 1, data: binary.LittleEndian.AppendUint16(nil, 6)}
+	fileUnderTest := writeJPEG(t, []tiffEntry{orientation}, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")})
+
+	previous, err := SetOrientation(fileUnderTest, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, previous)
+	assert.Equal(t, 1, decodeOrientation(t, fileUnderTest))
+
+	previous, err = SetOrientation(fileUnderTest, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, previous)
+	assert.Equal(t, 6, decodeOrientation(t, fileUnderTest))
+
+	ts, err := CaptureDate(fileUnderTest)
+	assert.NoError(t, err)
+	assert.Equal(t, "20190417_133044", ts.Format("20060102_150405"))
+}
+
+func TestSetOrientationErrors(t *testing.T) {
+	withoutOrientation := writeJPEG(t, nil, nil)
+	_, err := SetOrientation(withoutOrientation, 1)
+	assert.EqualError(t, err, "orientation tag not present")
+
+	_, err = SetOrientation(withoutOrientation, 9)
+	assert.EqualError(t, err, "invalid orientation 9")
+
+	_, err = SetOrientation(fixturePath("sample3.txt"), 1)
+	assert.EqualError(t, err, "could not find exif data: neither a jpeg nor a tiff file")
+}
+
+func TestOrientation(t *testing.T) {
+	orientation := tiffEntry{id: orientationTag, typ: tiffTypeShort, count: 1, data: binary.LittleEndian.AppendUint16(nil, 6)}
+	o, err := Orientation(writeJPEG(t, []tiffEntry{orientation}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 6, o)
+
+	invalid := tiffEntry{id: orientationTag, typ: tiffTypeShort, count: 1, data: binary.LittleEndian.AppendUint16(nil, 9)}
+	_, err = Orientation(writeJPEG(t, []tiffEntry{invalid}, nil))
+	assert.EqualError(t, err, "invalid orientation 9")
+
+	_, err = Orientation(writeJPEG(t, nil, nil))
+	assert.Error(t, err)
+	_, err = Orientation(fixturePath("sample3.txt"))
+	assert.Error(t, err)
+}
+
+func TestOrientationTransform(t *testing.T) {
+	for orientation, want := range map[int]Transform{
+		1: {},
+		2: {Flip: true},
+		3: {Rotate: 180},
+		4: {Rotate: 180, Flip: true},
+		5: {Rotate: 90, Flip: true},
+		6: {Rotate: 90},
+		7: {Rotate: 270, Flip: true},
+		8: {Rotate: 270},
+	} {
+		got, err := OrientationTransform(orientation)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, orientation)
+	}
+	_, err := OrientationTransform(0)
+	assert.EqualError(t, err, "invalid orientation 0")
+}
+
+func decodeOrientation(t *testing.T, fname string) int {
+	f, err := os.Open(fname)
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	defer f.Close()
+	x, err := exif.Decode(f)
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	o, err := tag.Int(0)
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	return o
+}
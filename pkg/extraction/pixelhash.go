@@ -0,0 +1,77 @@
+package extraction
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"image"
+	"image/color"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// PixelHash returns a SHA-224 checksum of the decoded pixels of the given JPEG or PNG image turned upright by its EXIF
+// orientation. Unlike the checksum of the file it doesn't change if only the meta data is edited, e.g. the capture
+// date, so pixel identical copies can be found exactly. The size of the upright image and its pixels as 8 bit RGBA
+// are hashed row by row.
+func PixelHash(fname string) ([]byte, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open file to hash pixels")
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode image")
+	}
+	orientation := 1
+	if x, err := decodeExif(f); err == nil {
+		if o, err := exifOrientation(x); err == nil {
+			orientation = o
+		}
+	}
+	t, err := OrientationTransform(orientation)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New224()
+	hashPixels(h, img, t)
+	return h.Sum(nil), nil
+}
+
+// hashPixels writes the size and the RGBA pixels of the image after applying the transform to h.
+func hashPixels(h hash.Hash, img image.Image, t Transform) {
+	b := img.Bounds()
+	w, ht := b.Dx(), b.Dy()
+	if t.Rotate == 90 || t.Rotate == 270 {
+		w, ht = ht, w
+	}
+	h.Write(binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(w)), uint32(ht)))
+	row := make([]byte, 4*w)
+	for v := 0; v < ht; v++ {
+		for u := 0; u < w; u++ {
+			x, y := sourcePixel(u, v, w, ht, t)
+			c := color.RGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.RGBA)
+			row[4*u], row[4*u+1], row[4*u+2], row[4*u+3] = c.R, c.G, c.B, c.A
+		}
+		h.Write(row)
+	}
+}
+
+// sourcePixel returns the pixel of the stored image which is shown at u, v of the upright image of width w and height
+// h.
+func sourcePixel(u, v, w, h int, t Transform) (int, int) {
+	if t.Flip {
+		u = w - 1 - u
+	}
+	switch t.Rotate {
+	case 90:
+		return v, w - 1 - u
+	case 180:
+		return w - 1 - u, h - 1 - v
+	case 270:
+		return h - 1 - v, u
+	}
+	return u, v
+}
@@ -0,0 +1,80 @@
+package extraction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPixelHash(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for x := 0; x < 32; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	withExif := func(date string, orientation uint16) string {
+		ifd0 := []tiffEntry{{id: orientationTag, typ: tiffTypeShort, count: 1, data: binary.LittleEndian.AppendUint16(nil, orientation)}}
+		exifIFD := []tiffEntry{asciiEntry(tagDateTimeOriginal, date)}
+		// the encoded image follows the APP1 segment instead of the end of image marker
+		content := buildJPEG(buildTiff(ifd0, exifIFD))
+		return writeTempFile(t, "image.jpg", append(content[:len(content)-2], buf.Bytes()[2:]...))
+	}
+
+	original, err := PixelHash(withExif("2019:04:17 13:30:44", 1))
+	assert.NoError(t, err)
+	edited, err := PixelHash(withExif("2020:01:01 00:00:00", 1))
+	assert.NoError(t, err)
+	assert.Equal(t, original, edited, "meta data edits don't change the hash")
+	assert.Len(t, original, sha256.Size224)
+
+	rotated, err := PixelHash(withExif("2019:04:17 13:30:44", 6))
+	assert.NoError(t, err)
+	assert.NotEqual(t, original, rotated, "the image is turned upright before hashing")
+
+	_, err = PixelHash(fixturePath("sample3.txt"))
+	assert.EqualError(t, err, "could not decode image: image: unknown format")
+}
+
+func TestHashPixelsTransform(t *testing.T) {
+	// stored is the upright image 3x2 stored in each of the orientations 1 to 8
+	upright := [][]uint8{{1, 2, 3}, {4, 5, 6}}
+	stored := map[int][][]uint8{
+		1: {{1, 2, 3}, {4, 5, 6}},
+		2: {{3, 2, 1}, {6, 5, 4}},
+		3: {{6, 5, 4}, {3, 2, 1}},
+		4: {{4, 5, 6}, {1, 2, 3}},
+		5: {{1, 4}, {2, 5}, {3, 6}},
+		6: {{3, 6}, {2, 5}, {1, 4}},
+		7: {{6, 3}, {5, 2}, {4, 1}},
+		8: {{4, 1}, {5, 2}, {6, 3}},
+	}
+	want := sha256.New224()
+	hashPixels(want, grayImage(upright), Transform{})
+	for orientation, pixels := range stored {
+		tr, err := OrientationTransform(orientation)
+		assert.NoError(t, err)
+		got := sha256.New224()
+		hashPixels(got, grayImage(pixels), tr)
+		assert.Equal(t, want.Sum(nil), got.Sum(nil), "orientation %d", orientation)
+	}
+}
+
+// grayImage returns the image with the given gray values by row.
+func grayImage(rows [][]uint8) image.Image {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, v := range row {
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
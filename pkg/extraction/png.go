@@ -0,0 +1,126 @@
+package extraction
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	pngCreationTimeKeyword = "Creation Time"
+	// pngMaxChunkLength limits the chunks read into memory
+	pngMaxChunkLength = 16 << 20
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngCreationTimeLayouts are the layouts found in the Creation Time text. RFC 1123 is recommended by the PNG
+// specification, the others are written by common tools.
+var pngCreationTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"2006:01:02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// pngDate returns the capture date from the eXIf chunk of the given PNG file and falls back to the Creation Time
+// text. Only the chunks before the image data are read.
+func pngDate(r ReadSeekerAt, loc *time.Location) (time.Time, DateSource, error) {
+	exifData, creationTime, err := pngMetadata(r)
+	if err != nil {
+		return time.Time{}, SourceNone, err
+	}
+	if exifData != nil {
+		if tm, source, err := exifDate(bytes.NewReader(exifData), loc); err == nil {
+			return tm, source, nil
+		}
+	}
+	if creationTime == "" {
+		return time.Time{}, SourceNone, errors.New("no date in png meta data")
+	}
+	for _, layout := range pngCreationTimeLayouts {
+		if tm, err := time.ParseInLocation(layout, creationTime, loc); err == nil {
+			return tm, SourcePNGText, nil
+		}
+	}
+	return time.Time{}, SourceNone, errors.Errorf("invalid png creation time '%s'", creationTime)
+}
+
+// pngMetadata returns the content of the eXIf chunk and the Creation Time text of the given PNG file.
+func pngMetadata(r io.ReaderAt) (exifData []byte, creationTime string, err error) {
+	signature := make([]byte, len(pngSignature))
+	if _, err := r.ReadAt(signature, 0); err != nil {
+		return nil, "", errors.Wrap(err, "could not read png signature")
+	}
+	if !bytes.Equal(signature, pngSignature) {
+		return nil, "", errors.New("not a png file")
+	}
+	offset := int64(len(pngSignature))
+	header := make([]byte, 8)
+	for {
+		if _, err := r.ReadAt(header, offset); err != nil {
+			return nil, "", errors.Wrap(err, "could not read png chunk")
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		typ := string(header[4:])
+		if typ == "IDAT" || typ == "IEND" {
+			return exifData, creationTime, nil
+		}
+		if (typ == "eXIf" || typ == "tEXt" || typ == "iTXt") && length <= pngMaxChunkLength {
+			data := make([]byte, length)
+			if _, err := r.ReadAt(data, offset+8); err != nil {
+				return nil, "", errors.Wrapf(err, "could not read png %s chunk", typ)
+			}
+			switch typ {
+			case "eXIf":
+				exifData = data
+			case "tEXt":
+				if keyword, text, found := strings.Cut(string(data), "\x00"); found && keyword == pngCreationTimeKeyword {
+					creationTime = strings.TrimSpace(text)
+				}
+			case "iTXt":
+				if keyword, text, err := pngInternationalText(data); err == nil && keyword == pngCreationTimeKeyword {
+					creationTime = strings.TrimSpace(text)
+				}
+			}
+		}
+		// length, type, data and crc
+		offset += 12 + length
+	}
+}
+
+// pngInternationalText returns the keyword and the text of an iTXt chunk.
+func pngInternationalText(data []byte) (string, string, error) {
+	keyword, rest, found := bytes.Cut(data, []byte{0})
+	if !found || len(rest) < 2 {
+		return "", "", errors.New("invalid iTXt chunk")
+	}
+	compressed := rest[0] == 1
+	// skip the compression flag and method, the language tag and the translated keyword
+	rest = rest[2:]
+	for i := 0; i < 2; i++ {
+		if _, rest, found = bytes.Cut(rest, []byte{0}); !found {
+			return "", "", errors.New("invalid iTXt chunk")
+		}
+	}
+	if !compressed {
+		return string(keyword), string(rest), nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(rest))
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid compressed iTXt chunk")
+	}
+	defer zr.Close()
+	text, err := io.ReadAll(io.LimitReader(zr, pngMaxChunkLength))
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid compressed iTXt chunk")
+	}
+	return string(keyword), string(text), nil
+}
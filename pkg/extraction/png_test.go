@@ -0,0 +1,96 @@
+package extraction
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureDatePNG(t *testing.T) {
+	exifChunk := pngChunk("eXIf", buildTiff(nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")}))
+	tests := []struct {
+		name          string
+		chunks        [][]byte
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "exif chunk",
+			chunks:   [][]byte{exifChunk},
+			expected: "2019-04-17T13:30:44",
+		},
+		{
+			name:     "text creation time",
+			chunks:   [][]byte{pngChunk("tEXt", []byte("Creation Time\x00Wed, 17 Apr 2019 13:30:44 +0200"))},
+			expected: "2019-04-17T13:30:44",
+		},
+		{
+			name:     "international text creation time",
+			chunks:   [][]byte{pngChunk("iTXt", []byte("Creation Time\x00\x00\x00en\x00Erstellungszeit\x002019:04:17 13:30:44"))},
+			expected: "2019-04-17T13:30:44",
+		},
+		{
+			name:     "compressed international text creation time",
+			chunks:   [][]byte{pngChunk("iTXt", append([]byte("Creation Time\x00\x01\x00\x00\x00"), compress(t, "2019-04-17T13:30:44Z")...))},
+			expected: "2019-04-17T13:30:44",
+		},
+		{
+			name:     "exif is preferred",
+			chunks:   [][]byte{pngChunk("tEXt", []byte("Creation Time\x002020:01:01 00:00:00")), exifChunk},
+			expected: "2019-04-17T13:30:44",
+		},
+		{
+			name:          "chunks after image data are ignored",
+			chunks:        [][]byte{pngChunk("IDAT", []byte{0}), exifChunk},
+			expectedError: "no date in png meta data",
+		},
+		{
+			name:          "invalid creation time",
+			chunks:        [][]byte{pngChunk("tEXt", []byte("Creation Time\x00yesterday"))},
+			expectedError: "invalid png creation time 'yesterday'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tm, err := CaptureDateFromReader(bytes.NewReader(buildPNG(test.chunks...)))
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, tm.Format("2006-01-02T15:04:05"))
+		})
+	}
+}
+
+// pngChunk returns a PNG chunk with the given type and data.
+func pngChunk(typ string, data []byte) []byte {
+	ret := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	ret = append(ret, typ...)
+	ret = append(ret, data...)
+	return binary.BigEndian.AppendUint32(ret, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+// buildPNG returns a PNG with the given chunks between the header and the end chunk.
+func buildPNG(chunks ...[]byte) []byte {
+	ret := append([]byte{}, pngSignature...)
+	ret = append(ret, pngChunk("IHDR", []byte{0, 0, 0, 1, 0, 0, 0, 1, 8, 0, 0, 0, 0})...)
+	for _, c := range chunks {
+		ret = append(ret, c...)
+	}
+	return append(ret, pngChunk("IEND", nil)...)
+}
+
+func compress(t *testing.T, text string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, err := w.Write([]byte(text))
+	if err != nil || w.Close() != nil {
+		t.Fatalf("broken test setup: %v", err)
+	}
+	return buf.Bytes()
+}
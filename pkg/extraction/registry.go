@@ -0,0 +1,216 @@
+package extraction
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Extractor returns the capture date of the media read from r.
+type Extractor func(r ReadSeekerAt) (time.Time, error)
+
+// DefaultRegistry is the registry used by CaptureDate and CaptureDateFromReader.
+var DefaultRegistry = NewDefaultRegistry()
+
+// NewDefaultRegistry returns a new registry with the built-in extractors and DefaultFileNamePatterns, e.g. to extract
+// dates in another location than the DefaultRegistry.
+func NewDefaultRegistry() *Registry {
+	reg := newRegistry(exifOrXMPDate)
+	// DNG and most other RAW formats are detected as tif
+	reg.register(exifOrXMPDate, "jpg", "tif", "cr2")
+	reg.register(withSource(matroskaDate, SourceVideo), "webm", "mkv")
+	reg.register(pngDate, "png")
+	reg.register(webpDate, "webp")
+	reg.register(heifDate, "heif", "avif")
+	reg.AddFileNamePatterns(mustFileNamePatterns(DefaultFileNamePatterns...)...)
+	return reg
+}
+
+// Registry chooses the extractor for a media file by the file type detected from its header.
+type Registry struct {
+	mtx        sync.RWMutex
+	extractors map[string]sourcedExtractor
+	fallback   sourcedExtractor
+	// fileNamePatterns are tried before the modification time
+	fileNamePatterns []*FileNamePattern
+	// location is the location of dates without a time zone
+	location *time.Location
+}
+
+// NewRegistry returns an empty registry. The fallback is used for all file types without a registered extractor.
+func NewRegistry(fallback Extractor) *Registry {
+	return newRegistry(withSource(fallback, SourceExtractor))
+}
+
+func newRegistry(fallback sourcedExtractor) *Registry {
+	return &Registry{
+		extractors: make(map[string]sourcedExtractor),
+		fallback:   fallback,
+		location:   time.Local,
+	}
+}
+
+// SetLocation sets the location assumed by the built-in extractors for dates without a time zone, e.g. EXIF dates
+// without an OffsetTime tag or dates in file names, instead of the local time zone. Time zones recorded in the file
+// still take precedence.
+func (reg *Registry) SetLocation(loc *time.Location) {
+	reg.mtx.Lock()
+	defer reg.mtx.Unlock()
+	reg.location = loc
+}
+
+// Location returns the location of dates without a time zone, see SetLocation.
+func (reg *Registry) Location() *time.Location {
+	reg.mtx.RLock()
+	defer reg.mtx.RUnlock()
+	return reg.location
+}
+
+// Register sets the extractor for the given file types. The file types are the extensions reported by
+// github.com/h2non/filetype. A previously registered extractor is replaced. Its dates are from SourceExtractor.
+func (reg *Registry) Register(e Extractor, extensions ...string) {
+	reg.register(withSource(e, SourceExtractor), extensions...)
+}
+
+func (reg *Registry) register(e sourcedExtractor, extensions ...string) {
+	reg.mtx.Lock()
+	defer reg.mtx.Unlock()
+	for _, ext := range extensions {
+		reg.extractors[ext] = e
+	}
+}
+
+// Extractor returns the extractor for the file type of the media read from r.
+func (reg *Registry) Extractor(r io.ReaderAt) Extractor {
+	e, loc := reg.sourcedExtractor(r)
+	return e.extractor(loc)
+}
+
+// sourcedExtractor returns the extractor for the file type of the media read from r and the location to pass to it.
+func (reg *Registry) sourcedExtractor(r io.ReaderAt) (sourcedExtractor, *time.Location) {
+	reg.mtx.RLock()
+	defer reg.mtx.RUnlock()
+	if e, found := reg.extractors[fileType(r)]; found {
+		return e, reg.location
+	}
+	return reg.fallback, reg.location
+}
+
+// CaptureDateFromReader returns the capture date of the media read from r using the extractor for its file type.
+func (reg *Registry) CaptureDateFromReader(r ReadSeekerAt) (time.Time, error) {
+	tm, _, err := reg.captureDateFromReader(r)
+	return tm, err
+}
+
+func (reg *Registry) captureDateFromReader(r ReadSeekerAt) (retTime time.Time, retSource DateSource, retErr error) {
+	defer func() {
+		rec := recover()
+		if rec != nil {
+			retTime, retSource = time.Time{}, SourceNone
+			retErr = fmt.Errorf("catched panic while processing media: %v", rec)
+		}
+	}()
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return time.Time{}, SourceNone, errors.Wrap(err, "could not seek to start")
+	}
+	e, loc := reg.sourcedExtractor(r)
+	return e(r, loc)
+}
+
+// CaptureDate returns the capture date of the given file using the extractor for its file type. If the extractor
+// fails, the date in the file name is used, see AddFileNamePatterns, and then the modification time of the file.
+// Files too short to detect their file type are an ErrTruncatedHeader instead.
+func (reg *Registry) CaptureDate(fname string) (time.Time, error) {
+	tm, _, err := reg.captureDate(fname, false)
+	return tm, err
+}
+
+// CaptureDateWithSidecar is CaptureDate, but files without an embedded capture date use the date of their XMP sidecar
+// file, if any, before falling back to the modification time. See SidecarDate.
+func (reg *Registry) CaptureDateWithSidecar(fname string) (time.Time, error) {
+	tm, _, err := reg.captureDate(fname, true)
+	return tm, err
+}
+
+// CaptureDateWithSource is CaptureDate, but also returns where the date was read from.
+func (reg *Registry) CaptureDateWithSource(fname string) (time.Time, DateSource, error) {
+	return reg.captureDate(fname, false)
+}
+
+// CaptureDateWithSidecarAndSource is CaptureDateWithSidecar, but also returns where the date was read from.
+func (reg *Registry) CaptureDateWithSidecarAndSource(fname string) (time.Time, DateSource, error) {
+	return reg.captureDate(fname, true)
+}
+
+func (reg *Registry) captureDate(fname string, sidecar bool) (time.Time, DateSource, error) {
+	fInfo, fInfoErr := os.Stat(fname)
+	f, err := os.Open(fname)
+	if err != nil {
+		if fInfoErr == nil {
+			return fInfo.ModTime(), SourceModTime, nil
+		}
+		return time.Time{}, SourceNone, errors.Wrap(err, "failed to open or fstat file.")
+	}
+	defer f.Close()
+	tm, source, err := reg.captureDateFromReader(f)
+	if err != nil && sidecar {
+		if sidecarTime, sidecarErr := sidecarDate(fname, reg.Location()); sidecarErr == nil {
+			return sidecarTime, SourceSidecar, nil
+		}
+	}
+	if err != nil {
+		if fInfoErr == nil && fInfo.Size() < headerSize && fileType(f) == "" {
+			return time.Time{}, SourceNone, errors.Wrapf(ErrTruncatedHeader, "%s is only %d bytes", fname, fInfo.Size())
+		}
+		if tm, ok := reg.fileNameDate(fname); ok {
+			return tm, SourceFileName, nil
+		}
+		if fInfoErr == nil {
+			return fInfo.ModTime(), SourceModTime, nil
+		}
+		return time.Time{}, SourceNone, errors.Wrapf(err, "%s (%s)", noInfoFoundError, fname)
+	}
+	return tm, source, nil
+}
+
+// HasCaptureDate returns true if the meta data or the name of the given file contains a capture date, i.e. CaptureDate
+// doesn't fall back to the modification time.
+func (reg *Registry) HasCaptureDate(fname string) (bool, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return false, errors.Wrap(err, "could not open file")
+	}
+	defer f.Close()
+	_, err = reg.CaptureDateFromReader(f)
+	if err != nil {
+		_, dated := reg.fileNameDate(fname)
+		return dated, nil
+	}
+	return true, nil
+}
+
+// HasCaptureDateWithSidecar returns true if the meta data of the given file or its XMP sidecar file contains a capture
+// date, i.e. CaptureDateWithSidecar doesn't fall back to the modification time.
+func (reg *Registry) HasCaptureDateWithSidecar(fname string) (bool, error) {
+	dated, err := reg.HasCaptureDate(fname)
+	if err != nil || dated {
+		return dated, err
+	}
+	_, err = sidecarDate(fname, reg.Location())
+	return err == nil, nil
+}
+
+// HasCaptureDate is Registry.HasCaptureDate of the DefaultRegistry.
+func HasCaptureDate(fname string) (bool, error) {
+	return DefaultRegistry.HasCaptureDate(fname)
+}
+
+// HasCaptureDateWithSidecar is Registry.HasCaptureDateWithSidecar of the DefaultRegistry.
+func HasCaptureDateWithSidecar(fname string) (bool, error) {
+	return DefaultRegistry.HasCaptureDateWithSidecar(fname)
+}
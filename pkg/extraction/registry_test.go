@@ -0,0 +1,113 @@
+package extraction
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	fallbackDate := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	jpegDate := time.Date(2002, 2, 2, 0, 0, 0, 0, time.UTC)
+	reg := NewRegistry(func(r ReadSeekerAt) (time.Time, error) {
+		return fallbackDate, nil
+	})
+	reg.Register(func(r ReadSeekerAt) (time.Time, error) {
+		return jpegDate, nil
+	}, "jpg")
+	reg.Register(func(r ReadSeekerAt) (time.Time, error) {
+		return time.Time{}, errors.New("no date")
+	}, "mp4")
+
+	tests := []struct {
+		name     string
+		expected time.Time
+	}{
+		{name: "sample1.JPG", expected: jpegDate},
+		{name: "sample3.txt", expected: fallbackDate},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tm, err := reg.CaptureDate(fixturePath(test.name))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, tm)
+		})
+	}
+
+	t.Run("modtime fallback", func(t *testing.T) {
+		content, err := os.ReadFile(fixturePath("sample2.mp4"))
+		if err != nil {
+			t.Fatalf("broken test setup: %s", err.Error())
+		}
+		fileUnderTest := writeTempFile(t, "sample.mp4", content)
+		modTime := parseTimeString(t, "2020-01-02 03:04:05 +0000 UTC")
+		assert.Nil(t, os.Chtimes(fileUnderTest, modTime, modTime))
+		tm, err := reg.CaptureDate(fileUnderTest)
+		assert.NoError(t, err)
+		assert.True(t, modTime.Equal(tm), "expected: %v, got: %v", modTime, tm)
+	})
+}
+
+func TestCaptureDateWithSource(t *testing.T) {
+	xmp := jpegSegment(jpegAPP1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta xmlns:x=\"adobe:ns:meta/\"><rdf:Description xmp:CreateDate=\"2019-04-17T13:30:44\"/></x:xmpmeta>"))
+	mp4, err := os.ReadFile(fixturePath("sample2.mp4"))
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	tests := []struct {
+		name     string
+		fname    string
+		expected DateSource
+	}{
+		{
+			name:     "date time original",
+			fname:    writeJPEG(t, nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")}),
+			expected: SourceExifDateTimeOriginal,
+		},
+		{
+			name:     "date time",
+			fname:    writeJPEG(t, []tiffEntry{asciiEntry(tagDateTime, "2019:04:17 13:30:44")}, nil),
+			expected: SourceExifDateTime,
+		},
+		{
+			name:     "xmp",
+			fname:    writeTempFile(t, "sample.jpg", buildJPEG(buildTiff(nil, nil), xmp)),
+			expected: SourceXMP,
+		},
+		{name: "video", fname: fixturePath("sample4.webm"), expected: SourceVideo},
+		{name: "file name", fname: writeTempFile(t, "VID-20190417-WA0001.mp4", mp4), expected: SourceFileName},
+		{
+			name:     "meta data before file name",
+			fname:    writeTempFile(t, "IMG-20190417-WA0001.jpg", buildJPEG(buildTiff(nil, nil), xmp)),
+			expected: SourceXMP,
+		},
+		{name: "modtime", fname: fixturePath("sample2.mp4"), expected: SourceModTime},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, source, err := CaptureDateWithSource(test.fname)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, source, "got %s", source)
+			assert.Equal(t, test.expected != SourceModTime, source.FromMetadata())
+		})
+	}
+
+	t.Run("registered extractor", func(t *testing.T) {
+		reg := NewRegistry(func(r ReadSeekerAt) (time.Time, error) {
+			return time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), nil
+		})
+		_, source, err := reg.CaptureDateWithSource(fixturePath("sample1.JPG"))
+		assert.NoError(t, err)
+		assert.Equal(t, SourceExtractor, source)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, source, err := CaptureDateWithSource(fixturePath("sample-not-exist"))
+		assert.Error(t, err)
+		assert.Equal(t, SourceNone, source)
+		assert.Equal(t, "none", source.String())
+	})
+}
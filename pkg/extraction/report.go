@@ -0,0 +1,87 @@
+package extraction
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SourceReport counts the capture dates of media files by where they were read from, e.g. to judge whether a directory
+// is well dated before sorting it.
+type SourceReport struct {
+	// Files is the number of media files with a capture date
+	Files int
+	// BySource is the number of media files per source of their capture date
+	BySource map[DateSource]int
+	// ModTimeSamples are the first files dated from their modification time
+	ModTimeSamples []string
+	// NotMedia is the number of files which aren't media files
+	NotMedia int
+	// Failed maps the files whose capture date couldn't be read to their error
+	Failed map[string]error
+
+	maxSamples int
+}
+
+// NewSourceReport returns an empty SourceReport keeping up to maxSamples files dated from their modification time.
+func NewSourceReport(maxSamples int) *SourceReport {
+	return &SourceReport{BySource: make(map[DateSource]int), Failed: make(map[string]error), maxSamples: maxSamples}
+}
+
+// Add counts the result of CaptureDateWithSource for the given media file.
+func (r *SourceReport) Add(fname string, source DateSource, err error) {
+	if err != nil {
+		r.Failed[fname] = err
+		return
+	}
+	r.Files++
+	r.BySource[source]++
+	if source == SourceModTime && len(r.ModTimeSamples) < r.maxSamples {
+		r.ModTimeSamples = append(r.ModTimeSamples, fname)
+	}
+}
+
+// Write prints the breakdown by source, most frequent first, followed by the sampled files dated from their
+// modification time and the failures.
+func (r SourceReport) Write(w io.Writer) error {
+	sources := make([]DateSource, 0, len(r.BySource))
+	for s := range r.BySource {
+		sources = append(sources, s)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if r.BySource[sources[i]] != r.BySource[sources[j]] {
+			return r.BySource[sources[i]] > r.BySource[sources[j]]
+		}
+		return sources[i] < sources[j]
+	})
+	counts := make([]string, 0, len(sources))
+	for _, s := range sources {
+		counts = append(counts, fmt.Sprintf("%d from %s", r.BySource[s], s))
+	}
+	lines := []string{fmt.Sprintf("%d media files dated: %s", r.Files, strings.Join(counts, ", "))}
+	if len(r.ModTimeSamples) > 0 {
+		lines = append(lines, "dated from their modification time:")
+		for _, f := range r.ModTimeSamples {
+			lines = append(lines, "  "+f)
+		}
+		if more := r.BySource[SourceModTime] - len(r.ModTimeSamples); more > 0 {
+			lines = append(lines, fmt.Sprintf("  and %d more", more))
+		}
+	}
+	failed := make([]string, 0, len(r.Failed))
+	for f := range r.Failed {
+		failed = append(failed, f)
+	}
+	sort.Strings(failed)
+	for _, f := range failed {
+		lines = append(lines, fmt.Sprintf("failed: %s: %v", f, r.Failed[f]))
+	}
+	lines = append(lines, fmt.Sprintf("%d not media, %d failed", r.NotMedia, len(r.Failed)))
+	for _, l := range lines {
+		if _, err := fmt.Fprintln(w, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
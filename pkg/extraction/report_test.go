@@ -0,0 +1,34 @@
+package extraction
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceReport(t *testing.T) {
+	r := NewSourceReport(2)
+	r.Add("a.jpg", SourceExifDateTimeOriginal, nil)
+	r.Add("b.jpg", SourceExifDateTimeOriginal, nil)
+	r.Add("c.jpg", SourceModTime, nil)
+	r.Add("d.mp4", SourceVideo, nil)
+	r.Add("e.jpg", SourceModTime, nil)
+	r.Add("f.png", SourceModTime, nil)
+	r.Add("g.jpg", SourceNone, errors.New("broken"))
+	r.NotMedia++
+
+	assert.Equal(t, 6, r.Files)
+	assert.Equal(t, []string{"c.jpg", "e.jpg"}, r.ModTimeSamples)
+	var buf bytes.Buffer
+	assert.NoError(t, r.Write(&buf))
+	assert.Equal(t, `6 media files dated: 3 from modtime, 2 from exif-datetime-original, 1 from video
+dated from their modification time:
+  c.jpg
+  e.jpg
+  and 1 more
+failed: g.jpg: broken
+1 not media, 1 failed
+`, buf.String())
+}
@@ -0,0 +1,51 @@
+package extraction
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxSidecarSize limits how much of a sidecar file is read
+const maxSidecarSize = 1 << 20
+
+// sidecarNames returns the possible names of the XMP sidecar file of the given media file in the order of preference.
+// Lightroom replaces the extension of the media file, other tools append the extension.
+func sidecarNames(fname string) []string {
+	base := strings.TrimSuffix(fname, filepath.Ext(fname))
+	return []string{base + ".xmp", base + ".XMP", fname + ".xmp", fname + ".XMP"}
+}
+
+// SidecarDate returns the capture date from the XMP sidecar file of the given media file, e.g. IMG_1234.xmp or
+// IMG_1234.CR2.xmp for IMG_1234.CR2. Dates without a time zone are in the location of the DefaultRegistry.
+func SidecarDate(fname string) (time.Time, error) {
+	return sidecarDate(fname, DefaultRegistry.Location())
+}
+
+// sidecarDate is SidecarDate with dates without a time zone in loc.
+func sidecarDate(fname string, loc *time.Location) (time.Time, error) {
+	for _, name := range sidecarNames(fname) {
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return time.Time{}, errors.Wrap(err, "could not open sidecar file")
+		}
+		packet, err := io.ReadAll(io.LimitReader(f, maxSidecarSize))
+		f.Close()
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "could not read sidecar file %s", name)
+		}
+		tm, err := xmpPacketDate(packet, loc)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid sidecar file %s", name)
+		}
+		return tm, nil
+	}
+	return time.Time{}, errors.Errorf("no sidecar file for %s", fname)
+}
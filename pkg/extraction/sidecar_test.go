@@ -0,0 +1,68 @@
+package extraction
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureDateWithSidecar(t *testing.T) {
+	const packet = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description exif:DateTimeOriginal="2019-04-17T13:30:44Z"/></x:xmpmeta>`
+	sidecarDate := time.Date(2019, 4, 17, 13, 30, 44, 0, time.UTC)
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	content, err := os.ReadFile(fixturePath("sample2.mp4"))
+	if err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+
+	tests := []struct {
+		name          string
+		sidecar       string
+		packet        string
+		expected      time.Time
+		source        DateSource
+		expectedError string
+	}{
+		{name: "replaced extension", sidecar: "IMG_1234.xmp", packet: packet, expected: sidecarDate, source: SourceSidecar},
+		{name: "appended extension", sidecar: "IMG_1234.mp4.XMP", packet: packet, expected: sidecarDate, source: SourceSidecar},
+		{name: "no sidecar", expected: modTime, source: SourceModTime, expectedError: "no sidecar file"},
+		{name: "invalid sidecar", sidecar: "IMG_1234.xmp", packet: "<x:xmpmeta/>", expected: modTime, source: SourceModTime, expectedError: "invalid sidecar file"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fname := writeTempFile(t, "IMG_1234.mp4", content)
+			assert.NoError(t, os.Chtimes(fname, modTime, modTime))
+			if test.sidecar != "" {
+				assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(fname), test.sidecar), []byte(test.packet), 0644))
+			}
+
+			tm, err := SidecarDate(fname)
+			if test.expectedError != "" {
+				assert.ErrorContains(t, err, test.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, test.expected.Equal(tm), "expected: %v, got: %v", test.expected, tm)
+			}
+
+			tm, err = CaptureDateWithSidecar(fname)
+			assert.NoError(t, err)
+			assert.True(t, test.expected.Equal(tm), "expected: %v, got: %v", test.expected, tm)
+
+			_, source, err := CaptureDateWithSidecarAndSource(fname)
+			assert.NoError(t, err)
+			assert.Equal(t, test.source, source)
+		})
+	}
+
+	t.Run("embedded date is preferred", func(t *testing.T) {
+		fname := fixturePath("sample1.JPG")
+		expected, err := CaptureDate(fname)
+		assert.NoError(t, err)
+		tm, err := CaptureDateWithSidecar(fname)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, tm)
+	})
+}
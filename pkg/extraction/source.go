@@ -0,0 +1,79 @@
+package extraction
+
+import "time"
+
+// DateSource is where a capture date was read from.
+type DateSource int
+
+const (
+	// SourceNone means no date was found
+	SourceNone DateSource = iota
+	// SourceExifDateTimeOriginal is the EXIF DateTimeOriginal field
+	SourceExifDateTimeOriginal
+	// SourceExifDateTime is the EXIF DateTime field, which is usually the time of the last modification
+	SourceExifDateTime
+	// SourceXMP is the embedded XMP packet
+	SourceXMP
+	// SourceVideo is the meta data of a video container, e.g. the DateUTC element of Matroska
+	SourceVideo
+	// SourcePNGText is the Creation Time text of a PNG file
+	SourcePNGText
+	// SourceSidecar is an XMP sidecar file, see SidecarDate
+	SourceSidecar
+	// SourceExtractor is an extractor added by Registry.Register
+	SourceExtractor
+	// SourceFileName is a date in the file name, see Registry.AddFileNamePatterns
+	SourceFileName
+	// SourceModTime is the modification time of the file, i.e. no date was found in the meta data
+	SourceModTime
+)
+
+var dateSourceNames = map[DateSource]string{
+	SourceNone:                 "none",
+	SourceExifDateTimeOriginal: "exif-datetime-original",
+	SourceExifDateTime:         "exif-datetime",
+	SourceXMP:                  "xmp",
+	SourceVideo:                "video",
+	SourcePNGText:              "png-text",
+	SourceSidecar:              "sidecar",
+	SourceExtractor:            "extractor",
+	SourceFileName:             "filename",
+	SourceModTime:              "modtime",
+}
+
+// String returns the name of the source, e.g. exif-datetime-original.
+func (s DateSource) String() string {
+	if name, found := dateSourceNames[s]; found {
+		return name
+	}
+	return "unknown"
+}
+
+// FromMetadata returns true if the date was read from the meta data of the file, its sidecar file or its name, i.e. it
+// wasn't guessed from the modification time.
+func (s DateSource) FromMetadata() bool {
+	return s != SourceNone && s != SourceModTime
+}
+
+// sourcedExtractor is an Extractor which also reports where the date was read from. Dates without a time zone are in
+// loc.
+type sourcedExtractor func(r ReadSeekerAt, loc *time.Location) (time.Time, DateSource, error)
+
+// withSource returns a sourcedExtractor reporting the given source for all dates of e.
+func withSource(e Extractor, source DateSource) sourcedExtractor {
+	return func(r ReadSeekerAt, _ *time.Location) (time.Time, DateSource, error) {
+		tm, err := e(r)
+		if err != nil {
+			return time.Time{}, SourceNone, err
+		}
+		return tm, source, nil
+	}
+}
+
+// extractor drops the source of the dates. Dates without a time zone are in loc.
+func (e sourcedExtractor) extractor(loc *time.Location) Extractor {
+	return func(r ReadSeekerAt) (time.Time, error) {
+		tm, _, err := e(r, loc)
+		return tm, err
+	}
+}
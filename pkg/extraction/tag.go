@@ -0,0 +1,58 @@
+package extraction
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/xor-gate/goexif2/exif"
+	"github.com/xor-gate/goexif2/tiff"
+)
+
+// Tag returns the value of the given EXIF field of the JPEG or TIFF file, e.g. "Model" or "LensModel". The value is
+// sanitized to be used in file names: all characters except letters, digits, dots and dashes are replaced by dashes.
+func Tag(fname string, fieldName string) (_ string, retErr error) {
+	defer recoverExif(&retErr)
+	f, err := os.Open(fname)
+	if err != nil {
+		return "", errors.Wrap(err, "could not open file")
+	}
+	defer f.Close()
+	x, err := decodeExif(f)
+	if err != nil {
+		return "", errors.Wrap(err, "could not decode exif meta data")
+	}
+	tag, err := x.Get(exif.FieldName(fieldName))
+	if err != nil {
+		return "", err
+	}
+	val := tag.String()
+	if tag.Format() == tiff.StringVal {
+		val, err = tag.StringVal()
+		if err != nil {
+			return "", errors.Wrapf(err, "could not read %s", fieldName)
+		}
+	}
+	return sanitizeTag(val), nil
+}
+
+// sanitizeTag replaces all characters which aren't safe in file names by dashes. Consecutive dashes are collapsed and
+// leading or trailing dashes are removed. Leading dots are removed as well, so that the value is neither a hidden file
+// nor a relative path like "..".
+func sanitizeTag(val string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.TrimSpace(val) {
+		safe := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.'
+		if !safe {
+			dash = true
+			continue
+		}
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteRune(c)
+	}
+	return strings.TrimLeft(b.String(), ".-")
+}
@@ -0,0 +1,49 @@
+package extraction
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTag(t *testing.T) {
+	fname := writeJPEG(t, []tiffEntry{asciiEntry(0x0110, " Canon EOS 5D/Mark  II ")}, nil)
+	model, err := Tag(fname, "Model")
+	assert.NoError(t, err)
+	assert.Equal(t, "Canon-EOS-5D-Mark-II", model)
+
+	_, err = Tag(fname, "LensModel")
+	assert.Error(t, err)
+	_, err = Tag(fixturePath("sample2.mp4"), "Model")
+	assert.Error(t, err)
+}
+
+func TestMalformedExifPointer(t *testing.T) {
+	tiff := buildTiff([]tiffEntry{asciiEntry(0x010f, "Canon")}, nil)
+	// the Exif IFD pointer, the second entry of IFD0, has no value, which panics in goexif2
+	binary.LittleEndian.PutUint32(tiff[8+2+12+4:], 0)
+	fname := writeTempFile(t, "sample.jpg", buildJPEG(tiff))
+
+	_, err := Tag(fname, "Make")
+	assert.ErrorContains(t, err, "catched panic")
+	_, err = Inspect(fname)
+	assert.ErrorContains(t, err, "catched panic")
+	_, err = Orientation(fname)
+	assert.ErrorContains(t, err, "catched panic")
+}
+
+func TestSanitizeTag(t *testing.T) {
+	for val, expected := range map[string]string{
+		"NIKON D750":      "NIKON-D750",
+		"EF24-105mm f/4L": "EF24-105mm-f-4L",
+		`..\/..`:          "",
+		"..":              "",
+		".hidden":         "hidden",
+		"f/1.8":           "f-1.8",
+		"---":             "",
+		"":                "",
+	} {
+		assert.Equal(t, expected, sanitizeTag(val), val)
+	}
+}
@@ -0,0 +1,64 @@
+package extraction
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	_ "image/png" // register the png decoder for the full decode fallback
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Thumbnail returns a JPEG thumbnail of the given image. The thumbnail embedded in the EXIF data is preferred. If
+// there is none, the full image is decoded and scaled down to fit into a square of size pixels.
+func Thumbnail(fname string, size int) ([]byte, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open file to extract thumbnail")
+	}
+	defer f.Close()
+	if x, err := decodeExif(f); err == nil {
+		if thumb, err := x.JpegThumbnail(); err == nil && len(thumb) > 0 {
+			return thumb, nil
+		}
+		if preview, err := x.PreviewImage(); err == nil && len(preview) > 0 {
+			return preview, nil
+		}
+	}
+	_, err = f.Seek(0, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not rewind file")
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode image")
+	}
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, scaleDown(img, size), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode thumbnail")
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown scales the image with nearest neighbour sampling to fit into a square of size pixels. Smaller images are
+// returned as is.
+func scaleDown(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	if b.Dx() <= size && b.Dy() <= size {
+		return img
+	}
+	w, h := size, b.Dy()*size/b.Dx()
+	if b.Dy() > b.Dx() {
+		w, h = b.Dx()*size/b.Dy(), size
+	}
+	w, h = max(w, 1), max(h, 1)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	return dst
+}
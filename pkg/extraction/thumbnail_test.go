@@ -0,0 +1,41 @@
+package extraction
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnail(t *testing.T) {
+	thumb, err := Thumbnail(fixturePath("sample1.JPG"), 160)
+	assert.NoError(t, err)
+	_, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+}
+
+func TestThumbnailFullDecodeFallback(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("broken test setup: %s", err.Error())
+	}
+	fileUnderTest := writeTempFile(t, "plain.jpg", buf.Bytes())
+
+	thumb, err := Thumbnail(fileUnderTest, 100)
+	assert.NoError(t, err)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(thumb))
+	assert.NoError(t, err)
+	assert.Equal(t, 100, cfg.Width)
+	assert.Equal(t, 50, cfg.Height)
+
+	_, err = Thumbnail(fixturePath("sample3.txt"), 100)
+	assert.EqualError(t, err, "could not decode image: image: unknown format")
+}
@@ -0,0 +1,72 @@
+package extraction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// webpMaxChunkLength limits the chunks read into memory
+const webpMaxChunkLength = 16 << 20
+
+// webpDate returns the capture date from the EXIF chunk of the given WebP file and falls back to the XMP chunk.
+func webpDate(r ReadSeekerAt, loc *time.Location) (time.Time, DateSource, error) {
+	exifData, xmpData, err := webpMetadata(r)
+	if err != nil {
+		return time.Time{}, SourceNone, err
+	}
+	if exifData != nil {
+		// some writers keep the intro of the JPEG APP1 segment
+		exifData = bytes.TrimPrefix(exifData, exifHeader)
+		if tm, source, err := exifDate(bytes.NewReader(exifData), loc); err == nil {
+			return tm, source, nil
+		}
+	}
+	if xmpData != nil {
+		if tm, err := xmpPacketDate(xmpData, loc); err == nil {
+			return tm, SourceXMP, nil
+		}
+	}
+	return time.Time{}, SourceNone, errors.New("no date in webp meta data")
+}
+
+// webpMetadata returns the content of the EXIF and XMP chunks of the given WebP file.
+func webpMetadata(r io.ReaderAt) (exifData []byte, xmpData []byte, err error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, nil, errors.Wrap(err, "could not read webp header")
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:]) != "WEBP" {
+		return nil, nil, errors.New("not a webp file")
+	}
+	end := 8 + int64(binary.LittleEndian.Uint32(header[4:8]))
+	offset := int64(len(header))
+	chunk := make([]byte, 8)
+	for offset+8 <= end {
+		if _, err := r.ReadAt(chunk, offset); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, errors.Wrap(err, "could not read webp chunk")
+		}
+		typ := string(chunk[:4])
+		length := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		if (typ == "EXIF" || typ == "XMP ") && length <= webpMaxChunkLength {
+			data := make([]byte, length)
+			if _, err := r.ReadAt(data, offset+8); err != nil {
+				return nil, nil, errors.Wrapf(err, "could not read webp %s chunk", typ)
+			}
+			if typ == "EXIF" {
+				exifData = data
+			} else {
+				xmpData = data
+			}
+		}
+		// chunks are padded to an even length
+		offset += 8 + length + length%2
+	}
+	return exifData, xmpData, nil
+}
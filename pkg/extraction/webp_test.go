@@ -0,0 +1,77 @@
+package extraction
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureDateWebP(t *testing.T) {
+	tiffData := buildTiff(nil, []tiffEntry{asciiEntry(tagDateTimeOriginal, "2019:04:17 13:30:44")})
+	// odd length to test the padding
+	image := webpChunk("VP8 ", []byte{1, 2, 3})
+	tests := []struct {
+		name          string
+		chunks        [][]byte
+		expected      string
+		source        DateSource
+		expectedError string
+	}{
+		{
+			name:     "exif chunk",
+			chunks:   [][]byte{image, webpChunk("EXIF", tiffData)},
+			expected: "2019-04-17T13:30:44",
+			source:   SourceExifDateTimeOriginal,
+		},
+		{
+			name:     "exif chunk with app1 intro",
+			chunks:   [][]byte{image, webpChunk("EXIF", append([]byte("Exif\x00\x00"), tiffData...))},
+			expected: "2019-04-17T13:30:44",
+			source:   SourceExifDateTimeOriginal,
+		},
+		{
+			name:     "xmp chunk",
+			chunks:   [][]byte{image, webpChunk("XMP ", []byte(`<x:xmpmeta><rdf:Description xmp:CreateDate="2019-04-17T13:30:44"/></x:xmpmeta>`))},
+			expected: "2019-04-17T13:30:44",
+			source:   SourceXMP,
+		},
+		{
+			name:          "no meta data",
+			chunks:        [][]byte{image},
+			expectedError: "no date in webp meta data",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bytes.NewReader(buildWebP(test.chunks...))
+			assert.Equal(t, "webp", fileType(r))
+			tm, source, err := DefaultRegistry.captureDateFromReader(r)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, tm.Format("2006-01-02T15:04:05"))
+			assert.Equal(t, test.source, source)
+		})
+	}
+}
+
+func webpChunk(typ string, data []byte) []byte {
+	ret := binary.LittleEndian.AppendUint32([]byte(typ), uint32(len(data)))
+	ret = append(ret, data...)
+	if len(data)%2 == 1 {
+		ret = append(ret, 0)
+	}
+	return ret
+}
+
+func buildWebP(chunks ...[]byte) []byte {
+	body := []byte("WEBP")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
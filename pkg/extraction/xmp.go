@@ -0,0 +1,90 @@
+package extraction
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// xmpHeader is the intro of the JPEG APP1 segment holding the XMP packet
+var xmpHeader = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// xmpDateFields are the XMP properties holding the capture date in the order of preference. Each property can be
+// serialized as attribute or as element.
+var xmpDateFields = []*regexp.Regexp{
+	xmpProperty("exif:DateTimeOriginal"),
+	xmpProperty("photoshop:DateCreated"),
+	xmpProperty("xmp:CreateDate"),
+}
+
+// xmpDateLayouts are the ISO-8601 subsets allowed for XMP dates. Without a time zone, the date is in the default
+// location.
+var xmpDateLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func xmpProperty(name string) *regexp.Regexp {
+	n := regexp.QuoteMeta(name)
+	return regexp.MustCompile(n + `\s*=\s*"([^"]*)"|<` + n + `>\s*([^<]*?)\s*</` + n + `>`)
+}
+
+// xmpDate returns the capture date from the XMP packet of the given JPEG file. Dates without a time zone are in loc.
+func xmpDate(r io.ReaderAt, loc *time.Location) (time.Time, error) {
+	header := make([]byte, 2)
+	_, err := r.ReadAt(header, 0)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "could not read file header")
+	}
+	if header[0] != jpegMarker || header[1] != jpegSOI {
+		return time.Time{}, errors.New("not a jpeg file")
+	}
+	section, err := jpegAPP1Section(r, 2, xmpHeader)
+	if err != nil {
+		return time.Time{}, err
+	}
+	packet, err := io.ReadAll(section)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "could not read xmp packet")
+	}
+	return xmpPacketDate(packet, loc)
+}
+
+// xmpPacketDate extracts the capture date from the given XMP packet. Dates without a time zone are in loc.
+func xmpPacketDate(packet []byte, loc *time.Location) (time.Time, error) {
+	if !bytes.Contains(packet, []byte("<x:xmpmeta")) {
+		return time.Time{}, errors.New("no xmpmeta element in xmp packet")
+	}
+	for _, field := range xmpDateFields {
+		m := field.FindSubmatch(packet)
+		if m == nil {
+			continue
+		}
+		val := string(m[1])
+		if val == "" {
+			val = string(m[2])
+		}
+		if tm, err := parseXMPDate(val, loc); err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, errors.New("no date in xmp packet")
+}
+
+// parseXMPDate parses the given ISO-8601 date. Dates without a time zone are in loc.
+func parseXMPDate(val string, loc *time.Location) (time.Time, error) {
+	for _, layout := range xmpDateLayouts {
+		if tm, err := time.ParseInLocation(layout, val, loc); err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid xmp date '%s'", val)
+}
@@ -0,0 +1,76 @@
+package extraction
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXMPPacketDate(t *testing.T) {
+	tests := []struct {
+		name          string
+		packet        string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "create date attribute",
+			packet:   `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description xmp:CreateDate="2019-04-17T13:30:44+02:00"/></x:xmpmeta>`,
+			expected: "2019-04-17T13:30:44+02:00",
+		},
+		{
+			name:     "date created element",
+			packet:   "<x:xmpmeta xmlns:x=\"adobe:ns:meta/\"><rdf:Description>\n <photoshop:DateCreated> 2019-04-17T13:30:44.25Z </photoshop:DateCreated>\n</rdf:Description></x:xmpmeta>",
+			expected: "2019-04-17T13:30:44Z",
+		},
+		{
+			name: "original date is preferred",
+			packet: `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description xmp:CreateDate="2020-01-01T00:00:00Z"
+				exif:DateTimeOriginal="2019-04-17T13:30:44-05:00"/></x:xmpmeta>`,
+			expected: "2019-04-17T13:30:44-05:00",
+		},
+		{
+			name:     "invalid date is skipped",
+			packet:   `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description photoshop:DateCreated="yesterday" xmp:CreateDate="2019-04-17T13:30Z"/></x:xmpmeta>`,
+			expected: "2019-04-17T13:30:00Z",
+		},
+		{
+			name:          "no date",
+			packet:        `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description xmp:CreatorTool="foo"/></x:xmpmeta>`,
+			expectedError: "no date in xmp packet",
+		},
+		{
+			name:          "no xmpmeta",
+			packet:        `xmp:CreateDate="2019-04-17T13:30:44Z"`,
+			expectedError: "no xmpmeta element in xmp packet",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tm, err := xmpPacketDate([]byte(test.packet), time.Local)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, tm.Format(time.RFC3339))
+		})
+	}
+}
+
+func TestCaptureDateXMPFallback(t *testing.T) {
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:Description xmp:CreateDate="2019-04-17T13:30:44"/></x:xmpmeta>`
+	content := []byte{jpegMarker, jpegSOI}
+	content = append(content, jpegSegment(jpegAPP1, append(append([]byte{}, xmpHeader...), packet...))...)
+	content = append(content, jpegMarker, jpegEOI)
+	fileUnderTest := writeTempFile(t, "sample.jpg", content)
+	modTime := parseTimeString(t, "2020-01-02 03:04:05 +0000 UTC")
+	assert.Nil(t, os.Chtimes(fileUnderTest, modTime, modTime))
+
+	ts, err := CaptureDate(fileUnderTest)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, ts.Location())
+	assert.Equal(t, "20190417_133044", ts.Format("20060102_150405"))
+}
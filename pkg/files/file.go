@@ -1,16 +1,17 @@
 package files
 
 import (
+	"context"
 	"os"
 
-	"syscall"
-
 	"io"
 
-	"path"
+	"path/filepath"
 
 	"hash"
 
+	"sync"
+
 	"github.com/pkg/errors"
 )
 
@@ -25,6 +26,39 @@ func IsNormalFile(fname string) (bool, error) {
 
 // File copies src file to dst. dst is truncated or created if not present. The FileMode and Modtimes are preserved.
 func Copy(src, dst string, hFunc hash.Hash) ([]byte, error) {
+	return CopyContext(context.Background(), src, dst, hFunc)
+}
+
+// CopyContext copies src file to dst like Copy. The copy is aborted if the context is cancelled.
+func CopyContext(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+	return copyBuffer(ctx, src, dst, hFunc, nil)
+}
+
+// BufferedCopier copies files like CopyContext with buffers of a fixed size. The buffers are shared by concurrent
+// copies instead of allocating a buffer per copy.
+type BufferedCopier struct {
+	pool sync.Pool
+}
+
+// NewBufferedCopier returns a BufferedCopier with buffers of the given size in bytes, e.g. a few MB for large videos
+// on fast disks. io.Copy uses 32KB.
+func NewBufferedCopier(size int) *BufferedCopier {
+	size = max(size, 1)
+	return &BufferedCopier{pool: sync.Pool{New: func() any {
+		buf := make([]byte, size)
+		return &buf
+	}}}
+}
+
+// CopyContext copies src file to dst like CopyContext using a pooled buffer.
+func (b *BufferedCopier) CopyContext(ctx context.Context, src, dst string, hFunc hash.Hash) ([]byte, error) {
+	buf := b.pool.Get().(*[]byte)
+	defer b.pool.Put(buf)
+	return copyBuffer(ctx, src, dst, hFunc, *buf)
+}
+
+// copyBuffer copies src file to dst using the given buffer. A nil buffer is allocated by io.CopyBuffer.
+func copyBuffer(ctx context.Context, src, dst string, hFunc hash.Hash, buf []byte) ([]byte, error) {
 	fInfo, err := os.Stat(src)
 	if err != nil {
 		return nil, errors.Wrap(err, "can not get file info of src")
@@ -50,7 +84,7 @@ func Copy(src, dst string, hFunc hash.Hash) ([]byte, error) {
 	}
 	defer dstFile.Close()
 	dstWriter := io.MultiWriter(dstFile, hFunc)
-	_, err = io.Copy(dstWriter, srcFile)
+	_, err = io.CopyBuffer(dstWriter, contextReader{ctx: ctx, r: srcFile}, buf)
 	if err != nil {
 		return nil, errors.Wrap(err, "error while copying file")
 	}
@@ -61,24 +95,49 @@ func Copy(src, dst string, hFunc hash.Hash) ([]byte, error) {
 	return hFunc.Sum(nil), dstFile.Sync()
 }
 
-// getFreeDiskSize returns the available disk size in bytes
-func getFreeDiskSize(dir string) (uint64, error) {
-	var stat syscall.Statfs_t
+// contextReader fails reading once the context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// Hash computes the checksum of the given file with the given hash function.
+func Hash(fname string, hFunc hash.Hash) ([]byte, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not open file")
+	}
+	defer f.Close()
+	_, err = io.Copy(hFunc, f)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while hashing file")
+	}
+	return hFunc.Sum(nil), nil
+}
+
+// freeDiskSizeDir returns the directory whose device is probed for free space of the given path.
+func freeDiskSizeDir(dir string) (string, error) {
 	fInfo, err := os.Stat(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			dir = path.Dir(dir)
-		} else {
-			return 0, errors.Wrap(err, "can not get file info of dir")
+			return filepath.Dir(dir), nil
 		}
+		return "", errors.Wrap(err, "can not get file info of dir")
 	} else if !fInfo.IsDir() {
-		dir = path.Dir(dir)
-	}
-	err = syscall.Statfs(dir, &stat)
-	if err != nil {
-		return 0, errors.Wrap(err, "failed syscall Statfs")
+		return filepath.Dir(dir), nil
 	}
+	return dir, nil
+}
 
-	// Available blocks * size per block = available space in bytes
-	return stat.Bavail * uint64(stat.Bsize), nil
+// FreeDiskSize returns the available disk size in bytes of the device storing the given directory. If the directory
+// doesn't exist, its parent is probed.
+func FreeDiskSize(dir string) (uint64, error) {
+	return getFreeDiskSize(dir)
 }
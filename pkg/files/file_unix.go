@@ -0,0 +1,58 @@
+//go:build unix
+
+package files
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// getFreeDiskSize returns the available disk size in bytes
+func getFreeDiskSize(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	dir, err := freeDiskSizeDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	err = syscall.Statfs(dir, &stat)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed syscall Statfs")
+	}
+
+	// Available blocks * size per block = available space in bytes
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+// SameDevice returns true if both given paths are stored on the same device.
+func SameDevice(a, b string) (bool, error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, errors.Wrap(err, "can not get file info of a")
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, errors.Wrap(err, "can not get file info of b")
+	}
+	aStat, aOk := aInfo.Sys().(*syscall.Stat_t)
+	bStat, bOk := bInfo.Sys().(*syscall.Stat_t)
+	if !aOk || !bOk {
+		return false, nil
+	}
+	return aStat.Dev == bStat.Dev, nil
+}
+
+// lockFile takes the exclusive flock of the file without waiting.
+func lockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return ErrLocked
+	}
+	return errors.Wrap(err, "failed syscall Flock")
+}
+
+// unlockFile releases the flock of the file.
+func unlockFile(f *os.File) error {
+	return errors.Wrap(syscall.Flock(int(f.Fd()), syscall.LOCK_UN), "failed syscall Flock")
+}
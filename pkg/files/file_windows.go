@@ -0,0 +1,48 @@
+//go:build windows
+
+package files
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+)
+
+// getFreeDiskSize returns the available disk size in bytes
+func getFreeDiskSize(dir string) (uint64, error) {
+	dir, err := freeDiskSizeDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	dirPtr, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid directory name")
+	}
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+	err = windows.GetDiskFreeSpaceEx(dirPtr, &freeBytesAvailable, &totalBytes, &totalFreeBytes)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed syscall GetDiskFreeSpaceEx")
+	}
+	return freeBytesAvailable, nil
+}
+
+// SameDevice returns true if both given paths are stored on the same device. Windows doesn't expose the device of a
+// file via os.Stat, thus it always returns false.
+func SameDevice(a, b string) (bool, error) {
+	return false, nil
+}
+
+// lockFile takes the exclusive lock of the first byte of the file without waiting.
+func lockFile(f *os.File) error {
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &windows.Overlapped{})
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return ErrLocked
+	}
+	return errors.Wrap(err, "failed syscall LockFileEx")
+}
+
+// unlockFile releases the lock of the file.
+func unlockFile(f *os.File) error {
+	return errors.Wrap(windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{}), "failed syscall UnlockFileEx")
+}
@@ -0,0 +1,41 @@
+package files
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrLocked is returned by TryLock if another process holds the lock.
+var ErrLocked = errors.New("locked by another process")
+
+// FileLock is an exclusive advisory lock of a file.
+type FileLock struct {
+	f *os.File
+}
+
+// TryLock takes the exclusive advisory lock of the given file without waiting. The file is created if it doesn't
+// exist. ErrLocked is returned if another process holds the lock. The lock is released by Unlock or when the process
+// exits.
+func TryLock(fname string) (*FileLock, error) {
+	f, err := os.OpenFile(fname, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "can not open lock file")
+	}
+	err = lockFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileLock{f: f}, nil
+}
+
+// Unlock releases the lock. The lock file is kept, removing it would allow two processes to lock different files.
+func (l *FileLock) Unlock() error {
+	err := unlockFile(l.f)
+	closeErr := l.f.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}